@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
@@ -14,6 +16,53 @@ type Config struct {
 	DBUser      string
 	DBPass      string
 	DBName      string
+	// MIGRATE_ON_START=false kada se migracije pokrecu posebno (`server migrate up`)
+	MigrateOnStart bool
+	Issuer         string
+	KeysDir        string
+	KeyRotation    time.Duration
+
+	// Javna adresa auth servisa kao OIDC provajdera (iss u ID tokenu, osnova discovery dokumenta)
+	OIDCIssuer string
+
+	// Servisi sa masinskim tokenima koji se upisuju pri startu
+	// (SERVICE_CLIENTS="id:secret:scope1 scope2", vise klijenata zarezom)
+	ServiceClients []string
+
+	// Uloge koje moraju da koriste 2FA (MFA_REQUIRED_ROLES, zarezom odvojene)
+	MFARequiredRoles []string
+
+	// Zastita logina: posle LoginFreeAttempts neuspeha raste pauza (base * 2^n, najvise
+	// LoginBackoffMax), a posle LoginMaxFailures nalog se zakljucava na LoginLockout.
+	// Brojaci se resetuju ako nema neuspeha duze od LoginFailureWindow.
+	LoginFreeAttempts  int
+	LoginMaxFailures   int
+	LoginIPMaxFailures int
+	LoginBackoffBase   time.Duration
+	LoginBackoffMax    time.Duration
+	LoginLockout       time.Duration
+	LoginFailureWindow time.Duration
+
+	// Politika lozinki: najmanja duzina i provera liste cestih/procurelih lozinki
+	// (PASSWORD_BREACHED_FILE zamenjuje ugradjenu listu, format je u pwpolicy/breached.txt)
+	PasswordMinLength     int
+	PasswordCheckBreached bool
+	PasswordBreachedFile  string
+
+	// Proksiji cijem X-Forwarded-For verujemo (TRUSTED_PROXIES, CIDR zarezom odvojeni)
+	TrustedProxies []string
+
+	// Linkovi u mejlovima vode na frontend
+	PublicURL string
+
+	// Mail: MAIL_DRIVER=smtp salje preko SMTP-a, sve ostalo upisuje u MAIL_DIR / log
+	MailDriver string
+	MailFrom   string
+	MailDir    string
+	SMTPHost   string
+	SMTPPort   int
+	SMTPUser   string
+	SMTPPass   string
 }
 
 func GetConfig() Config {
@@ -22,12 +71,77 @@ func GetConfig() Config {
 		panic(fmt.Sprintf("Couldn't parse service port: %v", err))
 	}
 
+	rotationHours, err := strconv.Atoi(envOr("JWT_KEY_ROTATION_HOURS", "720"))
+	if err != nil {
+		panic(fmt.Sprintf("Couldn't parse key rotation: %v", err))
+	}
+
+	smtpPort, err := strconv.Atoi(envOr("SMTP_PORT", "587"))
+	if err != nil {
+		panic(fmt.Sprintf("Couldn't parse SMTP port: %v", err))
+	}
+
 	return Config{
-		DBHost:      os.Getenv("DB_HOST"),
-		DBUser:      os.Getenv("DB_USER"),
-		DBPass:      os.Getenv("DB_PASS"),
-		DBName:      os.Getenv("DB_NAME"),
-		ServiceHost: os.Getenv("SERVICE_HOST"),
-		ServicePort: port,
+		DBHost:                os.Getenv("DB_HOST"),
+		DBUser:                os.Getenv("DB_USER"),
+		DBPass:                os.Getenv("DB_PASS"),
+		DBName:                os.Getenv("DB_NAME"),
+		MigrateOnStart:        envOr("MIGRATE_ON_START", "true") == "true",
+		ServiceHost:           os.Getenv("SERVICE_HOST"),
+		ServicePort:           port,
+		Issuer:                os.Getenv("ISSUER"),
+		KeysDir:               envOr("JWT_KEYS_DIR", "/var/lib/auth/keys"),
+		KeyRotation:           time.Duration(rotationHours) * time.Hour,
+		OIDCIssuer:            strings.TrimSuffix(envOr("OIDC_ISSUER", "http://localhost:8000/api/auth"), "/"),
+		PublicURL:             envOr("PUBLIC_URL", "http://localhost:3213"),
+		ServiceClients:        splitList(os.Getenv("SERVICE_CLIENTS")),
+		MFARequiredRoles:      splitList(envOr("MFA_REQUIRED_ROLES", "ADMIN")),
+		LoginFreeAttempts:     envInt("LOGIN_FREE_ATTEMPTS", 3),
+		LoginMaxFailures:      envInt("LOGIN_MAX_FAILURES", 10),
+		LoginIPMaxFailures:    envInt("LOGIN_IP_MAX_FAILURES", 100),
+		LoginBackoffBase:      time.Duration(envInt("LOGIN_BACKOFF_BASE_MS", 1000)) * time.Millisecond,
+		LoginBackoffMax:       time.Duration(envInt("LOGIN_BACKOFF_MAX_SECONDS", 300)) * time.Second,
+		LoginLockout:          time.Duration(envInt("LOGIN_LOCKOUT_MINUTES", 15)) * time.Minute,
+		LoginFailureWindow:    time.Duration(envInt("LOGIN_FAILURE_WINDOW_MINUTES", 30)) * time.Minute,
+		PasswordMinLength:     envInt("PASSWORD_MIN_LENGTH", 8),
+		PasswordCheckBreached: envOr("PASSWORD_CHECK_BREACHED", "true") == "true",
+		PasswordBreachedFile:  os.Getenv("PASSWORD_BREACHED_FILE"),
+		TrustedProxies:        splitList(os.Getenv("TRUSTED_PROXIES")),
+		MailDriver:            envOr("MAIL_DRIVER", "log"),
+		MailFrom:              envOr("MAIL_FROM", "no-reply@egov.local"),
+		MailDir:               os.Getenv("MAIL_DIR"),
+		SMTPHost:              os.Getenv("SMTP_HOST"),
+		SMTPPort:              smtpPort,
+		SMTPUser:              os.Getenv("SMTP_USER"),
+		SMTPPass:              os.Getenv("SMTP_PASS"),
+	}
+}
+
+func envOr(k, def string) string {
+	if v := os.Getenv(k); v != "" {
+		return v
+	}
+	return def
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func envInt(k string, def int) int {
+	v := os.Getenv(k)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		panic(fmt.Sprintf("Couldn't parse %s: %v", k, err))
 	}
+	return n
 }
@@ -0,0 +1,79 @@
+-- Pocetna sema auth servisa. IF NOT EXISTS da bi baza koju je ranije napravio
+-- GORM AutoMigrate mogla da se preuzme bez gubitka podataka.
+
+CREATE TABLE IF NOT EXISTS users (
+    id         bigserial PRIMARY KEY,
+    email      text        NOT NULL UNIQUE,
+    password   text        NOT NULL,
+    first_name text        NOT NULL,
+    last_name  text        NOT NULL,
+    role       text        NOT NULL,
+    status     varchar(30) NOT NULL DEFAULT 'ACTIVE'
+);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS status varchar(30) NOT NULL DEFAULT 'ACTIVE';
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id          uuid PRIMARY KEY,
+    user_id     bigint      NOT NULL,
+    family_id   uuid        NOT NULL,
+    token_hash  text        NOT NULL,
+    created_at  timestamptz,
+    expires_at  timestamptz NOT NULL,
+    revoked_at  timestamptz,
+    replaced_by uuid
+);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens (user_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens (family_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_refresh_tokens_token_hash ON refresh_tokens (token_hash);
+
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    jti        text PRIMARY KEY,
+    user_id    bigint      NOT NULL,
+    expires_at timestamptz NOT NULL,
+    revoked_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_user_id ON revoked_tokens (user_id);
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
+
+CREATE TABLE IF NOT EXISTS token_cutoffs (
+    user_id    bigint PRIMARY KEY,
+    not_before timestamptz NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+    id         uuid PRIMARY KEY,
+    user_id    bigint      NOT NULL,
+    token_hash text        NOT NULL,
+    created_at timestamptz,
+    expires_at timestamptz NOT NULL,
+    used_at    timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_user_id ON password_reset_tokens (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_password_reset_tokens_token_hash ON password_reset_tokens (token_hash);
+
+CREATE TABLE IF NOT EXISTS user_totp (
+    user_id        bigint PRIMARY KEY,
+    secret         text   NOT NULL,
+    confirmed_at   timestamptz,
+    last_used_step bigint NOT NULL DEFAULT 0,
+    created_at     timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id         uuid PRIMARY KEY,
+    user_id    bigint NOT NULL,
+    code_hash  text   NOT NULL,
+    created_at timestamptz,
+    used_at    timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user_id ON recovery_codes (user_id);
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_code_hash ON recovery_codes (code_hash);
+
+CREATE TABLE IF NOT EXISTS login_attempts (
+    kind            varchar(10) NOT NULL,
+    key             text        NOT NULL,
+    failures        bigint      NOT NULL DEFAULT 0,
+    last_failure_at timestamptz NOT NULL,
+    locked_until    timestamptz,
+    PRIMARY KEY (kind, key)
+);
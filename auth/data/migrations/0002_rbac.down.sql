@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS user_dorm_scopes;
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS roles;
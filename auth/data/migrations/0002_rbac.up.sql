@@ -0,0 +1,21 @@
+-- Uloge kao skupovi dozvola; podrazumevane uloge upisuje rbac.Seed.
+
+CREATE TABLE IF NOT EXISTS roles (
+    name        varchar(50) PRIMARY KEY,
+    description text
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_name  varchar(50)  NOT NULL REFERENCES roles (name) ON DELETE CASCADE,
+    permission varchar(100) NOT NULL,
+    PRIMARY KEY (role_name, permission)
+);
+
+CREATE TABLE IF NOT EXISTS user_dorm_scopes (
+    user_id bigint NOT NULL,
+    dorm_id uuid   NOT NULL,
+    PRIMARY KEY (user_id, dorm_id)
+);
+
+-- Auth je ranije koristio TEACHER, ostali servisi STAFF
+UPDATE users SET role = 'STAFF' WHERE role = 'TEACHER';
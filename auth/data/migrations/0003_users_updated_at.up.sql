@@ -0,0 +1,4 @@
+-- Kursor za sinhronizaciju profila u student-housing (GET /internal/users?since=)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS updated_at timestamptz;
+UPDATE users SET updated_at = now() WHERE updated_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_users_updated_at ON users (updated_at);
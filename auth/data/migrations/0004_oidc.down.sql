@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS oidc_auth_codes;
+DROP TABLE IF EXISTS oidc_redirect_uris;
+DROP TABLE IF EXISTS oidc_clients;
@@ -0,0 +1,31 @@
+-- Registrovani OIDC klijenti i jednokratni authorization code-ovi
+
+CREATE TABLE IF NOT EXISTS oidc_clients (
+    client_id   varchar(100) PRIMARY KEY,
+    name        text        NOT NULL,
+    secret_hash text,
+    public      boolean     NOT NULL DEFAULT false,
+    scopes      text        NOT NULL,
+    created_at  timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS oidc_redirect_uris (
+    client_id varchar(100) NOT NULL REFERENCES oidc_clients (client_id) ON DELETE CASCADE,
+    uri       text         NOT NULL,
+    PRIMARY KEY (client_id, uri)
+);
+
+CREATE TABLE IF NOT EXISTS oidc_auth_codes (
+    code_hash      text PRIMARY KEY,
+    client_id      varchar(100) NOT NULL REFERENCES oidc_clients (client_id) ON DELETE CASCADE,
+    user_id        bigint       NOT NULL,
+    redirect_uri   text         NOT NULL,
+    scope          text         NOT NULL,
+    nonce          text,
+    code_challenge text         NOT NULL,
+    auth_time      timestamptz  NOT NULL,
+    expires_at     timestamptz  NOT NULL,
+    used_at        timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_oidc_auth_codes_client_id ON oidc_auth_codes (client_id);
+CREATE INDEX IF NOT EXISTS idx_oidc_auth_codes_expires_at ON oidc_auth_codes (expires_at);
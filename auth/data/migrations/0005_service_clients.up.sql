@@ -0,0 +1,9 @@
+-- Servisi koji dobijaju masinske tokene (OAuth2 client credentials)
+
+CREATE TABLE IF NOT EXISTS service_clients (
+    client_id   varchar(100) PRIMARY KEY,
+    name        text        NOT NULL,
+    secret_hash text        NOT NULL,
+    scopes      text        NOT NULL,
+    created_at  timestamptz
+);
@@ -0,0 +1,14 @@
+-- Licni API kljucevi za open-data (potrosnju po kljucu broji open-data)
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id         uuid PRIMARY KEY,
+    user_id    bigint      NOT NULL,
+    name       text        NOT NULL,
+    prefix     varchar(20) NOT NULL,
+    key_hash   text        NOT NULL,
+    tier       varchar(20) NOT NULL,
+    created_at timestamptz,
+    revoked_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_key_hash ON api_keys (key_hash);
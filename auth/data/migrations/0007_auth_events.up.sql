@@ -0,0 +1,36 @@
+-- Bezbednosni dnevnik (login, registracija, lozinke, uloge). Redovi se samo dodaju;
+-- hash lanac otkriva izmene, a triger odbija UPDATE/DELETE kroz aplikacionog korisnika.
+
+CREATE TABLE IF NOT EXISTS auth_events (
+    id         bigserial PRIMARY KEY,
+    created_at timestamptz NOT NULL,
+    type       varchar(40) NOT NULL,
+    outcome    varchar(20) NOT NULL,
+    reason     text        NOT NULL DEFAULT '',
+    actor_id   bigint,
+    subject_id bigint,
+    email      text        NOT NULL DEFAULT '',
+    ip         text        NOT NULL DEFAULT '',
+    user_agent text        NOT NULL DEFAULT '',
+    prev_hash  text        NOT NULL,
+    hash       text        NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_auth_events_created_at ON auth_events (created_at);
+CREATE INDEX IF NOT EXISTS idx_auth_events_subject_id ON auth_events (subject_id);
+CREATE INDEX IF NOT EXISTS idx_auth_events_actor_id ON auth_events (actor_id);
+
+CREATE OR REPLACE FUNCTION auth_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'auth_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS auth_events_no_change ON auth_events;
+CREATE TRIGGER auth_events_no_change
+    BEFORE UPDATE OR DELETE ON auth_events
+    FOR EACH ROW EXECUTE FUNCTION auth_events_append_only();
+
+DROP TRIGGER IF EXISTS auth_events_no_truncate ON auth_events;
+CREATE TRIGGER auth_events_no_truncate
+    BEFORE TRUNCATE ON auth_events
+    FOR EACH STATEMENT EXECUTE FUNCTION auth_events_append_only();
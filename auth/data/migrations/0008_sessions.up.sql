@@ -0,0 +1,23 @@
+-- Sesije (jedna po porodici refresh tokena) za pregled i odjavu sa drugih uredjaja
+
+CREATE TABLE IF NOT EXISTS sessions (
+    id           uuid PRIMARY KEY,
+    user_id      bigint      NOT NULL,
+    user_agent   text        NOT NULL DEFAULT '',
+    ip           text        NOT NULL DEFAULT '',
+    created_at   timestamptz NOT NULL,
+    last_used_at timestamptz NOT NULL,
+    last_ip      text        NOT NULL DEFAULT '',
+    expires_at   timestamptz NOT NULL,
+    revoked_at   timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions (user_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_revoked_at ON sessions (revoked_at);
+
+-- Postojeci aktivni logini postaju sesije bez uredjaja i IP adrese
+INSERT INTO sessions (id, user_id, created_at, last_used_at, expires_at)
+SELECT family_id, user_id, COALESCE(MIN(created_at), now()), COALESCE(MAX(created_at), now()), MAX(expires_at)
+FROM refresh_tokens
+WHERE revoked_at IS NULL AND expires_at > now()
+GROUP BY family_id, user_id
+ON CONFLICT (id) DO NOTHING;
@@ -0,0 +1,29 @@
+-- Uvoz spiskova upisanih studenata; redovi cuvaju ishod i indeks/fakultet za student-housing
+
+CREATE TABLE IF NOT EXISTS user_imports (
+    id         uuid PRIMARY KEY,
+    file_name  text        NOT NULL,
+    status     varchar(20) NOT NULL,
+    total      bigint      NOT NULL,
+    created    bigint      NOT NULL,
+    failed     bigint      NOT NULL,
+    created_by bigint      NOT NULL,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_user_imports_created_at ON user_imports (created_at);
+
+CREATE TABLE IF NOT EXISTS user_import_rows (
+    import_id          uuid        NOT NULL REFERENCES user_imports (id) ON DELETE CASCADE,
+    line               bigint      NOT NULL,
+    email              text        NOT NULL,
+    first_name         text        NOT NULL,
+    last_name          text        NOT NULL,
+    "index"            text        NOT NULL,
+    faculty            text        NOT NULL,
+    result             varchar(20) NOT NULL,
+    error              text        NOT NULL DEFAULT '',
+    user_id            bigint,
+    activation_sent_at timestamptz,
+    PRIMARY KEY (import_id, line)
+);
+CREATE INDEX IF NOT EXISTS idx_user_import_rows_user_id ON user_import_rows (user_id);
@@ -0,0 +1,10 @@
+CREATE OR REPLACE FUNCTION auth_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'auth_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+-- Redovi verzije 2 i obrisani redovi verzije 1 posle ovoga ne prolaze staru proveru lanca
+ALTER TABLE auth_events
+    DROP COLUMN IF EXISTS redacted_at,
+    DROP COLUMN IF EXISTS hash_version;
@@ -0,0 +1,24 @@
+-- Licni podaci (email, IP, user agent) vise nisu u hash lancu (verzija 2), pa mogu da se
+-- obrisu sa nalogom. Redovi verzije 1 su hash-ovani zajedno sa njima; kada im se podaci
+-- obrisu (redacted_at), za njih se proverava samo veza u lancu.
+
+ALTER TABLE auth_events
+    ADD COLUMN IF NOT EXISTS hash_version smallint NOT NULL DEFAULT 1,
+    ADD COLUMN IF NOT EXISTS redacted_at  timestamptz;
+ALTER TABLE auth_events ALTER COLUMN hash_version SET DEFAULT 2;
+
+-- Jedina dozvoljena izmena je jednokratno brisanje licnih podataka; ostale kolone ostaju iste
+CREATE OR REPLACE FUNCTION auth_events_append_only() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND OLD.redacted_at IS NULL AND NEW.redacted_at IS NOT NULL
+        AND NEW.email = '' AND NEW.ip = '' AND NEW.user_agent = ''
+        AND (NEW.id, NEW.created_at, NEW.type, NEW.outcome, NEW.reason, NEW.actor_id, NEW.subject_id,
+             NEW.prev_hash, NEW.hash, NEW.hash_version)
+            IS NOT DISTINCT FROM
+            (OLD.id, OLD.created_at, OLD.type, OLD.outcome, OLD.reason, OLD.actor_id, OLD.subject_id,
+             OLD.prev_hash, OLD.hash, OLD.hash_version) THEN
+        RETURN NEW;
+    END IF;
+    RAISE EXCEPTION 'auth_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,244 @@
+package keys
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Key je jedan Ed25519 par kljuceva. Fajl na disku se zove "<createdUnix>_<kid>.pem".
+type Key struct {
+	ID        string
+	Private   ed25519.PrivateKey
+	CreatedAt time.Time
+}
+
+func (k Key) Public() ed25519.PublicKey {
+	return k.Private.Public().(ed25519.PublicKey)
+}
+
+// JWK je javni kljuc u JWKS formatu (RFC 8037 za Ed25519).
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Store drzi privatne kljuceve u direktorijumu koji vidi samo auth servis.
+// Najnoviji kljuc potpisuje; stariji ostaju objavljeni dok njihovi tokeni ne isteknu.
+type Store struct {
+	mu          sync.RWMutex
+	dir         string
+	keys        []Key // najnoviji prvi
+	rotateEvery time.Duration
+	retainFor   time.Duration
+}
+
+// Open ucitava kljuceve iz dir i odmah rotira ako nema aktivnog ili je prestar.
+// retainFor treba da bude bar koliko traje najduzi token potpisan ovim kljucevima.
+func Open(dir string, rotateEvery, retainFor time.Duration) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	s := &Store{dir: dir, rotateEvery: rotateEvery, retainFor: retainFor}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	if err := s.RotateIfDue(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Signing vraca kljuc kojim se trenutno potpisuju tokeni.
+func (s *Store) Signing() Key {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.keys[0]
+}
+
+// Rotate generise novi kljuc koji odmah postaje aktivan.
+func (s *Store) Rotate() error {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(pub)
+	k := Key{
+		ID:        hex.EncodeToString(sum[:8]),
+		Private:   priv,
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return err
+	}
+	name := filepath.Join(s.dir, fmt.Sprintf("%d_%s.pem", k.CreatedAt.Unix(), k.ID))
+	if err := os.WriteFile(name, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.keys = append([]Key{k}, s.keys...)
+	s.mu.Unlock()
+	log.Printf("[keys] rotated, new kid %s", k.ID)
+	return nil
+}
+
+// RotateIfDue rotira ako je aktivni kljuc stariji od rotateEvery i brise
+// penzionisane kljuceve ciji tokeni su sigurno istekli.
+func (s *Store) RotateIfDue() error {
+	s.mu.RLock()
+	due := len(s.keys) == 0 || time.Since(s.keys[0].CreatedAt) >= s.rotateEvery
+	s.mu.RUnlock()
+	if due {
+		if err := s.Rotate(); err != nil {
+			return err
+		}
+	}
+	return s.prune()
+}
+
+// Run periodicno proverava rotaciju dok se ctx ne zatvori.
+func (s *Store) Run(ctx context.Context, every time.Duration) {
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if err := s.RotateIfDue(); err != nil {
+				log.Printf("[keys] rotation err: %v", err)
+			}
+		}
+	}
+}
+
+// JWKS vraca sve javne kljuceve koji se jos objavljuju.
+func (s *Store) JWKS() JWKSet {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := JWKSet{Keys: make([]JWK, 0, len(s.keys))}
+	for _, k := range s.keys {
+		out.Keys = append(out.Keys, JWK{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(k.Public()),
+			Kid: k.ID,
+			Alg: "EdDSA",
+			Use: "sig",
+		})
+	}
+	return out
+}
+
+// Lookup vraca javni kljuc za kid (koristi auth kada sam proverava svoje tokene).
+func (s *Store) Lookup(kid string) (ed25519.PublicKey, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, k := range s.keys {
+		if k.ID == kid {
+			return k.Public(), true
+		}
+	}
+	return nil, false
+}
+
+/* ===================== Helpers ===================== */
+
+func (s *Store) load() error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+	var keys []Key
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".pem") {
+			continue
+		}
+		k, err := readKey(filepath.Join(s.dir, e.Name()))
+		if err != nil {
+			return fmt.Errorf("key %s: %w", e.Name(), err)
+		}
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.After(keys[j].CreatedAt) })
+	s.keys = keys
+	return nil
+}
+
+// prune: kljuc i je penzionisan kada je nastao kljuc i-1; cuvamo ga jos retainFor.
+func (s *Store) prune() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keep := s.keys[:1]
+	for i := 1; i < len(s.keys); i++ {
+		retiredAt := s.keys[i-1].CreatedAt
+		if time.Since(retiredAt) < s.retainFor {
+			keep = append(keep, s.keys[i])
+			continue
+		}
+		k := s.keys[i]
+		name := filepath.Join(s.dir, fmt.Sprintf("%d_%s.pem", k.CreatedAt.Unix(), k.ID))
+		if err := os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		log.Printf("[keys] removed retired kid %s", k.ID)
+	}
+	s.keys = keep
+	return nil
+}
+
+func readKey(path string) (Key, error) {
+	base := strings.TrimSuffix(filepath.Base(path), ".pem")
+	created, kid, ok := strings.Cut(base, "_")
+	if !ok {
+		return Key{}, errors.New("bad key file name")
+	}
+	unix, err := strconv.ParseInt(created, 10, 64)
+	if err != nil {
+		return Key{}, err
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return Key{}, err
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return Key{}, errors.New("no PEM block")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return Key{}, err
+	}
+	priv, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return Key{}, errors.New("not an Ed25519 key")
+	}
+	return Key{ID: kid, Private: priv, CreatedAt: time.Unix(unix, 0).UTC()}, nil
+}
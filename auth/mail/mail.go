@@ -0,0 +1,95 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender salje poruku; implementacija se bira preko MAIL_DRIVER.
+type Sender interface {
+	Send(ctx context.Context, m Message) error
+}
+
+// New vraca SMTP sender za driver "smtp", a za sve ostalo log/file sender.
+func New(driver, host string, port int, user, pass, from, dir string) Sender {
+	if strings.EqualFold(driver, "smtp") {
+		return &SMTPSender{Host: host, Port: port, User: user, Pass: pass, From: from}
+	}
+	return &LogSender{Dir: dir, From: from}
+}
+
+/* ===================== SMTP ===================== */
+
+type SMTPSender struct {
+	Host string
+	Port int
+	User string
+	Pass string
+	From string
+}
+
+func (s *SMTPSender) Send(_ context.Context, m Message) error {
+	addr := net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+	var auth smtp.Auth
+	if s.User != "" {
+		auth = smtp.PlainAuth("", s.User, s.Pass, s.Host)
+	}
+	return smtp.SendMail(addr, auth, s.From, []string{m.To}, render(s.From, m))
+}
+
+/* ===================== Log / file ===================== */
+
+// LogSender je za lokalni razvoj: upisuje .eml fajl u Dir (ako je zadat) i loguje poruku.
+type LogSender struct {
+	Dir  string
+	From string
+}
+
+func (s *LogSender) Send(_ context.Context, m Message) error {
+	log.Printf("[mail] to=%s subject=%q\n%s", m.To, m.Subject, m.Body)
+	if s.Dir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%d_%s.eml", time.Now().UnixNano(), sanitize(m.To))
+	return os.WriteFile(filepath.Join(s.Dir, name), render(s.From, m), 0o644)
+}
+
+/* ===================== Helpers ===================== */
+
+func render(from string, m Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", m.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", m.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(m.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '@' || r == '.' || r == '-' || r == '_' || (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, strings.ToLower(s))
+}
@@ -3,10 +3,17 @@ package main
 import (
 	"auth/config"
 	"auth/data"
+	"auth/keys"
+	"auth/mail"
+	"auth/pwpolicy"
+	"auth/rbac"
 	"auth/user"
+	"context"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"log"
+	"os"
+	"time"
 )
 
 func main() {
@@ -17,21 +24,52 @@ func main() {
 	if err != nil {
 		panic(fmt.Sprintf("Failed to connect to database: %v", err))
 	}
-	// if err = data.AutoMigrate(db); err != nil {
-	// 	panic(err)
-	// }
+
+	// `server migrate [up|down N|status]` radi samo migracije i izlazi
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := data.RunMigrateCommand(context.Background(), db, os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if cfg.MigrateOnStart {
+		if _, err := data.MigrateUp(context.Background(), db); err != nil {
+			panic(err)
+		}
+	}
+	if err := rbac.Seed(db); err != nil {
+		panic(err)
+	}
+	if err := user.SeedServiceClients(db, cfg.ServiceClients); err != nil {
+		panic(err)
+	}
 
 	// Release mode
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.Default()
 
-	if err := router.SetTrustedProxies(nil); err != nil {
+	// Iza nginx-a: bez poverenja u X-Forwarded-For svi bi delili IP proksija (bitno za zastitu logina)
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
 		panic("Error setting trusted proxies")
 	}
 
+	// Kljucevi za potpisivanje; stari javni kljucevi ostaju u JWKS dok svi njima potpisani tokeni ne isteknu
+	ks, err := keys.Open(cfg.KeysDir, cfg.KeyRotation, user.MaxSignedTokenTTL())
+	if err != nil {
+		panic(fmt.Sprintf("Failed to load signing keys: %v", err))
+	}
+	go ks.Run(context.Background(), 10*time.Minute)
+
 	api := router.Group("")
 
-	user.WithUserAPI(api, db)
+	mailer := mail.New(cfg.MailDriver, cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUser, cfg.SMTPPass, cfg.MailFrom, cfg.MailDir)
+
+	passwords, err := pwpolicy.New(cfg.PasswordMinLength, cfg.PasswordCheckBreached, cfg.PasswordBreachedFile)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to load password policy: %v", err))
+	}
+
+	user.WithUserAPI(api, db, cfg, ks, mailer, passwords)
 
 	url := fmt.Sprintf("%s:%d", cfg.ServiceHost, cfg.ServicePort)
 
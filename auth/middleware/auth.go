@@ -0,0 +1,251 @@
+package middleware
+
+import (
+	"auth/keys"
+	"auth/types"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+// Kljucevi pod kojima se podaci o pozivaocu cuvaju u gin.Context
+const (
+	CtxUserID = "userID"
+	CtxRole   = "role"
+	CtxClaims = "claims"
+
+	// ID admina kada zahtev stize sa tokenom za impersonaciju
+	CtxActorID = "actorID"
+
+	ctxImpersonationAllowed = "impersonationAllowed"
+)
+
+// Claims prati token koji izdaje login. Polje ID je korisnicki id; jti je RegisteredClaims.ID.
+// Perms su dozvole uloge u trenutku izdavanja, Dorms ogranicenje na domove (prazno = bez ogranicenja).
+type Claims struct {
+	ID    uint       `json:"id"`
+	Role  types.Role `json:"role"`
+	Perms []string   `json:"perms,omitempty"`
+	Dorms []string   `json:"dorms,omitempty"`
+	// Sid je sesija (porodica refresh tokena) iz koje je token izdat
+	Sid string `json:"sid,omitempty"`
+	// Act je admin koji radi u ime korisnika (POST /impersonate/:id, RFC 8693 "act")
+	Act *Actor `json:"act,omitempty"`
+	jwt.RegisteredClaims
+}
+
+type Actor struct {
+	ID      uint   `json:"id"`
+	Subject string `json:"sub"`
+}
+
+// AllowImpersonation dozvoljava izmenu (POST/PUT/PATCH/DELETE) i sa tokenom za
+// impersonaciju. Ide ispred RequireAuth; bez nje takve rute vracaju 403.
+func AllowImpersonation(c *gin.Context) {
+	c.Set(ctxImpersonationAllowed, true)
+	c.Next()
+}
+
+// RequireAuth proverava sopstvene tokene auth servisa. Za razliku od ostalih
+// servisa, opoziv se ovde cita direktno iz baze.
+func RequireAuth(db *gorm.DB, issuer string, ks *keys.Store) gin.HandlerFunc {
+	parser := jwt.NewParser(
+		jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(issuer),
+		jwt.WithExpirationRequired(),
+	)
+
+	return func(c *gin.Context) {
+		raw, ok := BearerToken(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing bearer token"})
+			return
+		}
+
+		var claims Claims
+		if _, err := parser.ParseWithClaims(raw, &claims, func(t *jwt.Token) (any, error) {
+			kid, _ := t.Header["kid"].(string)
+			if k, ok := ks.Lookup(kid); ok {
+				return k, nil
+			}
+			return nil, errors.New("unknown kid")
+		}); err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			return
+		}
+		if claims.ID == 0 || claims.Role == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
+			return
+		}
+
+		revoked, err := IsRevoked(db, claims.ID, claims.RegisteredClaims.ID, claims.IssuedAt)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check token"})
+			return
+		}
+		if revoked {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token revoked"})
+			return
+		}
+		if claims.Sid != "" {
+			ended, err := SessionRevoked(db, claims.Sid)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check token"})
+				return
+			}
+			if ended {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "session revoked"})
+				return
+			}
+		}
+		if claims.Act != nil {
+			// Token pada i kad se adminu ugase sesije (npr. onemogucen nalog)
+			revoked, err := IsRevoked(db, claims.Act.ID, "", claims.IssuedAt)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check token"})
+				return
+			}
+			if revoked {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token revoked"})
+				return
+			}
+			if !readOnly(c.Request.Method) && !c.GetBool(ctxImpersonationAllowed) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "read-only while impersonating", "code": "IMPERSONATION_READ_ONLY"})
+				return
+			}
+			c.Set(CtxActorID, claims.Act.ID)
+		}
+
+		c.Set(CtxUserID, claims.ID)
+		c.Set(CtxRole, claims.Role)
+		c.Set(CtxClaims, claims)
+		c.Next()
+	}
+}
+
+// RequireRoles propusta samo pozivaoce cija je uloga u listi. Ide posle RequireAuth.
+func RequireRoles(roles ...types.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := Role(c)
+		for _, r := range roles {
+			if role == r {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+	}
+}
+
+// RequirePermission propusta pozivaoce ciji token nosi bar jednu od dozvola. Ide posle RequireAuth.
+func RequirePermission(perms ...types.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, p := range perms {
+			if HasPermission(c, p) {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+	}
+}
+
+func HasPermission(c *gin.Context, p types.Permission) bool {
+	cl, ok := ClaimsFrom(c)
+	if !ok {
+		return false
+	}
+	for _, have := range cl.Perms {
+		if have == string(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRevoked: token je opozvan ako mu je jti na listi ili je izdat pre korisnikovog cutoff-a.
+func IsRevoked(db *gorm.DB, userID uint, jti string, iat *jwt.NumericDate) (bool, error) {
+	if jti != "" {
+		var n int64
+		if err := db.Model(&types.RevokedToken{}).Where("jti = ?", jti).Count(&n).Error; err != nil {
+			return false, err
+		}
+		if n > 0 {
+			return true, nil
+		}
+	}
+
+	var cut types.TokenCutoff
+	if err := db.First(&cut, "user_id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	if iat == nil {
+		return true, nil
+	}
+	return !iat.Time.After(cut.NotBefore.Truncate(time.Second)), nil
+}
+
+// SessionRevoked: sesija iz koje je token izdat je odjavljena.
+func SessionRevoked(db *gorm.DB, sid string) (bool, error) {
+	var n int64
+	err := db.Model(&types.Session{}).Where("id = ? AND revoked_at IS NOT NULL", sid).Count(&n).Error
+	return n > 0, err
+}
+
+func UserID(c *gin.Context) (uint, bool) {
+	v, ok := c.Get(CtxUserID)
+	if !ok {
+		return 0, false
+	}
+	id, ok := v.(uint)
+	return id, ok
+}
+
+// ActorID vraca admina iza tokena za impersonaciju; false za obican token.
+func ActorID(c *gin.Context) (uint, bool) {
+	v, ok := c.Get(CtxActorID)
+	if !ok {
+		return 0, false
+	}
+	id, ok := v.(uint)
+	return id, ok
+}
+
+func Role(c *gin.Context) types.Role {
+	v, _ := c.Get(CtxRole)
+	r, _ := v.(types.Role)
+	return r
+}
+
+func ClaimsFrom(c *gin.Context) (Claims, bool) {
+	v, ok := c.Get(CtxClaims)
+	if !ok {
+		return Claims{}, false
+	}
+	cl, ok := v.(Claims)
+	return cl, ok
+}
+
+/* ===================== Helpers ===================== */
+
+func readOnly(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// BearerToken vraca token iz Authorization: Bearer zaglavlja.
+func BearerToken(c *gin.Context) (string, bool) {
+	h := c.GetHeader("Authorization")
+	if len(h) < 7 || !strings.EqualFold(h[:7], "Bearer ") {
+		return "", false
+	}
+	tok := strings.TrimSpace(h[7:])
+	return tok, tok != ""
+}
@@ -0,0 +1,146 @@
+package pwpolicy
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// Politika lozinki za sve putanje koje postavljaju lozinku (registracija, promena, reset).
+// Provera procurelih lozinki je potpuno offline: lista je SHA-1 podeljen na prefiks i
+// ostatak (kao HIBP range), pa ni fajl ni memorija ne sadrze lozinke u citljivom obliku.
+
+// Kodovi koje klijent prevodi u poruke
+const (
+	CodeTooShort      = "PASSWORD_TOO_SHORT"
+	CodeTooLong       = "PASSWORD_TOO_LONG"
+	CodeContainsEmail = "PASSWORD_CONTAINS_EMAIL"
+	CodeContainsName  = "PASSWORD_CONTAINS_NAME"
+	CodeBreached      = "PASSWORD_BREACHED"
+)
+
+// bcrypt koristi samo prva 72 bajta; duza lozinka bi se tiho skratila
+const maxBytes = 72
+
+// Delovi imena i email-a kraci od ovoga se ne proveravaju ("an" u "Ana" bi odbio pola lozinki)
+const minPersonalPart = 3
+
+//go:embed breached.txt
+var bundledList []byte
+
+type Violation struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Identity su podaci naloga koje lozinka ne sme da sadrzi.
+type Identity struct {
+	Email     string
+	FirstName string
+	LastName  string
+}
+
+type Policy struct {
+	minLength int
+	breached  map[string]map[string]struct{} // prefiks -> ostaci
+}
+
+// New ucitava listu iz listPath ili, ako je prazan, ugradjenu listu. checkBreached=false
+// iskljucuje proveru liste (npr. za razvoj).
+func New(minLength int, checkBreached bool, listPath string) (*Policy, error) {
+	if minLength < 1 || minLength > maxBytes {
+		return nil, fmt.Errorf("password min length must be between 1 and %d", maxBytes)
+	}
+	p := &Policy{minLength: minLength}
+	if !checkBreached {
+		return p, nil
+	}
+
+	var r io.Reader = bytes.NewReader(bundledList)
+	if listPath != "" {
+		f, err := os.Open(listPath)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	set, err := parseList(r)
+	if err != nil {
+		return nil, fmt.Errorf("breached password list: %w", err)
+	}
+	p.breached = set
+	return p, nil
+}
+
+// Check vraca sve prekrsaje (prazno = lozinka je prihvatljiva).
+func (p *Policy) Check(password string, id Identity) []Violation {
+	var out []Violation
+	if n := utf8.RuneCountInString(password); n < p.minLength {
+		out = append(out, Violation{CodeTooShort, fmt.Sprintf("password must be at least %d characters", p.minLength)})
+	}
+	if len(password) > maxBytes {
+		out = append(out, Violation{CodeTooLong, fmt.Sprintf("password must be at most %d bytes", maxBytes)})
+	}
+
+	lower := strings.ToLower(password)
+	email := strings.ToLower(strings.TrimSpace(id.Email))
+	local, _, _ := strings.Cut(email, "@")
+	if containsPart(lower, email) || containsPart(lower, local) {
+		out = append(out, Violation{CodeContainsEmail, "password must not contain your email address"})
+	}
+	if containsPart(lower, strings.ToLower(strings.TrimSpace(id.FirstName))) ||
+		containsPart(lower, strings.ToLower(strings.TrimSpace(id.LastName))) {
+		out = append(out, Violation{CodeContainsName, "password must not contain your name"})
+	}
+
+	// "Lozinka123" je jednako losa kao "lozinka123"
+	if p.isBreached(password) || p.isBreached(lower) {
+		out = append(out, Violation{CodeBreached, "this password is too common or has appeared in a data breach"})
+	}
+	return out
+}
+
+/* ===================== Helpers ===================== */
+
+func (p *Policy) isBreached(password string) bool {
+	if p.breached == nil {
+		return false
+	}
+	sum := sha1.Sum([]byte(password))
+	h := strings.ToUpper(hex.EncodeToString(sum[:]))
+	_, ok := p.breached[h[:5]][h[5:]]
+	return ok
+}
+
+func containsPart(password, part string) bool {
+	return utf8.RuneCountInString(part) >= minPersonalPart && strings.Contains(password, part)
+}
+
+// parseList cita redove "PREFIKS:OSTATAK[:broj]"; prazni redovi i # komentari se preskacu.
+func parseList(r io.Reader) (map[string]map[string]struct{}, error) {
+	set := map[string]map[string]struct{}{}
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.Split(strings.ToUpper(line), ":")
+		if len(parts) < 2 || len(parts[0]) != 5 || len(parts[1]) != 35 {
+			return nil, fmt.Errorf("line %d: want PREFIX(5):SUFFIX(35)", n)
+		}
+		if set[parts[0]] == nil {
+			set[parts[0]] = map[string]struct{}{}
+		}
+		set[parts[0]][parts[1]] = struct{}{}
+	}
+	return set, sc.Err()
+}
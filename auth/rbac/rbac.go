@@ -0,0 +1,62 @@
+package rbac
+
+import (
+	"auth/types"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Seed upisuje podrazumevane uloge (bez gazenja izmena koje je admin napravio).
+// Tabele pravi migracija 0002_rbac; seed ide posle migracija pri svakom startu.
+func Seed(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for role, perms := range types.DefaultRolePermissions {
+			def := types.RoleDefinition{Name: role}
+			res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&def)
+			if res.Error != nil {
+				return res.Error
+			}
+			// Postojece uloge ne diramo, osim ADMIN koji uvek ima ceo katalog
+			if res.RowsAffected == 0 && role != types.Admin {
+				continue
+			}
+			rows := make([]types.RolePermission, 0, len(perms))
+			for _, p := range perms {
+				rows = append(rows, types.RolePermission{RoleName: role, Permission: p})
+			}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Permissions vraca dozvole uloge.
+func Permissions(db *gorm.DB, role types.Role) ([]string, error) {
+	var perms []string
+	err := db.Model(&types.RolePermission{}).
+		Where("role_name = ?", role).
+		Order("permission").
+		Pluck("permission", &perms).Error
+	return perms, err
+}
+
+// DormScopes vraca domove na koje je korisnik ogranicen (prazno = bez ogranicenja).
+func DormScopes(db *gorm.DB, userID uint) ([]string, error) {
+	var dorms []string
+	err := db.Model(&types.UserDormScope{}).
+		Where("user_id = ?", userID).
+		Pluck("dorm_id::text", &dorms).Error
+	return dorms, err
+}
+
+func IsKnownPermission(p string) bool {
+	for _, k := range types.AllPermissions {
+		if string(k) == p {
+			return true
+		}
+	}
+	return false
+}
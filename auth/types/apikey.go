@@ -0,0 +1,56 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKeyTier odredjuje limit zahteva u open-data servisu (limite drzi open-data).
+type APIKeyTier string
+
+const (
+	TierFree     APIKeyTier = "FREE"
+	TierStandard APIKeyTier = "STANDARD"
+	TierPartner  APIKeyTier = "PARTNER"
+)
+
+var APIKeyTiers = []APIKeyTier{TierFree, TierStandard, TierPartner}
+
+// APIKey je licni kljuc developera za open-data. Cuva se samo hash; Prefix sluzi
+// da vlasnik prepozna kljuc u listi.
+type APIKey struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"ownerId"`
+	Name      string     `gorm:"not null" json:"name"`
+	Prefix    string     `gorm:"type:varchar(20);not null" json:"prefix"`
+	KeyHash   string     `gorm:"uniqueIndex;not null" json:"-"`
+	Tier      APIKeyTier `gorm:"type:varchar(20);not null" json:"tier"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"createdAt"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+}
+
+type APIKeyReq struct {
+	Name string `json:"name"`
+}
+
+type APIKeyTierReq struct {
+	Tier string `json:"tier"`
+}
+
+// APIKeyCreatedResp: kljuc se vidi samo jednom, pri kreiranju.
+type APIKeyCreatedResp struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+type APIKeyVerifyReq struct {
+	Key string `json:"key"`
+}
+
+// APIKeyInfo vraca /internal/api-keys/verify servisu koji proverava kljuc.
+type APIKeyInfo struct {
+	ID     uuid.UUID  `json:"id"`
+	UserID uint       `json:"ownerId"`
+	Tier   APIKeyTier `json:"tier"`
+}
@@ -0,0 +1,64 @@
+package types
+
+import "time"
+
+type AuthEventType string
+
+const (
+	EventLogin               AuthEventType = "LOGIN"
+	EventLoginMFA            AuthEventType = "LOGIN_2FA"
+	EventRegister            AuthEventType = "REGISTER"
+	EventPasswordChange      AuthEventType = "PASSWORD_CHANGE"
+	EventPasswordReset       AuthEventType = "PASSWORD_RESET"
+	EventPasswordForcedReset AuthEventType = "PASSWORD_FORCED_RESET"
+	EventRoleChange          AuthEventType = "ROLE_CHANGE"
+	EventImpersonationStart  AuthEventType = "IMPERSONATION_START"
+	EventImpersonationEnd    AuthEventType = "IMPERSONATION_END"
+	EventUserImport          AuthEventType = "USER_IMPORT"
+	EventUserDelete          AuthEventType = "USER_DELETE"
+)
+
+type AuthOutcome string
+
+const (
+	OutcomeSuccess AuthOutcome = "SUCCESS"
+	OutcomeFailure AuthOutcome = "FAILURE"
+	// Lozinka je tacna, ali login ceka drugi korak (2FA)
+	OutcomeChallenged AuthOutcome = "CHALLENGED"
+)
+
+// AuthEvent je red bezbednosnog dnevnika (tabela auth_events, samo dodavanje).
+// Hash = sha256(prev_hash + sadrzaj reda), pa izmena ili brisanje starijeg reda
+// prekida lanac od tog mesta (vidi GET /audit/events/verify). Email, IP i user agent
+// nisu u hash-u (od verzije 2) i brisu se sa nalogom; tada se postavlja RedactedAt.
+type AuthEvent struct {
+	ID        int64         `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time     `gorm:"not null" json:"createdAt"`
+	Type      AuthEventType `gorm:"type:varchar(40);not null" json:"type"`
+	Outcome   AuthOutcome   `gorm:"type:varchar(20);not null" json:"outcome"`
+	Reason    string        `gorm:"not null" json:"reason,omitempty"`
+	// ActorID je ko je izvrsio radnju (nil za anonimni zahtev), SubjectID ciji je nalog u pitanju
+	ActorID   *uint  `json:"actorId,omitempty"`
+	SubjectID *uint  `json:"subjectId,omitempty"`
+	Email     string `gorm:"not null" json:"email,omitempty"`
+	IP        string `gorm:"column:ip;not null" json:"ip"`
+	UserAgent string `gorm:"not null" json:"userAgent"`
+	PrevHash  string `gorm:"not null" json:"prevHash"`
+	Hash      string `gorm:"not null" json:"hash"`
+	// HashVersion: 1 = hash pokriva i licne podatke (stari redovi), 2 = samo id-jeve i dogadjaj
+	HashVersion int        `gorm:"not null;default:2" json:"hashVersion"`
+	RedactedAt  *time.Time `json:"redactedAt,omitempty"`
+}
+
+func (AuthEvent) TableName() string { return "auth_events" }
+
+// AuditChainResp je rezultat provere lanca. Head je hash poslednjeg reda; ako se
+// povremeno cuva van baze, otkriva se i brisanje najnovijih redova.
+type AuditChainResp struct {
+	OK       bool   `json:"ok"`
+	Checked  int64  `json:"checked"`
+	Head     string `json:"head"`
+	BrokenAt *int64 `json:"brokenAt,omitempty"`
+	// Redacted su redovi verzije 1 bez licnih podataka; za njih se proverava samo veza u lancu
+	Redacted int64 `json:"redacted"`
+}
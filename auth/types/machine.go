@@ -0,0 +1,36 @@
+package types
+
+import "time"
+
+// Scope-ovi masinskih tokena (client credentials). Masinski token nema korisnika ni
+// dozvole uloge, pa ga rute koje vracaju licne podatke odbijaju.
+const (
+	ScopeHousingStatsRead = "housing:stats:read"
+)
+
+var MachineScopes = []string{
+	ScopeHousingStatsRead,
+}
+
+// ServiceClient je servis (npr. open-data) koji od auth-a dobija masinske tokene.
+type ServiceClient struct {
+	ClientID   string    `gorm:"primaryKey;type:varchar(100)" json:"clientId"`
+	Name       string    `gorm:"not null" json:"name"`
+	SecretHash string    `gorm:"not null" json:"-"`
+	Scopes     string    `gorm:"not null" json:"scopes"` // razdvojeni razmakom
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"createdAt"`
+}
+
+func (ServiceClient) TableName() string { return "service_clients" }
+
+type ServiceClientReq struct {
+	ClientID string   `json:"clientId"`
+	Name     string   `json:"name"`
+	Scopes   []string `json:"scopes"`
+}
+
+// ServiceClientCreatedResp: secret se vidi samo jednom, pri registraciji.
+type ServiceClientCreatedResp struct {
+	ServiceClient
+	ClientSecret string `json:"clientSecret"`
+}
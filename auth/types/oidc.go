@@ -0,0 +1,55 @@
+package types
+
+import "time"
+
+// OIDCClient je aplikacija (biblioteka, menza...) koja prijavljuje korisnike eUprava nalogom.
+// Javni klijenti (SPA, mobilni) nemaju secret i oslanjaju se samo na PKCE.
+type OIDCClient struct {
+	ClientID   string    `gorm:"primaryKey;type:varchar(100)" json:"clientId"`
+	Name       string    `gorm:"not null" json:"name"`
+	SecretHash string    `json:"-"`
+	Public     bool      `gorm:"not null;default:false" json:"public"`
+	Scopes     string    `gorm:"not null" json:"scopes"` // razdvojeni razmakom, kao u OAuth-u
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"createdAt"`
+
+	RedirectURIs []OIDCRedirectURI `gorm:"foreignKey:ClientID;references:ClientID;constraint:OnDelete:CASCADE" json:"redirectUris"`
+}
+
+func (OIDCClient) TableName() string { return "oidc_clients" }
+
+// OIDCRedirectURI: redirect_uri iz zahteva mora tacno da se poklopi sa jednim od ovih.
+type OIDCRedirectURI struct {
+	ClientID string `gorm:"primaryKey;type:varchar(100)" json:"-"`
+	URI      string `gorm:"primaryKey" json:"uri"`
+}
+
+func (OIDCRedirectURI) TableName() string { return "oidc_redirect_uris" }
+
+// OIDCAuthCode je jednokratni authorization code; cuva se samo hash.
+type OIDCAuthCode struct {
+	CodeHash      string     `gorm:"primaryKey" json:"-"`
+	ClientID      string     `gorm:"type:varchar(100);not null;index" json:"clientId"`
+	UserID        uint       `gorm:"not null" json:"userId"`
+	RedirectURI   string     `gorm:"not null" json:"redirectUri"`
+	Scope         string     `gorm:"not null" json:"scope"`
+	Nonce         string     `json:"nonce"`
+	CodeChallenge string     `gorm:"not null" json:"-"`
+	AuthTime      time.Time  `gorm:"not null" json:"authTime"`
+	ExpiresAt     time.Time  `gorm:"not null;index" json:"expiresAt"`
+	UsedAt        *time.Time `json:"usedAt,omitempty"`
+}
+
+func (OIDCAuthCode) TableName() string { return "oidc_auth_codes" }
+
+type OIDCClientReq struct {
+	Name         string   `json:"name"`
+	RedirectURIs []string `json:"redirectUris"`
+	Scopes       []string `json:"scopes"`
+	Public       bool     `json:"public"`
+}
+
+// OIDCClientCreatedResp: secret se vidi samo jednom, pri registraciji.
+type OIDCClientCreatedResp struct {
+	OIDCClient
+	ClientSecret string `json:"clientSecret,omitempty"`
+}
@@ -0,0 +1,92 @@
+package types
+
+import "github.com/google/uuid"
+
+type Permission string
+
+const (
+	PermUserRead        Permission = "user:read"
+	PermUserWrite       Permission = "user:write"
+	PermUserImport      Permission = "user:import"
+	PermUserRole        Permission = "user:role"
+	PermUserSessions    Permission = "user:sessions"
+	PermUserImpersonate Permission = "user:impersonate"
+	PermRBACManage      Permission = "rbac:manage"
+	PermClientManage    Permission = "client:manage"
+	PermAPIKeyManage    Permission = "apikey:manage"
+	PermAuditRead       Permission = "audit:read"
+
+	PermStudentRead  Permission = "student:read"
+	PermStudentWrite Permission = "student:write"
+
+	PermDormWrite Permission = "dorm:write"
+	PermRoomWrite Permission = "room:write"
+
+	PermApplicationRead   Permission = "application:read"
+	PermApplicationSubmit Permission = "application:submit"
+	PermApplicationReview Permission = "application:review"
+	PermApplicationDelete Permission = "application:delete"
+
+	PermPaymentRead   Permission = "payment:read"
+	PermPaymentIssue  Permission = "payment:issue"
+	PermPaymentDelete Permission = "payment:delete"
+
+	PermCompetitionManage Permission = "competition:manage"
+)
+
+// AllPermissions je katalog koji admin UI nudi pri sastavljanju uloga.
+var AllPermissions = []Permission{
+	PermUserRead, PermUserWrite, PermUserImport, PermUserRole, PermUserSessions, PermUserImpersonate, PermRBACManage, PermClientManage, PermAPIKeyManage, PermAuditRead,
+	PermStudentRead, PermStudentWrite,
+	PermDormWrite, PermRoomWrite,
+	PermApplicationRead, PermApplicationSubmit, PermApplicationReview, PermApplicationDelete,
+	PermPaymentRead, PermPaymentIssue, PermPaymentDelete,
+	PermCompetitionManage,
+}
+
+// DefaultRolePermissions se upisuje pri pokretanju ako uloga jos ne postoji.
+// ADMIN uvek dobija ceo katalog, pa nove dozvole ne treba rucno dodavati.
+var DefaultRolePermissions = map[Role][]Permission{
+	Admin: AllPermissions,
+	Staff: {
+		PermStudentRead,
+		PermRoomWrite,
+		PermApplicationRead, PermApplicationReview,
+		PermPaymentRead, PermPaymentIssue,
+	},
+	Student: {
+		PermApplicationSubmit,
+	},
+}
+
+// RoleDefinition je uloga kao skup dozvola. Korisnik i dalje ima jednu ulogu (users.role).
+type RoleDefinition struct {
+	Name        Role   `gorm:"primaryKey;type:varchar(50)" json:"name"`
+	Description string `json:"description"`
+
+	Permissions []RolePermission `gorm:"foreignKey:RoleName;references:Name;constraint:OnDelete:CASCADE" json:"permissions,omitempty"`
+}
+
+func (RoleDefinition) TableName() string { return "roles" }
+
+type RolePermission struct {
+	RoleName   Role       `gorm:"primaryKey;type:varchar(50)" json:"role"`
+	Permission Permission `gorm:"primaryKey;type:varchar(100)" json:"permission"`
+}
+
+// UserDormScope ogranicava staff korisnika na odredjene domove (npr. upravnik doma).
+// Bez ijednog reda korisnik nije ogranicen.
+type UserDormScope struct {
+	UserID uint      `gorm:"primaryKey" json:"userId"`
+	DormID uuid.UUID `gorm:"type:uuid;primaryKey" json:"dormId"`
+}
+
+type RoleReq struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type DormScopesReq struct {
+	DormIDs []uuid.UUID `json:"dormIds"`
+}
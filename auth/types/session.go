@@ -0,0 +1,28 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session je jedan login (porodica refresh tokena); ID je FamilyID. Access tokeni
+// nose ID u claim-u sid, pa opoziv sesije gasi i njih, ne samo refresh token.
+type Session struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID     uint       `gorm:"not null;index" json:"userId"`
+	UserAgent  string     `gorm:"not null" json:"userAgent"`
+	IP         string     `gorm:"column:ip;not null" json:"ip"`
+	CreatedAt  time.Time  `gorm:"not null" json:"createdAt"`
+	LastUsedAt time.Time  `gorm:"not null" json:"lastUsedAt"`
+	LastIP     string     `gorm:"column:last_ip;not null" json:"lastIp"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expiresAt"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+}
+
+// SessionInfo je sesija kako je vidi korisnik; Current je sesija iz koje stize zahtev.
+type SessionInfo struct {
+	Session
+	Device  string `json:"device"`
+	Current bool   `json:"current"`
+}
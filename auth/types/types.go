@@ -1,5 +1,11 @@
 package types
 
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
 type User struct {
 	ID        uint   `gorm:"primaryKey" json:"ID"`
 	Email     string `gorm:"unique;not null" json:"email"`
@@ -7,14 +13,90 @@ type User struct {
 	FirstName string `gorm:"not null" json:"firstName"`
 	LastName  string `gorm:"not null" json:"lastName"`
 	Role      Role   `gorm:"not null" json:"role"`
+	// Postojeci nalozi dobijaju ACTIVE preko default-a kolone
+	Status UserStatus `gorm:"type:varchar(30);not null;default:'ACTIVE'" json:"status"`
+	// Po ovoj koloni student-housing povlaci izmene naloga (GET /internal/users?since=)
+	UpdatedAt time.Time `gorm:"autoUpdateTime;index" json:"updatedAt"`
+}
+
+// UserIdentity je javni deo naloga koji ostali servisi kesiraju; lozinka nikad ne izlazi iz auth-a.
+type UserIdentity struct {
+	ID        uint       `json:"id"`
+	Email     string     `json:"email"`
+	FirstName string     `json:"firstName"`
+	LastName  string     `json:"lastName"`
+	Role      Role       `json:"role"`
+	Status    UserStatus `json:"status"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+func (u User) Identity() UserIdentity {
+	return UserIdentity{
+		ID:        u.ID,
+		Email:     u.Email,
+		FirstName: u.FirstName,
+		LastName:  u.LastName,
+		Role:      u.Role,
+		Status:    u.Status,
+		UpdatedAt: u.UpdatedAt,
+	}
+}
+
+type UpdateMeReq struct {
+	FirstName *string `json:"firstName"`
+	LastName  *string `json:"lastName"`
+}
+
+type ChangePasswordReq struct {
+	OldPassword string `json:"oldPassword"`
+	NewPassword string `json:"newPassword"`
+}
+
+type SetRoleReq struct {
+	Role string `json:"role"`
+}
+
+// ImpersonateReq: razlog je obavezan i ide u bezbednosni dnevnik.
+type ImpersonateReq struct {
+	Reason string `json:"reason"`
+}
+
+// ImpersonationResp je kratkotrajni token bez refresh tokena; po isteku admin pocinje iznova.
+type ImpersonationResp struct {
+	AccessToken string       `json:"access_token"`
+	ExpiresIn   int64        `json:"expires_in"`
+	TokenType   string       `json:"token_type"`
+	User        UserIdentity `json:"user"`
+}
+
+type AdminUpdateUserReq struct {
+	Email     *string `json:"email"`
+	FirstName *string `json:"firstName"`
+	LastName  *string `json:"lastName"`
+}
+
+type UserStatus string
+
+const (
+	StatusPendingVerification UserStatus = "PENDING_VERIFICATION"
+	StatusActive              UserStatus = "ACTIVE"
+	StatusDisabled            UserStatus = "DISABLED"
+	// Obrisan nalog ostaje kao anonimizovan red, da bi prijave i uplate i dalje imale vlasnika
+	StatusDeleted UserStatus = "DELETED"
+)
+
+// CanSignIn: onemoguceni i obrisani nalozi ne dobijaju nove tokene.
+func (s UserStatus) CanSignIn() bool {
+	return s != StatusDisabled && s != StatusDeleted
 }
 
 type Role string
 
+// Iste vrednosti kao u student-housing i na klijentu (ranije TEACHER, sada STAFF)
 const (
 	Admin   Role = "ADMIN"
 	Student Role = "STUDENT"
-	Teacher Role = "TEACHER"
+	Staff   Role = "STAFF"
 )
 
 type LoginReq struct {
@@ -22,7 +104,136 @@ type LoginReq struct {
 	Password string `json:"password"`
 }
 type LoginResp struct {
-	AccessToken string `json:"access_token"`
-	ExpiresIn   int64  `json:"expires_in"`
-	TokenType   string `json:"token_type"`
+	AccessToken      string `json:"access_token"`
+	ExpiresIn        int64  `json:"expires_in"`
+	TokenType        string `json:"token_type"`
+	RefreshToken     string `json:"refresh_token,omitempty"`
+	RefreshExpiresIn int64  `json:"refresh_expires_in,omitempty"`
+}
+
+// RefreshToken cuva samo SHA-256 hash tokena. Svi tokeni nastali rotacijom
+// jednog logina dele FamilyID, pa ponovna upotreba starog tokena gasi celu porodicu.
+type RefreshToken struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID     uint       `gorm:"not null;index" json:"userId"`
+	FamilyID   uuid.UUID  `gorm:"type:uuid;not null;index" json:"familyId"`
+	TokenHash  string     `gorm:"uniqueIndex;not null" json:"-"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"createdAt"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expiresAt"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+	ReplacedBy *uuid.UUID `gorm:"type:uuid" json:"replacedBy,omitempty"`
+}
+
+type RefreshReq struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// RevokedToken je access token (po jti) opozvan pre isteka, npr. na logout.
+type RevokedToken struct {
+	JTI       string    `gorm:"primaryKey" json:"jti"`
+	UserID    uint      `gorm:"not null;index" json:"userId"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expiresAt"`
+	RevokedAt time.Time `gorm:"autoCreateTime" json:"revokedAt"`
+}
+
+// TokenCutoff: svi tokeni korisnika izdati pre NotBefore su nevazeci.
+type TokenCutoff struct {
+	UserID    uint      `gorm:"primaryKey" json:"userId"`
+	NotBefore time.Time `gorm:"not null" json:"notBefore"`
+}
+
+// RevocationList je ono sto verifikatori u drugim servisima preuzimaju i kesiraju.
+type RevocationList struct {
+	JTIs        []string         `json:"jtis"`
+	Users       map[string]int64 `json:"users"`    // userId -> notBefore (unix)
+	Sessions    []string         `json:"sessions"` // opozvane sesije (claim sid)
+	GeneratedAt int64            `json:"generatedAt"`
+}
+
+type LogoutReq struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// PasswordResetToken je jednokratni token iz mejla; cuva se samo hash.
+type PasswordResetToken struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"userId"`
+	TokenHash string     `gorm:"uniqueIndex;not null" json:"-"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"createdAt"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expiresAt"`
+	UsedAt    *time.Time `json:"usedAt,omitempty"`
+}
+
+type ForgotPasswordReq struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordReq struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"newPassword"`
+}
+
+type VerifyEmailReq struct {
+	Token string `json:"token"`
+}
+
+type ResendVerificationReq struct {
+	Email string `json:"email"`
+}
+
+// UserTOTP je TOTP secret korisnika. Dok ConfirmedAt nije postavljen, upis je u toku.
+type UserTOTP struct {
+	UserID       uint       `gorm:"primaryKey" json:"userId"`
+	Secret       string     `gorm:"not null" json:"-"`
+	ConfirmedAt  *time.Time `json:"confirmedAt,omitempty"`
+	LastUsedStep int64      `gorm:"not null;default:0" json:"-"`
+	CreatedAt    time.Time  `gorm:"autoCreateTime" json:"createdAt"`
+}
+
+func (UserTOTP) TableName() string { return "user_totp" }
+
+// RecoveryCode je jednokratni rezervni kod za slucaj gubitka telefona; cuva se hash.
+type RecoveryCode struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"userId"`
+	CodeHash  string     `gorm:"not null;index" json:"-"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"createdAt"`
+	UsedAt    *time.Time `json:"usedAt,omitempty"`
+}
+
+// MFAChallengeResp vraca login kada je potreban drugi korak (ili upis 2FA).
+type MFAChallengeResp struct {
+	MFARequired        bool   `json:"mfa_required,omitempty"`
+	EnrollmentRequired bool   `json:"mfa_enrollment_required,omitempty"`
+	MFAToken           string `json:"mfa_token"`
+	ExpiresIn          int64  `json:"expires_in"`
+}
+
+type MFALoginReq struct {
+	MFAToken     string `json:"mfa_token"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+type TOTPCodeReq struct {
+	Code string `json:"code"`
+}
+
+type TOTPEnrollResp struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type TOTPConfirmResp struct {
+	RecoveryCodes []string   `json:"recovery_codes"`
+	Tokens        *LoginResp `json:"tokens,omitempty"`
+}
+
+// LoginAttempt broji neuspele pokusaje po nalogu (Kind "account", Key = email) i po IP adresi.
+type LoginAttempt struct {
+	Kind          string     `gorm:"primaryKey;type:varchar(10)" json:"kind"`
+	Key           string     `gorm:"primaryKey" json:"key"`
+	Failures      int        `gorm:"not null;default:0" json:"failures"`
+	LastFailureAt time.Time  `gorm:"not null" json:"lastFailureAt"`
+	LockedUntil   *time.Time `json:"lockedUntil,omitempty"`
 }
@@ -0,0 +1,79 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type UserImportStatus string
+
+const (
+	ImportCompleted UserImportStatus = "COMPLETED"
+	// Fajl je imao neispravne redove; nijedan nalog nije napravljen
+	ImportRejected UserImportStatus = "REJECTED"
+)
+
+// ImportRowResult je ishod jednog reda iz CSV-a.
+type ImportRowResult string
+
+const (
+	RowValid     ImportRowResult = "VALID" // samo u dry-run izvestaju
+	RowCreated   ImportRowResult = "CREATED"
+	RowInvalid   ImportRowResult = "INVALID"
+	RowDuplicate ImportRowResult = "DUPLICATE"
+	RowExists    ImportRowResult = "EXISTS"
+	// Red je ispravan, ali je uvoz odbijen zbog drugih redova
+	RowSkipped ImportRowResult = "SKIPPED"
+)
+
+// UserImport je jedan uvoz spiska upisanih studenata (dry-run se ne cuva).
+type UserImport struct {
+	ID        uuid.UUID        `gorm:"type:uuid;primaryKey" json:"id"`
+	FileName  string           `gorm:"not null" json:"fileName"`
+	Status    UserImportStatus `gorm:"type:varchar(20);not null" json:"status"`
+	Total     int              `gorm:"not null" json:"total"`
+	Created   int              `gorm:"not null" json:"created"`
+	Failed    int              `gorm:"not null" json:"failed"`
+	CreatedBy uint             `gorm:"not null" json:"createdBy"`
+	CreatedAt time.Time        `gorm:"autoCreateTime" json:"createdAt"`
+}
+
+// UserImportRow cuva red iz fajla i njegov ishod. Index i Faculty auth ne koristi; student-housing
+// ih preuzima preko /internal/users kada prvi put napravi profil za nalog.
+type UserImportRow struct {
+	ImportID         uuid.UUID       `gorm:"type:uuid;primaryKey" json:"-"`
+	Line             int             `gorm:"primaryKey;autoIncrement:false" json:"line"`
+	Email            string          `gorm:"not null" json:"email"`
+	FirstName        string          `gorm:"not null" json:"firstName"`
+	LastName         string          `gorm:"not null" json:"lastName"`
+	Index            string          `gorm:"column:index;not null" json:"index"`
+	Faculty          string          `gorm:"not null" json:"faculty"`
+	Result           ImportRowResult `gorm:"type:varchar(20);not null" json:"result"`
+	Error            string          `gorm:"not null" json:"error,omitempty"`
+	UserID           *uint           `gorm:"index" json:"userId,omitempty"`
+	ActivationSentAt *time.Time      `json:"activationSentAt,omitempty"`
+}
+
+// UserImportReport je odgovor na uvoz (i na dry-run, kada je ID prazan).
+type UserImportReport struct {
+	ID     *uuid.UUID       `json:"id,omitempty"`
+	DryRun bool             `json:"dryRun"`
+	Status UserImportStatus `json:"status,omitempty"`
+	Total  int              `json:"total"`
+	Valid  int              `json:"valid"`
+	Failed int              `json:"failed"`
+	Rows   []UserImportRow  `json:"rows"`
+}
+
+// Enrollment su studentski podaci iz uvoza koje auth samo prosledjuje student-housing-u.
+type Enrollment struct {
+	Index   string `json:"index"`
+	Faculty string `json:"faculty"`
+}
+
+// InternalUserIdentity je identitet za /internal/users; Enrollment postoji samo za uvezene naloge.
+type InternalUserIdentity struct {
+	UserIdentity
+	Enrollment *Enrollment `json:"enrollment,omitempty"`
+}
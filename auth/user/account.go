@@ -0,0 +1,227 @@
+package user
+
+import (
+	"auth/keys"
+	"auth/middleware"
+	"auth/pwpolicy"
+	"auth/types"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// Auth je jedini vlasnik naloga: lozinka, ime, email i uloga se menjaju samo ovde,
+// a student-housing ih povlaci preko /internal/users.
+
+func me(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		u, ok := loadCaller(c, db)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, u.Identity())
+	}
+}
+
+func updateMe(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req types.UpdateMeReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
+			return
+		}
+		u, ok := loadCaller(c, db)
+		if !ok {
+			return
+		}
+
+		updates := map[string]any{}
+		if req.FirstName != nil {
+			updates["first_name"] = strings.TrimSpace(*req.FirstName)
+		}
+		if req.LastName != nil {
+			updates["last_name"] = strings.TrimSpace(*req.LastName)
+		}
+		if len(updates) > 0 {
+			if err := db.WithContext(c.Request.Context()).Model(&u).Updates(updates).Error; err != nil {
+				log.Printf("[updateMe] err: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update profile"})
+				return
+			}
+		}
+		c.JSON(http.StatusOK, u.Identity())
+	}
+}
+
+// changePassword menja lozinku uz staru lozinku. Sve ostale sesije se gase,
+// a pozivalac dobija novi par tokena da ne bi morao ponovo da se loguje.
+func changePassword(db *gorm.DB, issuer string, ks *keys.Store, pw *pwpolicy.Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req types.ChangePasswordReq
+		if err := c.ShouldBindJSON(&req); err != nil || req.OldPassword == "" || req.NewPassword == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "oldPassword and newPassword are required"})
+			return
+		}
+		u, ok := loadCaller(c, db)
+		if !ok {
+			return
+		}
+		if err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(req.OldPassword)); err != nil {
+			audit(c, db, types.AuthEvent{Type: types.EventPasswordChange, Outcome: types.OutcomeFailure, Reason: "incorrect_old_password", SubjectID: &u.ID, Email: u.Email})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "incorrect old password"})
+			return
+		}
+		if rejectPassword(c, pw.Check(req.NewPassword, identityOf(u))) {
+			return
+		}
+
+		hash, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to hash password"})
+			return
+		}
+
+		ctxDB := db.WithContext(c.Request.Context())
+		err = ctxDB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&u).Update("password", string(hash)).Error; err != nil {
+				return err
+			}
+			return revokeAllForUser(tx, u.ID)
+		})
+		if err != nil {
+			log.Printf("[changePassword] err: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to change password"})
+			return
+		}
+		audit(c, db, types.AuthEvent{Type: types.EventPasswordChange, Outcome: types.OutcomeSuccess, SubjectID: &u.ID, Email: u.Email})
+
+		resp, _, err := issueTokens(c, ctxDB, u, issuer, ks, uuid.New())
+		if err != nil {
+			log.Printf("[changePassword] issue tokens err: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to issue tokens"})
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// setUserRole menja ulogu naloga; stari tokeni nose staru ulogu i dozvole pa se opozivaju.
+func setUserRole(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req types.SetRoleReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
+			return
+		}
+		role := types.Role(strings.ToUpper(strings.TrimSpace(req.Role)))
+
+		var def types.RoleDefinition
+		if err := db.WithContext(c.Request.Context()).First(&def, "name = ?", role).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid role"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load role"})
+			return
+		}
+
+		u, ok := loadUserParam(c, db)
+		if !ok {
+			return
+		}
+		if u.Role != role {
+			err := db.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+				if err := tx.Model(&u).Update("role", role).Error; err != nil {
+					return err
+				}
+				return revokeAllForUser(tx, u.ID)
+			})
+			if err != nil {
+				log.Printf("[setUserRole] err: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update role"})
+				return
+			}
+			audit(c, db, types.AuthEvent{
+				Type:      types.EventRoleChange,
+				Outcome:   types.OutcomeSuccess,
+				Reason:    string(u.Role) + " -> " + string(role),
+				SubjectID: &u.ID,
+				Email:     u.Email,
+			})
+			u.Role = role
+		}
+		c.JSON(http.StatusOK, u.Identity())
+	}
+}
+
+/* ===================== Internal ===================== */
+
+const internalUsersPageSize = 500
+
+// internalUsers vraca naloge izmenjene posle kursora (?since=RFC3339Nano&afterId=), rastuce po (updated_at, id).
+// Interni endpoint kao i /revocations: proxy ga ne izlaze spolja.
+func internalUsers(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		q := db.WithContext(c.Request.Context()).Order("updated_at, id").Limit(internalUsersPageSize)
+		if raw := c.Query("since"); raw != "" {
+			since, err := time.Parse(time.RFC3339Nano, raw)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid since"})
+				return
+			}
+			afterID, _ := strconv.ParseUint(c.DefaultQuery("afterId", "0"), 10, 64)
+			q = q.Where("updated_at > ? OR (updated_at = ? AND id > ?)", since, since, afterID)
+		}
+
+		var users []types.User
+		if err := q.Find(&users).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load users"})
+			return
+		}
+		out, err := withEnrollments(db.WithContext(c.Request.Context()), users)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load enrollments"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"items": out})
+	}
+}
+
+func internalUser(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		u, ok := loadUserParam(c, db)
+		if !ok {
+			return
+		}
+		out, err := withEnrollments(db.WithContext(c.Request.Context()), []types.User{u})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load enrollments"})
+			return
+		}
+		c.JSON(http.StatusOK, out[0])
+	}
+}
+
+/* ===================== Helpers ===================== */
+
+func loadCaller(c *gin.Context, db *gorm.DB) (types.User, bool) {
+	id, _ := middleware.UserID(c)
+	var u types.User
+	if err := db.WithContext(c.Request.Context()).First(&u, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return types.User{}, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load user"})
+		return types.User{}, false
+	}
+	return u, true
+}
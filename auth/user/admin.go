@@ -0,0 +1,296 @@
+package user
+
+import (
+	"auth/mail"
+	"auth/middleware"
+	"auth/types"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// Administracija naloga. Student-housing samo kesira identitet (vidi /internal/users),
+// pa sve izmene naloga idu kroz ove endpointe.
+
+func listUsers(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, size, offset := pagination(c)
+
+		q := db.WithContext(c.Request.Context()).Model(&types.User{})
+		if s := strings.TrimSpace(c.Query("q")); s != "" {
+			like := "%" + strings.ToLower(s) + "%"
+			q = q.Where("LOWER(email) LIKE ? OR LOWER(first_name) LIKE ? OR LOWER(last_name) LIKE ?", like, like, like)
+		}
+		if role := strings.TrimSpace(c.Query("role")); role != "" {
+			q = q.Where("role = ?", strings.ToUpper(role))
+		}
+		// Obrisani (anonimizovani) nalozi se vide samo kad se eksplicitno traze
+		if status := strings.TrimSpace(c.Query("status")); status != "" {
+			q = q.Where("status = ?", strings.ToUpper(status))
+		} else {
+			q = q.Where("status <> ?", types.StatusDeleted)
+		}
+
+		var total int64
+		if err := q.Count(&total).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count users"})
+			return
+		}
+		var users []types.User
+		if err := q.Order("id").Offset(offset).Limit(size).Find(&users).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load users"})
+			return
+		}
+
+		items := make([]types.UserIdentity, 0, len(users))
+		for _, u := range users {
+			items = append(items, u.Identity())
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"items":      items,
+			"pagination": gin.H{"page": page, "pageSize": size, "totalCount": total},
+		})
+	}
+}
+
+func getUser(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		u, ok := loadUserParam(c, db)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, u.Identity())
+	}
+}
+
+func adminUpdateUser(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req types.AdminUpdateUserReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
+			return
+		}
+		u, ok := loadUserParam(c, db)
+		if !ok {
+			return
+		}
+		if u.Status == types.StatusDeleted {
+			c.JSON(http.StatusConflict, gin.H{"error": "user is deleted"})
+			return
+		}
+
+		updates := map[string]any{}
+		if req.Email != nil {
+			email := strings.TrimSpace(strings.ToLower(*req.Email))
+			if email == "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "email cannot be empty"})
+				return
+			}
+			updates["email"] = email
+		}
+		if req.FirstName != nil {
+			updates["first_name"] = strings.TrimSpace(*req.FirstName)
+		}
+		if req.LastName != nil {
+			updates["last_name"] = strings.TrimSpace(*req.LastName)
+		}
+		if len(updates) > 0 {
+			if err := db.WithContext(c.Request.Context()).Model(&u).Updates(updates).Error; err != nil {
+				var pgErr *pgconn.PgError
+				if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+					c.JSON(http.StatusConflict, gin.H{"error": "email already exists"})
+					return
+				}
+				log.Printf("[adminUpdateUser] err: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update user"})
+				return
+			}
+		}
+		c.JSON(http.StatusOK, u.Identity())
+	}
+}
+
+// disableUser blokira prijavu i gasi sve postojece sesije.
+func disableUser(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		u, ok := loadUserParam(c, db)
+		if !ok || rejectSelf(c, u) {
+			return
+		}
+		if u.Status == types.StatusDeleted {
+			c.JSON(http.StatusConflict, gin.H{"error": "user is deleted"})
+			return
+		}
+
+		err := db.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&u).Update("status", types.StatusDisabled).Error; err != nil {
+				return err
+			}
+			return revokeAllForUser(tx, u.ID)
+		})
+		if err != nil {
+			log.Printf("[disableUser] err: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to disable user"})
+			return
+		}
+		c.JSON(http.StatusOK, u.Identity())
+	}
+}
+
+func enableUser(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		u, ok := loadUserParam(c, db)
+		if !ok {
+			return
+		}
+		if u.Status != types.StatusDisabled {
+			c.JSON(http.StatusConflict, gin.H{"error": "user is not disabled"})
+			return
+		}
+		if err := db.WithContext(c.Request.Context()).Model(&u).Update("status", types.StatusActive).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to enable user"})
+			return
+		}
+		c.JSON(http.StatusOK, u.Identity())
+	}
+}
+
+// deleteUser anonimizuje nalog umesto brisanja reda: id ostaje (prijave i uplate
+// u student-housing ga referenciraju), a licni podaci (i u dnevniku i uvozima), lozinka, 2FA i tokeni nestaju.
+func deleteUser(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		u, ok := loadUserParam(c, db)
+		if !ok || rejectSelf(c, u) {
+			return
+		}
+		if u.Status == types.StatusDeleted {
+			c.Status(http.StatusNoContent)
+			return
+		}
+
+		unusable, err := unusablePassword()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete user"})
+			return
+		}
+
+		email := u.Email
+		err = db.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&u).Updates(map[string]any{
+				"email":      fmt.Sprintf("deleted-%d@deleted.invalid", u.ID),
+				"first_name": "",
+				"last_name":  "",
+				"password":   unusable,
+				"status":     types.StatusDeleted,
+			}).Error; err != nil {
+				return err
+			}
+			for _, m := range []any{&types.UserTOTP{}, &types.RecoveryCode{}, &types.PasswordResetToken{}, &types.UserDormScope{}} {
+				if err := tx.Where("user_id = ?", u.ID).Delete(m).Error; err != nil {
+					return err
+				}
+			}
+			if err := tx.Where("kind = ? AND key = ?", attemptAccount, normalizeEmail(email)).Delete(&types.LoginAttempt{}).Error; err != nil {
+				return err
+			}
+			if err := redactAuthEvents(tx, u.ID, email); err != nil {
+				return err
+			}
+			if err := scrubImportRows(tx, u.ID, email); err != nil {
+				return err
+			}
+			return revokeAllForUser(tx, u.ID)
+		})
+		if err != nil {
+			log.Printf("[deleteUser] err: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete user"})
+			return
+		}
+		// Brisanje se belezi samo po id-u; email je upravo uklonjen
+		audit(c, db, types.AuthEvent{Type: types.EventUserDelete, Outcome: types.OutcomeSuccess, SubjectID: &u.ID})
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// forcePasswordReset ponistava trenutnu lozinku i sesije i salje korisniku link za novu.
+func forcePasswordReset(db *gorm.DB, mailer mail.Sender, publicURL string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		u, ok := loadUserParam(c, db)
+		if !ok {
+			return
+		}
+		if !u.Status.CanSignIn() {
+			c.JSON(http.StatusConflict, gin.H{"error": "user is disabled or deleted"})
+			return
+		}
+
+		unusable, err := unusablePassword()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reset password"})
+			return
+		}
+		ctxDB := db.WithContext(c.Request.Context())
+		err = ctxDB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&u).Update("password", unusable).Error; err != nil {
+				return err
+			}
+			return revokeAllForUser(tx, u.ID)
+		})
+		if err != nil {
+			log.Printf("[forcePasswordReset] err: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reset password"})
+			return
+		}
+		audit(c, db, types.AuthEvent{Type: types.EventPasswordForcedReset, Outcome: types.OutcomeSuccess, SubjectID: &u.ID, Email: u.Email})
+
+		if err := sendResetLink(c.Request.Context(), db, mailer, publicURL, u); err != nil {
+			log.Printf("[forcePasswordReset] send link err: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create reset token"})
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{"message": "password reset link sent"})
+	}
+}
+
+/* ===================== Helpers ===================== */
+
+// rejectSelf: admin ne moze sam sebe da onemoguci ili obrise (i tako ostane bez pristupa).
+func rejectSelf(c *gin.Context, u types.User) bool {
+	if id, _ := middleware.UserID(c); id == u.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot apply this action to your own account"})
+		return true
+	}
+	return false
+}
+
+// unusablePassword je bcrypt hash nasumicne vrednosti koju niko ne zna.
+func unusablePassword() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(hex.EncodeToString(b)), bcrypt.DefaultCost)
+	return string(hash), err
+}
+
+func pagination(c *gin.Context) (page, size, offset int) {
+	page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	size, _ = strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	if size < 1 || size > 200 {
+		size = 20
+	}
+	return page, size, (page - 1) * size
+}
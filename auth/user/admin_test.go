@@ -0,0 +1,68 @@
+package user
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"auth/types"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// deleteUserModels su tabele koje deleteUser cisti.
+var deleteUserModels = []any{
+	&types.User{}, &types.AuthEvent{}, &types.UserTOTP{}, &types.RecoveryCode{}, &types.PasswordResetToken{},
+	&types.UserDormScope{}, &types.LoginAttempt{}, &types.TokenCutoff{}, &types.RefreshToken{}, &types.Session{},
+	&types.UserImport{}, &types.UserImportRow{},
+}
+
+func TestDeleteUserScrubsImportRows(t *testing.T) {
+	db := openTestDB(t, deleteUserModels...)
+	admin := types.User{Email: "admin@student.test", Password: "x", Role: "ADMIN", Status: types.StatusActive}
+	ana := types.User{Email: "ana@student.test", Password: "x", FirstName: "Ana", Role: "STUDENT", Status: types.StatusActive}
+	for _, u := range []*types.User{&admin, &ana} {
+		if err := db.Create(u).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	first := types.UserImport{ID: uuid.New(), FileName: "upis.csv", Status: "DONE", CreatedBy: admin.ID}
+	again := types.UserImport{ID: uuid.New(), FileName: "upis-2.csv", Status: "DONE", CreatedBy: admin.ID}
+	if err := db.Create([]types.UserImport{first, again}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create([]types.UserImportRow{
+		{ImportID: first.ID, Line: 2, Email: ana.Email, FirstName: "Ana", LastName: "Jovanovic", Index: "2024/0042", Faculty: "ETF", Result: types.RowCreated, UserID: &ana.ID},
+		{ImportID: first.ID, Line: 3, Email: "marko@student.test", FirstName: "Marko", LastName: "Markovic", Index: "2024/0043", Faculty: "ETF", Result: types.RowCreated},
+		// Ponovni uvoz: nalog vec postoji, user_id nije upisan
+		{ImportID: again.ID, Line: 2, Email: " Ana@Student.test", FirstName: "Ana", LastName: "Jovanovic", Index: "2024/0042", Faculty: "ETF", Result: types.RowExists, Error: "account already exists"},
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	c, _ := requestContext(admin.ID)
+	c.Params = gin.Params{{Key: "id", Value: "2"}}
+	deleteUser(db)(c)
+	if c.Writer.Status() != http.StatusNoContent {
+		t.Fatalf("delete: status %d", c.Writer.Status())
+	}
+
+	for _, imp := range []types.UserImport{first, again} {
+		c, w := requestContext(admin.ID)
+		c.Params = gin.Params{{Key: "id", Value: imp.ID.String()}}
+		userImportResults(db)(c)
+		body := w.Body.String()
+		for _, pii := range []string{"Ana", "ana@", "Jovanovic", "2024/0042"} {
+			if strings.Contains(strings.ToLower(body), strings.ToLower(pii)) {
+				t.Errorf("%s results still contain %q:\n%s", imp.FileName, pii, body)
+			}
+		}
+		if imp.ID == first.ID && !strings.Contains(body, "marko@student.test") {
+			t.Errorf("other rows were scrubbed too:\n%s", body)
+		}
+		if imp.ID == again.ID && !strings.Contains(body, "EXISTS") {
+			t.Errorf("row outcome was lost:\n%s", body)
+		}
+	}
+}
@@ -1,16 +1,107 @@
 package user
 
 import (
-	"os"
+	"auth/config"
+	"auth/keys"
+	"auth/mail"
+	"auth/middleware"
+	"auth/pwpolicy"
+	"auth/types"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func WithUserAPI(r *gin.RouterGroup, db *gorm.DB) {
-	secret := []byte(os.Getenv("JWT_SECRET"))
-	issuer := os.Getenv("ISSUER")
+func WithUserAPI(r *gin.RouterGroup, db *gorm.DB, cfg config.Config, ks *keys.Store, mailer mail.Sender, pw *pwpolicy.Policy) {
+	issuer := cfg.Issuer
+	auth := middleware.RequireAuth(db, issuer, ks)
+	can := middleware.RequirePermission
 
-	r.POST("/users", createUser(db))
-	r.POST("/login", login(db, issuer, secret))
+	verifier := NewVerifier(db, ks, mailer, issuer, cfg.PublicURL)
+	mfa := NewMFA(db, ks, issuer, cfg.MFARequiredRoles)
+	enrollAuth := mfa.RequireUserOrEnrollToken(auth)
+	throttle := NewThrottle(db, cfg)
+	oidc := NewOIDC(db, ks, mfa, throttle, cfg.OIDCIssuer)
+
+	r.POST("/users", createUser(db, verifier, pw))
+	r.POST("/login", login(db, issuer, ks, mfa, throttle))
+	r.POST("/login/2fa", loginSecondStep(mfa, throttle))
+	r.POST("/refresh", refresh(db, issuer, ks))
+	r.POST("/logout", middleware.AllowImpersonation, auth, logout(db))
+	r.POST("/password/forgot", forgotPassword(db, mailer, cfg.PublicURL))
+	r.POST("/password/reset", resetPassword(db, pw))
+	r.POST("/password/change", auth, changePassword(db, issuer, ks, pw))
+	r.GET("/me", auth, me(db))
+	r.PATCH("/me", auth, updateMe(db))
+	r.GET("/me/sessions", auth, listMySessions(db))
+	r.DELETE("/me/sessions", auth, revokeMySessions(db))
+	r.DELETE("/me/sessions/:sid", auth, revokeMySession(db))
+
+	r.GET("/users", auth, can(types.PermUserRead), listUsers(db))
+	r.GET("/users/:id", auth, can(types.PermUserRead), getUser(db))
+	r.PATCH("/users/:id", auth, can(types.PermUserWrite), adminUpdateUser(db))
+	r.DELETE("/users/:id", auth, can(types.PermUserWrite), deleteUser(db))
+	r.POST("/users/:id/disable", auth, can(types.PermUserWrite), disableUser(db))
+	r.POST("/users/:id/enable", auth, can(types.PermUserWrite), enableUser(db))
+	r.POST("/users/:id/password-reset", auth, can(types.PermUserWrite), forcePasswordReset(db, mailer, cfg.PublicURL))
+	r.PUT("/users/:id/role", auth, can(types.PermUserRole), setUserRole(db))
+	r.POST("/impersonate/:id", auth, can(types.PermUserImpersonate), impersonate(db, issuer, ks))
+	r.POST("/users/:id/revoke-sessions", auth, can(types.PermUserSessions), revokeSessions(db))
+	r.GET("/users/:id/sessions", auth, can(types.PermUserSessions), listUserSessions(db))
+	r.DELETE("/users/:id/sessions/:sid", auth, can(types.PermUserSessions), revokeUserSession(db))
+	r.POST("/users/:id/unlock", auth, can(types.PermUserWrite), unlockUser(db, throttle))
+
+	r.POST("/user-imports", auth, can(types.PermUserImport), importUsers(db, mailer, cfg.PublicURL))
+	r.GET("/user-imports", auth, can(types.PermUserImport), listUserImports(db))
+	r.GET("/user-imports/:id", auth, can(types.PermUserImport), getUserImport(db))
+	r.GET("/user-imports/:id/results.csv", auth, can(types.PermUserImport), userImportResults(db))
+
+	r.GET("/verify-email", verifyEmail(verifier))
+	r.POST("/verify-email", verifyEmail(verifier))
+	r.POST("/verify-email/resend", resendVerification(verifier))
+	r.POST("/users/:id/verification/resend", auth, can(types.PermUserWrite), adminResendVerification(verifier))
+	r.POST("/users/:id/verify", auth, can(types.PermUserWrite), forceVerify(db))
+
+	r.POST("/2fa/totp/enroll", enrollAuth, enrollTOTP(mfa))
+	r.GET("/2fa/totp/qr.png", enrollAuth, totpQR(mfa))
+	r.POST("/2fa/totp/confirm", enrollAuth, confirmTOTP(mfa))
+	r.DELETE("/2fa/totp", auth, disableTOTP(mfa))
+	r.POST("/2fa/recovery-codes", auth, regenerateRecoveryCodes(mfa))
+	r.DELETE("/users/:id/2fa", auth, can(types.PermUserWrite), adminResetTOTP(mfa))
+
+	r.GET("/permissions", auth, can(types.PermRBACManage), listPermissions())
+	r.GET("/roles", auth, can(types.PermRBACManage), listRoles(db))
+	r.POST("/roles", auth, can(types.PermRBACManage), createRole(db))
+	r.PUT("/roles/:name/permissions", auth, can(types.PermRBACManage), setRolePermissions(db))
+	r.PUT("/users/:id/dorm-scopes", auth, can(types.PermRBACManage), setDormScopes(db))
+
+	r.GET("/.well-known/openid-configuration", oidcDiscovery(oidc))
+	r.GET("/oidc/authorize", oidcAuthorizeForm(oidc))
+	r.POST("/oidc/authorize", oidcAuthorize(oidc))
+	r.POST("/oidc/token", oidcToken(oidc))
+	r.GET("/oidc/userinfo", oidcUserinfo(oidc))
+	r.POST("/oidc/userinfo", oidcUserinfo(oidc))
+	r.GET("/oidc/clients", auth, can(types.PermClientManage), listOIDCClients(db))
+	r.POST("/oidc/clients", auth, can(types.PermClientManage), createOIDCClient(db))
+	r.DELETE("/oidc/clients/:id", auth, can(types.PermClientManage), deleteOIDCClient(db))
+
+	r.POST("/oauth/token", clientCredentials(db, issuer, ks))
+	r.GET("/service-clients", auth, can(types.PermClientManage), listServiceClients(db))
+	r.POST("/service-clients", auth, can(types.PermClientManage), createServiceClient(db))
+	r.DELETE("/service-clients/:id", auth, can(types.PermClientManage), deleteServiceClient(db))
+
+	r.GET("/api-keys", auth, listMyAPIKeys(db))
+	r.POST("/api-keys", auth, createAPIKey(db))
+	r.DELETE("/api-keys/:id", auth, revokeAPIKey(db))
+	r.PUT("/api-keys/:id/tier", auth, can(types.PermAPIKeyManage), setAPIKeyTier(db))
+	r.GET("/users/:id/api-keys", auth, can(types.PermAPIKeyManage), listUserAPIKeys(db))
+
+	r.GET("/audit/events", auth, can(types.PermAuditRead), listAuthEvents(db))
+	r.GET("/audit/events/verify", auth, can(types.PermAuditRead), verifyAuthEvents(db))
+
+	r.GET("/.well-known/jwks.json", jwks(ks))
+	r.GET("/revocations", revocations(db))
+	r.GET("/internal/users", internalUsers(db))
+	r.GET("/internal/users/:id", internalUser(db))
+	r.POST("/internal/api-keys/verify", verifyAPIKey(db))
 }
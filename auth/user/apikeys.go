@@ -0,0 +1,205 @@
+package user
+
+import (
+	"auth/middleware"
+	"auth/types"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// API kljucevi za open-data. Auth ih izdaje i opoziva; open-data ih proverava preko
+// /internal/api-keys/verify, kesira rezultat i sam sprovodi limit za tier.
+
+const (
+	apiKeyPrefix      = "od_"
+	apiKeyShownChars  = 8
+	maxAPIKeysPerUser = 10
+)
+
+func createAPIKey(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		u, ok := loadCaller(c, db)
+		if !ok {
+			return
+		}
+		var req types.APIKeyReq
+		if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+			return
+		}
+
+		var active int64
+		if err := db.Model(&types.APIKey{}).Where("user_id = ? AND revoked_at IS NULL", u.ID).Count(&active).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count keys"})
+			return
+		}
+		if active >= maxAPIKeysPerUser {
+			c.JSON(http.StatusConflict, gin.H{"error": "too many active keys, revoke one first"})
+			return
+		}
+
+		raw, err := randomToken()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create key"})
+			return
+		}
+		raw = apiKeyPrefix + raw
+		k := types.APIKey{
+			ID:      uuid.New(),
+			UserID:  u.ID,
+			Name:    strings.TrimSpace(req.Name),
+			Prefix:  raw[:len(apiKeyPrefix)+apiKeyShownChars],
+			KeyHash: hashToken(raw),
+			Tier:    types.TierFree,
+		}
+		if err := db.WithContext(c.Request.Context()).Create(&k).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save key"})
+			return
+		}
+		c.JSON(http.StatusCreated, types.APIKeyCreatedResp{APIKey: k, Key: raw})
+	}
+}
+
+func listMyAPIKeys(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, _ := middleware.UserID(c)
+		listAPIKeys(c, db, uid)
+	}
+}
+
+// listUserAPIKeys (admin) vraca kljuceve jednog korisnika.
+func listUserAPIKeys(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		u, ok := loadUserParam(c, db)
+		if !ok {
+			return
+		}
+		listAPIKeys(c, db, u.ID)
+	}
+}
+
+// revokeAPIKey: vlasnik ili admin (apikey:manage). Opoziv stize do open-data
+// najkasnije kad istekne njegov kes provere kljuca.
+func revokeAPIKey(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		k, ok := loadAPIKeyParam(c, db)
+		if !ok {
+			return
+		}
+		if k.RevokedAt == nil {
+			if err := db.Model(&k).Update("revoked_at", time.Now()).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke key"})
+				return
+			}
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// setAPIKeyTier (admin) menja limit kljuca.
+func setAPIKeyTier(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req types.APIKeyTierReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
+			return
+		}
+		tier := types.APIKeyTier(strings.ToUpper(strings.TrimSpace(req.Tier)))
+		known := false
+		for _, t := range types.APIKeyTiers {
+			if t == tier {
+				known = true
+			}
+		}
+		if !known {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown tier", "tiers": types.APIKeyTiers})
+			return
+		}
+
+		k, ok := loadAPIKeyParam(c, db)
+		if !ok {
+			return
+		}
+		if err := db.Model(&k).Update("tier", tier).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update key"})
+			return
+		}
+		c.JSON(http.StatusOK, k)
+	}
+}
+
+// verifyAPIKey (interno) vraca vlasnika i tier aktivnog kljuca. Kljuc ide u telu
+// zahteva, da ne bi zavrsio u access logovima.
+func verifyAPIKey(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req types.APIKeyVerifyReq
+		if err := c.ShouldBindJSON(&req); err != nil || req.Key == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "key is required"})
+			return
+		}
+
+		var k types.APIKey
+		err := db.WithContext(c.Request.Context()).
+			First(&k, "key_hash = ? AND revoked_at IS NULL", hashToken(req.Key)).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "unknown or revoked key"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load key"})
+			return
+		}
+		// Kljucevi onemogucenog ili obrisanog naloga ne vaze
+		var owner types.User
+		if err := db.First(&owner, "id = ?", k.UserID).Error; err != nil || !owner.Status.CanSignIn() {
+			c.JSON(http.StatusNotFound, gin.H{"error": "unknown or revoked key"})
+			return
+		}
+
+		c.JSON(http.StatusOK, types.APIKeyInfo{ID: k.ID, UserID: k.UserID, Tier: k.Tier})
+	}
+}
+
+/* ===================== Helpers ===================== */
+
+func listAPIKeys(c *gin.Context, db *gorm.DB, userID uint) {
+	var keys []types.APIKey
+	if err := db.WithContext(c.Request.Context()).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&keys).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load keys"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": keys})
+}
+
+// loadAPIKeyParam ucitava :id; tudji kljuc (bez apikey:manage) se prikazuje kao nepostojeci.
+func loadAPIKeyParam(c *gin.Context, db *gorm.DB) (types.APIKey, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return types.APIKey{}, false
+	}
+	var k types.APIKey
+	if err := db.WithContext(c.Request.Context()).First(&k, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "key not found"})
+			return types.APIKey{}, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load key"})
+		return types.APIKey{}, false
+	}
+	uid, _ := middleware.UserID(c)
+	if k.UserID != uid && !middleware.HasPermission(c, types.PermAPIKeyManage) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "key not found"})
+		return types.APIKey{}, false
+	}
+	return k, true
+}
@@ -0,0 +1,316 @@
+package user
+
+import (
+	"auth/middleware"
+	"auth/types"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Bezbednosni dnevnik (auth_events). Upis ne sme da obori radnju koju belezi, pa se
+// greska samo loguje. Upisi su serijalizovani advisory lock-om da bi lanac bio linearan.
+// Licni podaci (email, IP, user agent) su van hash-a, da bi brisanje naloga moglo da ih ukloni.
+
+const (
+	auditLockKey      = 7_201_016
+	auditBatchSize    = 1000
+	maxUserAgentChars = 512
+	// auditHashVersion je verzija eventHash za nove redove (1 je hash-ovala i licne podatke)
+	auditHashVersion = 2
+)
+
+// audit dodaje dogadjaj; IP, user agent i izvrsilac (ako je zahtev sa tokenom) se
+// popunjavaju iz zahteva (kod impersonacije izvrsilac je admin). Namerno bez konteksta zahteva: prekinut zahtev se i dalje belezi.
+func audit(c *gin.Context, db *gorm.DB, ev types.AuthEvent) {
+	ev.IP = c.ClientIP()
+	ev.UserAgent = c.Request.UserAgent()
+	if len(ev.UserAgent) > maxUserAgentChars {
+		ev.UserAgent = ev.UserAgent[:maxUserAgentChars]
+	}
+	if ev.ActorID == nil {
+		if id, ok := middleware.ActorID(c); ok {
+			ev.ActorID = &id
+		} else if id, ok := middleware.UserID(c); ok {
+			ev.ActorID = &id
+		}
+	}
+	ev.Email = normalizeEmail(ev.Email)
+	ev.HashVersion = auditHashVersion
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// SQLite (testovi) nema advisory lock, a upisi su tamo ionako serijski
+		if tx.Dialector.Name() == "postgres" {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", auditLockKey).Error; err != nil {
+				return err
+			}
+		}
+		var prev []string
+		if err := tx.Model(&types.AuthEvent{}).Order("id DESC").Limit(1).Pluck("hash", &prev).Error; err != nil {
+			return err
+		}
+		if len(prev) > 0 {
+			ev.PrevHash = prev[0]
+		}
+		// Postgres cuva mikrosekunde; hash mora da odgovara onome sto se procita nazad
+		ev.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+		ev.Hash = eventHash(ev)
+		return tx.Create(&ev).Error
+	})
+	if err != nil {
+		log.Printf("[audit] %s/%s err: %v", ev.Type, ev.Outcome, err)
+	}
+}
+
+// auditLoginFailure belezi odbijen login; razlog je kod greske, ne poruka korisniku.
+func auditLoginFailure(c *gin.Context, db *gorm.DB, email string, u types.User, err error) {
+	ev := types.AuthEvent{Type: types.EventLogin, Outcome: types.OutcomeFailure, Email: email}
+	if u.ID != 0 {
+		ev.SubjectID = &u.ID
+	}
+	var d *throttleDenial
+	switch {
+	case errors.As(err, &d):
+		ev.Reason = strings.ToLower(d.code)
+	case errors.Is(err, errInvalidCredentials):
+		ev.Reason = "invalid_credentials"
+	case errors.Is(err, errAccountDisabled):
+		ev.Reason = "account_disabled"
+	case errors.Is(err, errEmailNotVerified):
+		ev.Reason = "email_not_verified"
+	default:
+		// Greska baze nije pokusaj prijave o kome treba voditi racuna
+		return
+	}
+	audit(c, db, ev)
+}
+
+// auditLoginSuccess belezi prijavu (ili prolaz prvog koraka kad sledi 2FA).
+func auditLoginSuccess(c *gin.Context, db *gorm.DB, typ types.AuthEventType, u types.User, outcome types.AuthOutcome, reason string) {
+	audit(c, db, types.AuthEvent{Type: typ, Outcome: outcome, Reason: reason, SubjectID: &u.ID, Email: u.Email})
+}
+
+// listAuthEvents (admin) vraca dnevnik od najnovijeg, ili ceo izbor kao CSV (?format=csv).
+// Filteri: type, outcome, userId (izvrsilac ili nalog), email, ip, from, to (RFC3339 ili YYYY-MM-DD).
+func listAuthEvents(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		q, ok := authEventsFilter(c, db.WithContext(c.Request.Context()).Model(&types.AuthEvent{}))
+		if !ok {
+			return
+		}
+
+		if strings.EqualFold(c.Query("format"), "csv") {
+			writeAuthEventsCSV(c, q)
+			return
+		}
+
+		page, size, offset := pagination(c)
+		var total int64
+		if err := q.Count(&total).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count events"})
+			return
+		}
+		var events []types.AuthEvent
+		if err := q.Order("id DESC").Offset(offset).Limit(size).Find(&events).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load events"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"items":      events,
+			"pagination": gin.H{"page": page, "pageSize": size, "totalCount": total},
+		})
+	}
+}
+
+// verifyAuthEvents (admin) prolazi ceo lanac i vraca prvi red koji se ne slaze.
+func verifyAuthEvents(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var resp types.AuditChainResp
+		var batch []types.AuthEvent
+		err := db.WithContext(c.Request.Context()).Model(&types.AuthEvent{}).
+			FindInBatches(&batch, auditBatchSize, func(_ *gorm.DB, _ int) error {
+				for _, ev := range batch {
+					if resp.BrokenAt != nil {
+						return nil
+					}
+					// Stari red bez licnih podataka: njegov hash se ne moze ponoviti, ali veza mora da stoji
+					redacted := ev.HashVersion == 1 && ev.RedactedAt != nil
+					if ev.PrevHash != resp.Head || (!redacted && eventHash(ev) != ev.Hash) {
+						id := ev.ID
+						resp.BrokenAt = &id
+						return nil
+					}
+					resp.Head = ev.Hash
+					resp.Checked++
+					if redacted {
+						resp.Redacted++
+					}
+				}
+				return nil
+			}).Error
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load events"})
+			return
+		}
+		resp.OK = resp.BrokenAt == nil
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+/* ===================== Helpers ===================== */
+
+// redactAuthEvents brise licne podatke iz dogadjaja naloga (kao subjekta ili izvrsioca) i
+// neuspelih prijava na njegov email. Hash ih od verzije 2 ne pokriva, pa lanac ostaje ispravan.
+func redactAuthEvents(tx *gorm.DB, userID uint, email string) error {
+	return tx.Model(&types.AuthEvent{}).
+		Where("(subject_id = ? OR actor_id = ? OR email = ?) AND redacted_at IS NULL", userID, userID, normalizeEmail(email)).
+		Updates(map[string]any{"email": "", "ip": "", "user_agent": "", "redacted_at": time.Now().UTC()}).Error
+}
+
+// eventHash: verzija 2 pokriva dogadjaj i id-jeve, bez licnih podataka; verzija 1 (stari redovi)
+// pokriva sve kolone osim id-a i samog hash-a. JSON daje jednoznacan zapis polja.
+func eventHash(ev types.AuthEvent) string {
+	if ev.HashVersion >= 2 {
+		body, _ := json.Marshal(struct {
+			Version   int                 `json:"v"`
+			Prev      string              `json:"prev"`
+			CreatedAt string              `json:"createdAt"`
+			Type      types.AuthEventType `json:"type"`
+			Outcome   types.AuthOutcome   `json:"outcome"`
+			Reason    string              `json:"reason"`
+			ActorID   *uint               `json:"actorId"`
+			SubjectID *uint               `json:"subjectId"`
+		}{
+			ev.HashVersion, ev.PrevHash, ev.CreatedAt.UTC().Format(time.RFC3339Nano), ev.Type, ev.Outcome, ev.Reason,
+			ev.ActorID, ev.SubjectID,
+		})
+		sum := sha256.Sum256(body)
+		return hex.EncodeToString(sum[:])
+	}
+	body, _ := json.Marshal(struct {
+		Prev      string              `json:"prev"`
+		CreatedAt string              `json:"createdAt"`
+		Type      types.AuthEventType `json:"type"`
+		Outcome   types.AuthOutcome   `json:"outcome"`
+		Reason    string              `json:"reason"`
+		ActorID   *uint               `json:"actorId"`
+		SubjectID *uint               `json:"subjectId"`
+		Email     string              `json:"email"`
+		IP        string              `json:"ip"`
+		UserAgent string              `json:"userAgent"`
+	}{
+		ev.PrevHash, ev.CreatedAt.UTC().Format(time.RFC3339Nano), ev.Type, ev.Outcome, ev.Reason,
+		ev.ActorID, ev.SubjectID, ev.Email, ev.IP, ev.UserAgent,
+	})
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+func authEventsFilter(c *gin.Context, q *gorm.DB) (*gorm.DB, bool) {
+	if t := strings.TrimSpace(c.Query("type")); t != "" {
+		q = q.Where("type = ?", strings.ToUpper(t))
+	}
+	if o := strings.TrimSpace(c.Query("outcome")); o != "" {
+		q = q.Where("outcome = ?", strings.ToUpper(o))
+	}
+	if raw := strings.TrimSpace(c.Query("userId")); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid userId"})
+			return nil, false
+		}
+		q = q.Where("actor_id = ? OR subject_id = ?", id, id)
+	}
+	if e := strings.TrimSpace(c.Query("email")); e != "" {
+		q = q.Where("email LIKE ?", "%"+normalizeEmail(e)+"%")
+	}
+	if ip := strings.TrimSpace(c.Query("ip")); ip != "" {
+		q = q.Where("ip = ?", ip)
+	}
+	for _, p := range []struct {
+		param, cond string
+		dayEnd      bool
+	}{{"from", "created_at >= ?", false}, {"to", "created_at < ?", true}} {
+		raw := strings.TrimSpace(c.Query(p.param))
+		if raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			day, derr := time.Parse("2006-01-02", raw)
+			if derr != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid %s (RFC3339 or YYYY-MM-DD)", p.param)})
+				return nil, false
+			}
+			// "to" kao datum obuhvata ceo taj dan
+			if p.dayEnd {
+				day = day.AddDate(0, 0, 1)
+			}
+			t = day
+		}
+		q = q.Where(p.cond, t)
+	}
+	return q, true
+}
+
+func writeAuthEventsCSV(c *gin.Context, q *gorm.DB) {
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="auth-events.csv"`)
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write([]string{"id", "createdAt", "type", "outcome", "reason", "actorId", "subjectId", "email", "ip", "userAgent", "prevHash", "hash"})
+
+	var batch []types.AuthEvent
+	err := q.FindInBatches(&batch, auditBatchSize, func(_ *gorm.DB, _ int) error {
+		for _, ev := range batch {
+			if err := w.Write([]string{
+				strconv.FormatInt(ev.ID, 10),
+				ev.CreatedAt.UTC().Format(time.RFC3339Nano),
+				string(ev.Type),
+				string(ev.Outcome),
+				csvSafe(ev.Reason),
+				optionalID(ev.ActorID),
+				optionalID(ev.SubjectID),
+				csvSafe(ev.Email),
+				csvSafe(ev.IP),
+				csvSafe(ev.UserAgent),
+				ev.PrevHash,
+				ev.Hash,
+			}); err != nil {
+				return err
+			}
+		}
+		w.Flush()
+		return w.Error()
+	}).Error
+	w.Flush()
+	if err != nil {
+		// Zaglavlje je vec poslato; prekinut fajl je jedini signal klijentu
+		log.Printf("[listAuthEvents] csv export err: %v", err)
+	}
+}
+
+// csvSafe sprecava da Excel vrednost koju je uneo napadac (npr. user agent) izvrsi kao formulu.
+func csvSafe(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func optionalID(id *uint) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.FormatUint(uint64(*id), 10)
+}
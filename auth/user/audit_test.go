@@ -0,0 +1,121 @@
+package user
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"auth/middleware"
+	"auth/types"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// requestContext je zahtev sa IP adresom i user agent-om; adminID != 0 je prijavljeni admin.
+func requestContext(adminID uint) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+	c.Request.RemoteAddr = "198.51.100.7:4242"
+	c.Request.Header.Set("User-Agent", "test-browser/1.0")
+	if adminID != 0 {
+		c.Set(middleware.CtxUserID, adminID)
+	}
+	return c, w
+}
+
+func verifyChain(t *testing.T, db *gorm.DB) types.AuditChainResp {
+	t.Helper()
+	c, w := requestContext(1)
+	verifyAuthEvents(db)(c)
+	var resp types.AuditChainResp
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("verify: %v (%s)", err, w.Body.String())
+	}
+	return resp
+}
+
+func TestDeleteUserRedactsAuditTrail(t *testing.T) {
+	db := openTestDB(t, deleteUserModels...)
+	admin := types.User{Email: "admin@student.test", Password: "x", Role: "ADMIN", Status: types.StatusActive}
+	ana := types.User{Email: "ana@student.test", Password: "x", FirstName: "Ana", Role: "STUDENT", Status: types.StatusActive}
+	other := types.User{Email: "marko@student.test", Password: "x", Role: "STUDENT", Status: types.StatusActive}
+	for _, u := range []*types.User{&admin, &ana, &other} {
+		if err := db.Create(u).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Red iz vremena kada je hash pokrivao i licne podatke
+	legacy := types.AuthEvent{
+		CreatedAt: time.Now().UTC().Add(-time.Hour).Truncate(time.Microsecond), Type: types.EventRegister,
+		Outcome: types.OutcomeSuccess, SubjectID: &ana.ID, Email: ana.Email, IP: "203.0.113.9", UserAgent: "old", HashVersion: 1,
+	}
+	legacy.Hash = eventHash(legacy)
+	if err := db.Create(&legacy).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	c, _ := requestContext(0)
+	auditLoginSuccess(c, db, types.EventLogin, ana, types.OutcomeSuccess, "")
+	audit(c, db, types.AuthEvent{Type: types.EventLogin, Outcome: types.OutcomeFailure, Reason: "invalid_credentials", Email: "ANA@student.test"})
+	auditLoginSuccess(c, db, types.EventLogin, other, types.OutcomeSuccess, "")
+
+	if resp := verifyChain(t, db); !resp.OK || resp.Checked != 4 {
+		t.Fatalf("chain before delete: %+v", resp)
+	}
+
+	c, w := requestContext(admin.ID)
+	c.Request.Method = http.MethodDelete
+	c.Params = gin.Params{{Key: "id", Value: "2"}}
+	deleteUser(db)(c)
+	if c.Writer.Status() != http.StatusNoContent {
+		t.Fatalf("delete: status %d, body %s", c.Writer.Status(), w.Body.String())
+	}
+
+	var events []types.AuthEvent
+	if err := db.Order("id").Find(&events).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 5 {
+		t.Fatalf("got %d events, want 5", len(events))
+	}
+	for _, ev := range events[:3] {
+		if ev.Email != "" || ev.IP != "" || ev.UserAgent != "" || ev.RedactedAt == nil {
+			t.Errorf("event %d still holds personal data: %+v", ev.ID, ev)
+		}
+	}
+	if ev := events[3]; ev.Email != other.Email || ev.IP == "" || ev.RedactedAt != nil {
+		t.Errorf("unrelated event was redacted: %+v", ev)
+	}
+	del := events[4]
+	if del.Type != types.EventUserDelete || del.SubjectID == nil || *del.SubjectID != ana.ID ||
+		del.ActorID == nil || *del.ActorID != admin.ID || del.Email != "" {
+		t.Fatalf("deletion event = %+v", del)
+	}
+
+	resp := verifyChain(t, db)
+	if !resp.OK || resp.Checked != 5 || resp.Redacted != 1 {
+		t.Fatalf("chain after delete: %+v", resp)
+	}
+}
+
+func TestVerifyDetectsTamperingWithoutPersonalData(t *testing.T) {
+	db := openTestDB(t, &types.AuthEvent{})
+	c, _ := requestContext(0)
+	subject := uint(7)
+	for i := 0; i < 3; i++ {
+		audit(c, db, types.AuthEvent{Type: types.EventLogin, Outcome: types.OutcomeSuccess, SubjectID: &subject})
+	}
+	if err := db.Model(&types.AuthEvent{}).Where("id = ?", 2).Update("outcome", types.OutcomeFailure).Error; err != nil {
+		t.Fatal(err)
+	}
+	resp := verifyChain(t, db)
+	if resp.OK || resp.BrokenAt == nil || *resp.BrokenAt != 2 {
+		t.Fatalf("tampered chain: %+v", resp)
+	}
+}
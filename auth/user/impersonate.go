@@ -0,0 +1,81 @@
+package user
+
+import (
+	"auth/keys"
+	"auth/middleware"
+	"auth/types"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Impersonacija ("vidi kao student") za podrsku. Token nosi studentov id, ulogu i
+// dozvole, a u claim-u act admina. Servisi ga tretiraju kao studenta, ali izmene
+// odbijaju (osim ruta sa middleware.AllowImpersonation) i beleze stvarnog izvrsioca.
+
+const (
+	impersonationTTL      = 10 * time.Minute
+	minImpersonationChars = 5
+)
+
+// impersonate (admin) izdaje token za rad u ime studenta :id.
+func impersonate(db *gorm.DB, issuer string, ks *keys.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req types.ImpersonateReq
+		if err := c.ShouldBindJSON(&req); err != nil || len(strings.TrimSpace(req.Reason)) < minImpersonationChars {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "reason is required"})
+			return
+		}
+		admin, ok := loadCaller(c, db)
+		if !ok {
+			return
+		}
+		u, ok := loadUserParam(c, db)
+		if !ok {
+			return
+		}
+		if rejectSelf(c, u) {
+			return
+		}
+		// Samo studenti: token sa tudjim admin/staff dozvolama bi bio eskalacija
+		if u.Role != types.Student {
+			c.JSON(http.StatusForbidden, gin.H{"error": "only student accounts can be impersonated", "code": "IMPERSONATION_NOT_ALLOWED"})
+			return
+		}
+		if !u.Status.CanSignIn() {
+			c.JSON(http.StatusConflict, gin.H{"error": "user is disabled or deleted"})
+			return
+		}
+
+		claims, err := accessClaims(db, u, issuer, impersonationTTL)
+		if err != nil {
+			log.Printf("[impersonate] claims err: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "signing failed"})
+			return
+		}
+		claims["act"] = middleware.Actor{ID: admin.ID, Subject: admin.Email}
+		signed, err := signClaims(ks, claims)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "signing failed"})
+			return
+		}
+
+		audit(c, db, types.AuthEvent{
+			Type:      types.EventImpersonationStart,
+			Outcome:   types.OutcomeSuccess,
+			Reason:    strings.TrimSpace(req.Reason),
+			SubjectID: &u.ID,
+			Email:     u.Email,
+		})
+		c.JSON(http.StatusOK, types.ImpersonationResp{
+			AccessToken: signed,
+			ExpiresIn:   int64(impersonationTTL.Seconds()),
+			TokenType:   "Bearer",
+			User:        u.Identity(),
+		})
+	}
+}
@@ -0,0 +1,498 @@
+package user
+
+import (
+	"auth/mail"
+	"auth/middleware"
+	"auth/types"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	netmail "net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// Uvoz spiskova upisanih studenata (CSV sa fakulteta). Ceo fajl prolazi ili nijedan red:
+// ako bilo koji red nije ispravan, nalozi se ne prave, a izvestaj po redovima ostaje za preuzimanje.
+
+const (
+	maxImportBytes     = 5 << 20
+	maxImportRows      = 5000
+	importBatchSize    = 500
+	activationTokenTTL = 7 * 24 * time.Hour
+)
+
+// importColumns mapira normalizovano zaglavlje (mala slova, bez razmaka, _ i -) na kolonu.
+var importColumns = map[string]string{
+	"email": "email", "mail": "email",
+	"firstname": "firstName", "ime": "firstName",
+	"lastname": "lastName", "prezime": "lastName",
+	"index": "index", "indeks": "index", "brojindeksa": "index",
+	"faculty": "faculty", "fakultet": "faculty",
+}
+
+var errImportConflict = errors.New("an account from the file was created in the meantime")
+
+// importUsers prima CSV (multipart polje "file" ili telo text/csv). Sa ?dryRun=true samo vraca izvestaj.
+func importUsers(db *gorm.DB, mailer mail.Sender, publicURL string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		dryRun, _ := strconv.ParseBool(c.DefaultQuery("dryRun", "false"))
+
+		name, rows, err := readImportFile(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		failed, err := validateImportRows(db.WithContext(c.Request.Context()), rows)
+		if err != nil {
+			log.Printf("[importUsers] validate err: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check existing accounts"})
+			return
+		}
+		report := types.UserImportReport{DryRun: dryRun, Total: len(rows), Valid: len(rows) - failed, Failed: failed, Rows: rows}
+		if dryRun {
+			c.JSON(http.StatusOK, report)
+			return
+		}
+
+		uid, _ := middleware.UserID(c)
+		imp := types.UserImport{ID: uuid.New(), FileName: name, Total: len(rows), Failed: failed, CreatedBy: uid}
+		var pending []activation
+		if failed > 0 {
+			imp.Status = types.ImportRejected
+			for i := range rows {
+				if rows[i].Result == types.RowValid {
+					rows[i].Result = types.RowSkipped
+				}
+			}
+			err = saveImport(db.WithContext(c.Request.Context()), imp, rows)
+		} else {
+			imp.Status = types.ImportCompleted
+			imp.Created = len(rows)
+			pending, err = createImportedUsers(db.WithContext(c.Request.Context()), imp, rows)
+		}
+		if errors.Is(err, errImportConflict) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error() + "; run the import again"})
+			return
+		}
+		if err != nil {
+			log.Printf("[importUsers] err: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to import users"})
+			return
+		}
+
+		ev := types.AuthEvent{
+			Type:    types.EventUserImport,
+			Outcome: types.OutcomeSuccess,
+			Reason:  fmt.Sprintf("%s: %d created, %d failed (%s)", imp.ID, imp.Created, failed, name),
+		}
+		report.ID, report.Status = &imp.ID, imp.Status
+		if failed > 0 {
+			ev.Outcome = types.OutcomeFailure
+			audit(c, db, ev)
+			c.JSON(http.StatusUnprocessableEntity, report)
+			return
+		}
+		audit(c, db, ev)
+
+		// Mejlovi idu posle commit-a i van zahteva; vreme slanja se upisuje u red
+		go sendActivations(db, mailer, publicURL, imp.ID, pending)
+		c.JSON(http.StatusCreated, report)
+	}
+}
+
+func listUserImports(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, size, offset := pagination(c)
+		q := db.WithContext(c.Request.Context()).Model(&types.UserImport{})
+
+		var total int64
+		if err := q.Count(&total).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count imports"})
+			return
+		}
+		var imports []types.UserImport
+		if err := q.Order("created_at DESC").Offset(offset).Limit(size).Find(&imports).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load imports"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"items":      imports,
+			"pagination": gin.H{"page": page, "pageSize": size, "totalCount": total},
+		})
+	}
+}
+
+func getUserImport(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		imp, ok := loadImportParam(c, db)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, imp)
+	}
+}
+
+// userImportResults vraca ishod svakog reda kao CSV, istim redosledom kao u poslatom fajlu.
+func userImportResults(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		imp, ok := loadImportParam(c, db)
+		if !ok {
+			return
+		}
+		var rows []types.UserImportRow
+		if err := db.WithContext(c.Request.Context()).Where("import_id = ?", imp.ID).Order("line").Find(&rows).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load rows"})
+			return
+		}
+
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="import-%s.csv"`, imp.ID))
+		w := csv.NewWriter(c.Writer)
+		_ = w.Write([]string{"line", "email", "firstName", "lastName", "index", "faculty", "result", "error", "userId", "activationSentAt"})
+		for _, r := range rows {
+			sent := ""
+			if r.ActivationSentAt != nil {
+				sent = r.ActivationSentAt.UTC().Format(time.RFC3339)
+			}
+			_ = w.Write([]string{
+				strconv.Itoa(r.Line),
+				csvSafe(r.Email),
+				csvSafe(r.FirstName),
+				csvSafe(r.LastName),
+				csvSafe(r.Index),
+				csvSafe(r.Faculty),
+				string(r.Result),
+				r.Error,
+				optionalID(r.UserID),
+				sent,
+			})
+		}
+		w.Flush()
+	}
+}
+
+/* ===================== Helpers ===================== */
+
+func readImportFile(c *gin.Context) (string, []types.UserImportRow, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+
+	name := "upload.csv"
+	var body io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fh, err := c.FormFile("file")
+		if err != nil {
+			return "", nil, errors.New(`multipart field "file" is required`)
+		}
+		f, err := fh.Open()
+		if err != nil {
+			return "", nil, err
+		}
+		defer f.Close()
+		name, body = fh.Filename, f
+	}
+
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		return "", nil, fmt.Errorf("file is larger than %d MB", maxImportBytes>>20)
+	}
+	rows, err := parseImportCSV(raw)
+	return name, rows, err
+}
+
+// parseImportCSV cita fajl sa zaglavljem; Excel sa srpskim podesavanjima izvozi sa ";" pa se separator pogadja.
+func parseImportCSV(raw []byte) ([]types.UserImportRow, error) {
+	raw = bytes.TrimPrefix(raw, []byte("\xef\xbb\xbf"))
+	first, _, _ := bufio.NewReader(bytes.NewReader(raw)).ReadLine()
+
+	r := csv.NewReader(bytes.NewReader(raw))
+	if bytes.Count(first, []byte(";")) > bytes.Count(first, []byte(",")) {
+		r.Comma = ';'
+	}
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, errors.New("file is empty or not a valid CSV")
+	}
+	cols := map[string]int{}
+	for i, h := range header {
+		key := strings.NewReplacer(" ", "", "_", "", "-", "").Replace(strings.ToLower(strings.TrimSpace(h)))
+		if col, ok := importColumns[key]; ok {
+			cols[col] = i
+		}
+	}
+	var missing []string
+	for _, col := range []string{"email", "firstName", "lastName", "index", "faculty"} {
+		if _, ok := cols[col]; !ok {
+			missing = append(missing, col)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing column(s): %s", strings.Join(missing, ", "))
+	}
+
+	var rows []types.UserImportRow
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %v", err)
+		}
+		field := func(col string) string {
+			if i := cols[col]; i < len(rec) {
+				return strings.TrimSpace(rec[i])
+			}
+			return ""
+		}
+		if strings.TrimSpace(strings.Join(rec, "")) == "" {
+			continue
+		}
+		line, _ := r.FieldPos(0)
+		rows = append(rows, types.UserImportRow{
+			Line:      line,
+			Email:     normalizeEmail(field("email")),
+			FirstName: field("firstName"),
+			LastName:  field("lastName"),
+			Index:     field("index"),
+			Faculty:   field("faculty"),
+		})
+		if len(rows) > maxImportRows {
+			return nil, fmt.Errorf("file has more than %d rows; split it", maxImportRows)
+		}
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("file has no rows")
+	}
+	return rows, nil
+}
+
+// validateImportRows postavlja Result/Error svakom redu i vraca broj neispravnih.
+func validateImportRows(db *gorm.DB, rows []types.UserImportRow) (int, error) {
+	emails := map[string]int{}
+	indexes := map[string]int{}
+	for i := range rows {
+		r := &rows[i]
+		r.Result = types.RowValid
+		switch {
+		case r.Email == "" || r.FirstName == "" || r.LastName == "" || r.Index == "" || r.Faculty == "":
+			r.Result, r.Error = types.RowInvalid, "all of email, firstName, lastName, index and faculty are required"
+		case !validEmail(r.Email):
+			r.Result, r.Error = types.RowInvalid, "invalid email"
+		case emails[r.Email] != 0:
+			r.Result, r.Error = types.RowDuplicate, fmt.Sprintf("duplicate email (line %d)", emails[r.Email])
+		case indexes[strings.ToUpper(r.Index)] != 0:
+			r.Result, r.Error = types.RowDuplicate, fmt.Sprintf("duplicate index (line %d)", indexes[strings.ToUpper(r.Index)])
+		}
+		if emails[r.Email] == 0 {
+			emails[r.Email] = r.Line
+		}
+		if idx := strings.ToUpper(r.Index); idx != "" && indexes[idx] == 0 {
+			indexes[idx] = r.Line
+		}
+	}
+
+	var check []string
+	for _, r := range rows {
+		if r.Result == types.RowValid {
+			check = append(check, r.Email)
+		}
+	}
+	existing := map[string]bool{}
+	for start := 0; start < len(check); start += importBatchSize {
+		end := min(start+importBatchSize, len(check))
+		var found []string
+		if err := db.Model(&types.User{}).Where("email IN ?", check[start:end]).Pluck("email", &found).Error; err != nil {
+			return 0, err
+		}
+		for _, e := range found {
+			existing[e] = true
+		}
+	}
+
+	failed := 0
+	for i := range rows {
+		if rows[i].Result == types.RowValid && existing[rows[i].Email] {
+			rows[i].Result, rows[i].Error = types.RowExists, "account already exists"
+		}
+		if rows[i].Result != types.RowValid {
+			failed++
+		}
+	}
+	return failed, nil
+}
+
+// validEmail prihvata samo golu adresu (bez imena i <>), sa domenom koji ima tacku.
+func validEmail(e string) bool {
+	addr, err := netmail.ParseAddress(e)
+	if err != nil || addr.Name != "" || addr.Address != e {
+		return false
+	}
+	at := strings.LastIndex(e, "@")
+	return at > 0 && strings.Contains(e[at+1:], ".")
+}
+
+type activation struct {
+	line  int
+	user  types.User
+	token string
+}
+
+// createImportedUsers u jednoj transakciji pravi naloge, aktivacione tokene i zapis o uvozu.
+// Nalozi cekaju potvrdu mejla i nemaju upotrebljivu lozinku dok je korisnik ne postavi preko linka.
+func createImportedUsers(db *gorm.DB, imp types.UserImport, rows []types.UserImportRow) ([]activation, error) {
+	// Jedan hash za ceo uvoz: bcrypt za hiljade redova bi trajao minutima, a vrednost ionako niko ne zna
+	unusable, err := unusablePassword()
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]types.User, len(rows))
+	for i, r := range rows {
+		users[i] = types.User{
+			Email:     r.Email,
+			Password:  unusable,
+			FirstName: r.FirstName,
+			LastName:  r.LastName,
+			Role:      types.Student,
+			Status:    types.StatusPendingVerification,
+		}
+	}
+
+	pending := make([]activation, len(rows))
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(&users, importBatchSize).Error; err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+				return errImportConflict
+			}
+			return err
+		}
+
+		tokens := make([]types.PasswordResetToken, len(users))
+		for i, u := range users {
+			raw, err := randomToken()
+			if err != nil {
+				return err
+			}
+			tokens[i] = types.PasswordResetToken{
+				ID:        uuid.New(),
+				UserID:    u.ID,
+				TokenHash: hashToken(raw),
+				ExpiresAt: time.Now().Add(activationTokenTTL),
+			}
+			pending[i] = activation{line: rows[i].Line, user: u, token: raw}
+			rows[i].Result, rows[i].UserID = types.RowCreated, &users[i].ID
+		}
+		if err := tx.CreateInBatches(&tokens, importBatchSize).Error; err != nil {
+			return err
+		}
+		return saveImport(tx, imp, rows)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pending, nil
+}
+
+func saveImport(db *gorm.DB, imp types.UserImport, rows []types.UserImportRow) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&imp).Error; err != nil {
+			return err
+		}
+		for i := range rows {
+			rows[i].ImportID = imp.ID
+		}
+		return tx.CreateInBatches(&rows, importBatchSize).Error
+	})
+}
+
+// sendActivations salje linkove za postavljanje lozinke; neuspelo slanje ostaje bez activationSentAt,
+// a admin moze ponovo da posalje link preko POST /users/:id/password-reset.
+func sendActivations(db *gorm.DB, mailer mail.Sender, publicURL string, importID uuid.UUID, pending []activation) {
+	ctx := context.Background()
+	for _, a := range pending {
+		link := fmt.Sprintf("%s/reset-password?token=%s", strings.TrimRight(publicURL, "/"), url.QueryEscape(a.token))
+		err := mailer.Send(ctx, mail.Message{
+			To:      a.user.Email,
+			Subject: "eGovernment - aktivacija naloga",
+			Body: fmt.Sprintf("Zdravo %s,\n\nstudentska sluzba vam je otvorila nalog. Da biste ga aktivirali, postavite lozinku preko linka ispod (vazi %d dana):\n%s\n",
+				a.user.FirstName, int(activationTokenTTL.Hours()/24), link),
+		})
+		if err != nil {
+			log.Printf("[sendActivations] %s line %d: %v", importID, a.line, err)
+			continue
+		}
+		if err := db.Model(&types.UserImportRow{}).
+			Where("import_id = ? AND line = ?", importID, a.line).
+			Update("activation_sent_at", time.Now()).Error; err != nil {
+			log.Printf("[sendActivations] %s line %d mark sent: %v", importID, a.line, err)
+		}
+	}
+}
+
+// withEnrollments dodaje indeks i fakultet iz uvoza, da bi ih student-housing upisao u novi profil.
+func withEnrollments(db *gorm.DB, users []types.User) ([]types.InternalUserIdentity, error) {
+	ids := make([]uint, 0, len(users))
+	for _, u := range users {
+		ids = append(ids, u.ID)
+	}
+	var rows []types.UserImportRow
+	if len(ids) > 0 {
+		if err := db.Where("user_id IN ? AND result = ?", ids, types.RowCreated).Find(&rows).Error; err != nil {
+			return nil, err
+		}
+	}
+	byUser := make(map[uint]*types.Enrollment, len(rows))
+	for _, r := range rows {
+		byUser[*r.UserID] = &types.Enrollment{Index: r.Index, Faculty: r.Faculty}
+	}
+
+	out := make([]types.InternalUserIdentity, 0, len(users))
+	for _, u := range users {
+		out = append(out, types.InternalUserIdentity{UserIdentity: u.Identity(), Enrollment: byUser[u.ID]})
+	}
+	return out, nil
+}
+
+// scrubImportRows brise licne podatke obrisanog naloga iz redova uvoza (i iz onih gde je nalog
+// vec postojao, pa user_id nije upisan); ishod reda ostaje u izvestaju.
+func scrubImportRows(tx *gorm.DB, userID uint, email string) error {
+	return tx.Model(&types.UserImportRow{}).
+		Where("user_id = ? OR LOWER(TRIM(email)) = ?", userID, normalizeEmail(email)).
+		Updates(map[string]any{"email": "", "first_name": "", "last_name": "", "index": "", "faculty": ""}).Error
+}
+
+func loadImportParam(c *gin.Context, db *gorm.DB) (types.UserImport, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return types.UserImport{}, false
+	}
+	var imp types.UserImport
+	if err := db.WithContext(c.Request.Context()).First(&imp, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "import not found"})
+			return types.UserImport{}, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load import"})
+		return types.UserImport{}, false
+	}
+	return imp, true
+}
@@ -0,0 +1,228 @@
+package user
+
+import (
+	"auth/keys"
+	"auth/types"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Masinski tokeni (client credentials) za pozive izmedju servisa. Potpisuje ih isti
+// kljuc i isti issuer kao access token, ali nemaju id/role: sub je "client:<id>", a
+// prava nosi samo claim scope. Brisanje klijenta ne opoziva vec izdate tokene; oni
+// isticu posle machineTokenTTL.
+
+const machineTokenTTL = 15 * time.Minute
+
+var clientIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{2,99}$`)
+
+// clientCredentials izdaje masinski token (grant_type=client_credentials).
+func clientCredentials(db *gorm.DB, issuer string, ks *keys.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "no-store")
+		c.Header("Pragma", "no-cache")
+
+		if c.PostForm("grant_type") != "client_credentials" {
+			oauthError(c, http.StatusBadRequest, "unsupported_grant_type", "only client_credentials is supported")
+			return
+		}
+
+		id, secret, basic := c.Request.BasicAuth()
+		if !basic {
+			id, secret = c.PostForm("client_id"), c.PostForm("client_secret")
+		}
+		var client types.ServiceClient
+		err := db.First(&client, "client_id = ?", id).Error
+		if err == nil && subtle.ConstantTimeCompare([]byte(hashToken(secret)), []byte(client.SecretHash)) != 1 {
+			err = errors.New("bad secret")
+		}
+		if err != nil {
+			if basic {
+				c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+			}
+			oauthError(c, http.StatusUnauthorized, "invalid_client", "client authentication failed")
+			return
+		}
+
+		// Bez scope parametra klijent dobija sve sto mu je dozvoljeno
+		allowed := strings.Fields(client.Scopes)
+		scopes := allowed
+		if requested := strings.Fields(c.PostForm("scope")); len(requested) > 0 {
+			for _, s := range requested {
+				if !contains(allowed, s) {
+					oauthError(c, http.StatusBadRequest, "invalid_scope", fmt.Sprintf("scope %q is not allowed for this client", s))
+					return
+				}
+			}
+			scopes = requested
+		}
+		scope := strings.Join(scopes, " ")
+
+		now := time.Now()
+		key := ks.Signing()
+		tok := jwt.NewWithClaims(jwt.SigningMethodEdDSA, jwt.MapClaims{
+			"iss":       issuer,
+			"sub":       "client:" + client.ClientID,
+			"client_id": client.ClientID,
+			"scope":     scope,
+			"iat":       now.Unix(),
+			"exp":       now.Add(machineTokenTTL).Unix(),
+			"jti":       uuid.NewString(),
+		})
+		tok.Header["kid"] = key.ID
+		signed, err := tok.SignedString(key.Private)
+		if err != nil {
+			oauthError(c, http.StatusInternalServerError, "server_error", "signing failed")
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"access_token": signed,
+			"token_type":   "Bearer",
+			"expires_in":   int64(machineTokenTTL.Seconds()),
+			"scope":        scope,
+		})
+	}
+}
+
+// createServiceClient (admin) registruje servis; secret se vraca samo ovde.
+func createServiceClient(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req types.ServiceClientReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
+			return
+		}
+		req.Name = strings.TrimSpace(req.Name)
+		if req.Name == "" || len(req.Scopes) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name and scopes are required"})
+			return
+		}
+		scopes, ok := parseMachineScopes(c, req.Scopes)
+		if !ok {
+			return
+		}
+
+		id := strings.TrimSpace(req.ClientID)
+		if id == "" {
+			buf := make([]byte, 12)
+			if _, err := rand.Read(buf); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create client"})
+				return
+			}
+			id = hex.EncodeToString(buf)
+		} else if !clientIDPattern.MatchString(id) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "clientId may contain only lowercase letters, digits and dashes"})
+			return
+		}
+
+		secret, err := randomToken()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create client"})
+			return
+		}
+		client := types.ServiceClient{
+			ClientID:   id,
+			Name:       req.Name,
+			SecretHash: hashToken(secret),
+			Scopes:     scopes,
+		}
+		if err := db.WithContext(c.Request.Context()).Create(&client).Error; err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+				c.JSON(http.StatusConflict, gin.H{"error": "client already exists"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save client"})
+			return
+		}
+		c.JSON(http.StatusCreated, types.ServiceClientCreatedResp{ServiceClient: client, ClientSecret: secret})
+	}
+}
+
+func listServiceClients(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var clients []types.ServiceClient
+		if err := db.WithContext(c.Request.Context()).Order("client_id").Find(&clients).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load clients"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"items": clients, "scopes": types.MachineScopes})
+	}
+}
+
+func deleteServiceClient(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		res := db.WithContext(c.Request.Context()).Delete(&types.ServiceClient{}, "client_id = ?", c.Param("id"))
+		if res.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete client"})
+			return
+		}
+		if res.RowsAffected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "client not found"})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// SeedServiceClients upisuje klijente iz konfiguracije ("id:secret:scope1 scope2"),
+// da bi docker-compose radio bez rucne registracije. Secret i scope-ovi se osvezavaju
+// pri svakom startu.
+func SeedServiceClients(db *gorm.DB, specs []string) error {
+	for _, spec := range specs {
+		parts := strings.SplitN(spec, ":", 3)
+		if len(parts) != 3 || !clientIDPattern.MatchString(parts[0]) || parts[1] == "" {
+			return fmt.Errorf("invalid service client spec for %q (want id:secret:scopes)", parts[0])
+		}
+		for _, s := range strings.Fields(parts[2]) {
+			if !contains(types.MachineScopes, s) {
+				return fmt.Errorf("service client %s: unknown scope %q", parts[0], s)
+			}
+		}
+		client := types.ServiceClient{
+			ClientID:   parts[0],
+			Name:       parts[0],
+			SecretHash: hashToken(parts[1]),
+			Scopes:     strings.Join(strings.Fields(parts[2]), " "),
+		}
+		if err := db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "client_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"secret_hash", "scopes"}),
+		}).Create(&client).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/* ===================== Helpers ===================== */
+
+func parseMachineScopes(c *gin.Context, in []string) (string, bool) {
+	var out []string
+	for _, s := range in {
+		s = strings.TrimSpace(s)
+		if !contains(types.MachineScopes, s) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown scope", "scope": s})
+			return "", false
+		}
+		if !contains(out, s) {
+			out = append(out, s)
+		}
+	}
+	return strings.Join(out, " "), true
+}
@@ -0,0 +1,448 @@
+package user
+
+import (
+	"auth/keys"
+	"auth/middleware"
+	"auth/totp"
+	"auth/types"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/skip2/go-qrcode"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	mfaTokenTTL       = 5 * time.Minute
+	mfaLoginPurpose   = "mfa"
+	mfaEnrollPurpose  = "mfa_enroll"
+	recoveryCodeCount = 10
+	mfaTokenHeader    = "X-MFA-Token"
+	totpIssuerLabel   = "eGovernment"
+)
+
+var errInvalidMFACode = errors.New("invalid code")
+
+// MFA drzi sve sto je potrebno za drugi korak logina i upis TOTP-a.
+type MFA struct {
+	db       *gorm.DB
+	ks       *keys.Store
+	issuer   string
+	required map[types.Role]bool
+}
+
+func NewMFA(db *gorm.DB, ks *keys.Store, issuer string, requiredRoles []string) *MFA {
+	req := make(map[types.Role]bool, len(requiredRoles))
+	for _, r := range requiredRoles {
+		req[types.Role(strings.ToUpper(r))] = true
+	}
+	return &MFA{db: db, ks: ks, issuer: issuer, required: req}
+}
+
+// Challenge se poziva iz login-a posle tacne lozinke. Ako korisnik ima 2FA (ili mora
+// da ga ima), upisuje odgovor sa mfa_token-om i vraca true; access token se tada ne izdaje.
+func (m *MFA) Challenge(c *gin.Context, u types.User) (bool, error) {
+	t, err := m.load(u.ID)
+	if err != nil {
+		return false, err
+	}
+
+	var resp types.MFAChallengeResp
+	switch {
+	case t != nil && t.ConfirmedAt != nil:
+		resp.MFARequired = true
+		resp.MFAToken, err = signPurposeToken(m.ks, m.issuer, mfaLoginPurpose, u.ID, u.Email, mfaTokenTTL)
+	case m.required[u.Role]:
+		// Uloga mora da ima 2FA: token sluzi samo za enroll/confirm (header X-MFA-Token)
+		resp.EnrollmentRequired = true
+		resp.MFAToken, err = signPurposeToken(m.ks, m.issuer, mfaEnrollPurpose, u.ID, u.Email, mfaTokenTTL)
+	default:
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	resp.ExpiresIn = int64(mfaTokenTTL.Seconds())
+	c.JSON(http.StatusOK, resp)
+	return true, nil
+}
+
+// loginSecondStep menja mfa_token + TOTP (ili recovery) kod za access/refresh tokene.
+func loginSecondStep(m *MFA, th *Throttle) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req types.MFALoginReq
+		if err := c.ShouldBindJSON(&req); err != nil || req.MFAToken == "" || (req.Code == "" && req.RecoveryCode == "") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "mfa_token and code or recovery_code are required"})
+			return
+		}
+
+		uid, email, err := parsePurposeToken(m.ks, m.issuer, mfaLoginPurpose, req.MFAToken)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Pogresni kodovi se broje kao neuspeli login istog naloga
+		if ok, err := th.Check(c, email); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check login attempts"})
+			return
+		} else if !ok {
+			return
+		}
+
+		if req.Code != "" {
+			err = m.verifyCode(uid, req.Code)
+		} else {
+			err = m.useRecoveryCode(uid, req.RecoveryCode)
+		}
+		if err != nil {
+			if errors.Is(err, errInvalidMFACode) {
+				if ferr := th.Fail(c, email); ferr != nil {
+					log.Printf("[loginSecondStep] record failure err: %v", ferr)
+				}
+				audit(c, m.db, types.AuthEvent{Type: types.EventLoginMFA, Outcome: types.OutcomeFailure, Reason: "invalid_code", SubjectID: &uid, Email: email})
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid code", "code": "MFA_INVALID_CODE"})
+				return
+			}
+			log.Printf("[loginSecondStep] err: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify code"})
+			return
+		}
+
+		var u types.User
+		if err := m.db.First(&u, "id = ?", uid).Error; err != nil || !u.Status.CanSignIn() {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+			return
+		}
+		if err := th.Success(email); err != nil {
+			log.Printf("[loginSecondStep] reset attempts err: %v", err)
+		}
+
+		resp, _, err := issueTokens(c, m.db, u, m.issuer, m.ks, uuid.New())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "signing failed"})
+			return
+		}
+		reason := "totp"
+		if req.Code == "" {
+			reason = "recovery_code"
+		}
+		auditLoginSuccess(c, m.db, types.EventLoginMFA, u, types.OutcomeSuccess, reason)
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// RequireUserOrEnrollToken propusta pozivaoca sa access tokenom ili sa enroll tokenom
+// iz logina (X-MFA-Token), jer admin bez 2FA ne moze da dobije access token.
+func (m *MFA) RequireUserOrEnrollToken(auth gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		raw := strings.TrimSpace(c.GetHeader(mfaTokenHeader))
+		if raw == "" {
+			auth(c)
+			return
+		}
+		uid, _, err := parsePurposeToken(m.ks, m.issuer, mfaEnrollPurpose, raw)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.Set(middleware.CtxUserID, uid)
+		c.Set(mfaEnrollPurpose, true)
+		c.Next()
+	}
+}
+
+// enrollTOTP pravi novi (nepotvrdjen) secret; postojeci potvrdjen 2FA se ovde ne menja.
+func enrollTOTP(m *MFA) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, _ := middleware.UserID(c)
+		u, ok := loadUserByID(c, m.db, uid)
+		if !ok {
+			return
+		}
+
+		existing, err := m.load(uid)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load 2fa"})
+			return
+		}
+		if existing != nil && existing.ConfirmedAt != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "2fa already enabled"})
+			return
+		}
+
+		secret, err := totp.NewSecret()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create secret"})
+			return
+		}
+		t := types.UserTOTP{UserID: uid, Secret: secret}
+		if err := m.db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"secret", "confirmed_at", "last_used_step", "created_at"}),
+		}).Create(&t).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save secret"})
+			return
+		}
+
+		c.JSON(http.StatusOK, types.TOTPEnrollResp{
+			Secret:     secret,
+			OTPAuthURI: totp.URI(totpIssuerLabel, u.Email, secret),
+		})
+	}
+}
+
+// totpQR vraca QR kod (PNG) za secret koji je u toku upisa.
+func totpQR(m *MFA) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, _ := middleware.UserID(c)
+		u, ok := loadUserByID(c, m.db, uid)
+		if !ok {
+			return
+		}
+		t, err := m.load(uid)
+		if err != nil || t == nil || t.ConfirmedAt != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no pending 2fa enrollment"})
+			return
+		}
+		png, err := qrcode.Encode(totp.URI(totpIssuerLabel, u.Email, t.Secret), qrcode.Medium, 256)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to render qr"})
+			return
+		}
+		c.Header("Cache-Control", "no-store")
+		c.Data(http.StatusOK, "image/png", png)
+	}
+}
+
+// confirmTOTP potvrdjuje upis prvim kodom i vraca recovery kodove (jedini put kada se vide).
+// Ako je pozivalac dosao sa enroll tokenom, odmah dobija i access/refresh tokene.
+func confirmTOTP(m *MFA) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, _ := middleware.UserID(c)
+		var req types.TOTPCodeReq
+		if err := c.ShouldBindJSON(&req); err != nil || req.Code == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "code is required"})
+			return
+		}
+
+		t, err := m.load(uid)
+		if err != nil || t == nil || t.ConfirmedAt != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no pending 2fa enrollment"})
+			return
+		}
+		step, ok := totp.Validate(t.Secret, req.Code, time.Now())
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid code", "code": "MFA_INVALID_CODE"})
+			return
+		}
+
+		var codes []string
+		err = m.db.Transaction(func(tx *gorm.DB) error {
+			now := time.Now()
+			if err := tx.Model(&types.UserTOTP{}).Where("user_id = ?", uid).
+				Updates(map[string]any{"confirmed_at": now, "last_used_step": step}).Error; err != nil {
+				return err
+			}
+			var err error
+			codes, err = replaceRecoveryCodes(tx, uid)
+			return err
+		})
+		if err != nil {
+			log.Printf("[confirmTOTP] err: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to enable 2fa"})
+			return
+		}
+
+		resp := types.TOTPConfirmResp{RecoveryCodes: codes}
+		if c.GetBool(mfaEnrollPurpose) {
+			u, ok := loadUserByID(c, m.db, uid)
+			if !ok {
+				return
+			}
+			tokens, _, err := issueTokens(c, m.db, u, m.issuer, m.ks, uuid.New())
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "signing failed"})
+				return
+			}
+			resp.Tokens = &tokens
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// regenerateRecoveryCodes ponistava stare i vraca nove kodove; trazi vazeci TOTP kod.
+func regenerateRecoveryCodes(m *MFA) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, _ := middleware.UserID(c)
+		var req types.TOTPCodeReq
+		if err := c.ShouldBindJSON(&req); err != nil || req.Code == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "code is required"})
+			return
+		}
+		if err := m.verifyCode(uid, req.Code); err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid code", "code": "MFA_INVALID_CODE"})
+			return
+		}
+
+		var codes []string
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			codes, err = replaceRecoveryCodes(tx, uid)
+			return err
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create recovery codes"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+	}
+}
+
+// disableTOTP gasi 2FA korisniku cija uloga ga ne zahteva.
+func disableTOTP(m *MFA) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, _ := middleware.UserID(c)
+		if m.required[middleware.Role(c)] {
+			c.JSON(http.StatusForbidden, gin.H{"error": "2fa is required for this role"})
+			return
+		}
+		var req types.TOTPCodeReq
+		if err := c.ShouldBindJSON(&req); err != nil || req.Code == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "code is required"})
+			return
+		}
+		if err := m.verifyCode(uid, req.Code); err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid code", "code": "MFA_INVALID_CODE"})
+			return
+		}
+		if err := m.reset(uid); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to disable 2fa"})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// adminResetTOTP (admin) brise 2FA korisniku koji je izgubio uredjaj i recovery kodove.
+func adminResetTOTP(m *MFA) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		u, ok := loadUserParam(c, m.db)
+		if !ok {
+			return
+		}
+		if err := m.reset(u.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reset 2fa"})
+			return
+		}
+		if err := revokeAllForUser(m.db, u.ID); err != nil {
+			log.Printf("[adminResetTOTP] revoke err: %v", err)
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+/* ===================== Helpers ===================== */
+
+func (m *MFA) load(userID uint) (*types.UserTOTP, error) {
+	var t types.UserTOTP
+	if err := m.db.First(&t, "user_id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+// verifyCode proverava TOTP kod i pamti korak, da isti kod ne bi mogao dvaput.
+func (m *MFA) verifyCode(userID uint, code string) error {
+	t, err := m.load(userID)
+	if err != nil {
+		return err
+	}
+	if t == nil || t.ConfirmedAt == nil {
+		return errInvalidMFACode
+	}
+	step, ok := totp.Validate(t.Secret, code, time.Now())
+	if !ok || step <= t.LastUsedStep {
+		return errInvalidMFACode
+	}
+	res := m.db.Model(&types.UserTOTP{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Update("last_used_step", step)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errInvalidMFACode
+	}
+	return nil
+}
+
+func (m *MFA) useRecoveryCode(userID uint, code string) error {
+	res := m.db.Model(&types.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hashToken(normalizeRecoveryCode(code))).
+		Update("used_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errInvalidMFACode
+	}
+	return nil
+}
+
+func (m *MFA) reset(userID uint) error {
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&types.UserTOTP{}, "user_id = ?", userID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&types.RecoveryCode{}, "user_id = ?", userID).Error
+	})
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Delete(&types.RecoveryCode{}, "user_id = ?", userID).Error; err != nil {
+		return nil, err
+	}
+	enc := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, 0, recoveryCodeCount)
+	rows := make([]types.RecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		buf := make([]byte, 7)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		raw := strings.ToLower(enc.EncodeToString(buf))[:10]
+		code := raw[:5] + "-" + raw[5:]
+		codes = append(codes, code)
+		rows = append(rows, types.RecoveryCode{ID: uuid.New(), UserID: userID, CodeHash: hashToken(raw)})
+	}
+	if err := tx.Create(&rows).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
+
+func loadUserByID(c *gin.Context, db *gorm.DB, id uint) (types.User, bool) {
+	var u types.User
+	if err := db.First(&u, "id = ?", id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return types.User{}, false
+	}
+	return u, true
+}
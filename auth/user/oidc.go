@@ -0,0 +1,564 @@
+package user
+
+import (
+	"auth/keys"
+	"auth/middleware"
+	"auth/types"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OIDC provajder za druge fakultetske sisteme (biblioteka, menza): authorization code + PKCE.
+// Nema SSO sesije ni consent ekrana; svaki /oidc/authorize trazi prijavu lozinkom
+// (i 2FA kodom ako ga nalog ima). Klijenti dobijaju ID token i access token koji vazi
+// samo za /oidc/userinfo, nikad token sa dozvolama za nase servise.
+
+const (
+	oidcCodeTTL       = time.Minute
+	oidcAccessPurpose = "oidc_userinfo"
+)
+
+var oidcScopes = []string{"openid", "profile", "email"}
+
+type OIDC struct {
+	db     *gorm.DB
+	ks     *keys.Store
+	mfa    *MFA
+	th     *Throttle
+	issuer string
+}
+
+func NewOIDC(db *gorm.DB, ks *keys.Store, mfa *MFA, th *Throttle, issuer string) *OIDC {
+	return &OIDC{db: db, ks: ks, mfa: mfa, th: th, issuer: issuer}
+}
+
+// oidcAccessClaims: access token za userinfo. Nema role/id, pa ga RequireAuth odbija.
+type oidcAccessClaims struct {
+	Scope    string `json:"scope"`
+	ClientID string `json:"client_id"`
+	Purpose  string `json:"purpose"`
+	jwt.RegisteredClaims
+}
+
+// authorizeReq su parametri /oidc/authorize; forma za prijavu ih nosi kao skrivena polja.
+type authorizeReq struct {
+	ClientID      string
+	ClientName    string
+	RedirectURI   string
+	Scope         string
+	State         string
+	Nonce         string
+	CodeChallenge string
+}
+
+func oidcDiscovery(o *OIDC) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=3600")
+		c.JSON(http.StatusOK, gin.H{
+			"issuer":                                o.issuer,
+			"authorization_endpoint":                o.issuer + "/oidc/authorize",
+			"token_endpoint":                        o.issuer + "/oidc/token",
+			"userinfo_endpoint":                     o.issuer + "/oidc/userinfo",
+			"jwks_uri":                              o.issuer + "/.well-known/jwks.json",
+			"scopes_supported":                      oidcScopes,
+			"response_types_supported":              []string{"code"},
+			"grant_types_supported":                 []string{"authorization_code"},
+			"subject_types_supported":               []string{"public"},
+			"id_token_signing_alg_values_supported": []string{jwt.SigningMethodEdDSA.Alg()},
+			"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+			"code_challenge_methods_supported":      []string{"S256"},
+			"claims_supported": []string{
+				"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce",
+				"email", "email_verified", "name", "given_name", "family_name",
+			},
+		})
+	}
+}
+
+// oidcAuthorizeForm prikazuje formu za prijavu posle provere klijenta i parametara.
+func oidcAuthorizeForm(o *OIDC) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req, ok := o.authorizeRequest(c)
+		if !ok {
+			return
+		}
+		renderLogin(c, http.StatusOK, loginPage{Req: req})
+	}
+}
+
+// oidcAuthorize proverava lozinku (isto kao /login) i 2FA kod, pa vraca code na redirect_uri.
+func oidcAuthorize(o *OIDC) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		req, ok := o.authorizeRequest(c)
+		if !ok {
+			return
+		}
+		email := normalizeEmail(c.PostForm("email"))
+		page := loginPage{Req: req, Email: email}
+		if email == "" || c.PostForm("password") == "" {
+			page.Error = "Unesite email i lozinku."
+			renderLogin(c, http.StatusBadRequest, page)
+			return
+		}
+
+		u, err := authenticate(c, o.db, o.th, email, c.PostForm("password"))
+		if err != nil {
+			var d *throttleDenial
+			status := http.StatusUnauthorized
+			switch {
+			case errors.As(err, &d):
+				status, page.Error = d.status, "Previse neuspelih pokusaja, pokusajte kasnije."
+			case errors.Is(err, errInvalidCredentials):
+				page.Error = "Pogresan email ili lozinka."
+			case errors.Is(err, errAccountDisabled):
+				status, page.Error = http.StatusForbidden, "Nalog je onemogucen."
+			case errors.Is(err, errEmailNotVerified):
+				status, page.Error = http.StatusForbidden, "Email adresa nije potvrdjena."
+			default:
+				log.Printf("[oidcAuthorize] authenticate err: %v", err)
+				status, page.Error = http.StatusInternalServerError, "Greska na serveru."
+			}
+			renderLogin(c, status, page)
+			return
+		}
+
+		t, err := o.mfa.load(u.ID)
+		if err != nil {
+			renderLogin(c, http.StatusInternalServerError, loginPage{Req: req, Email: email, Error: "Greska na serveru."})
+			return
+		}
+		switch {
+		case t != nil && t.ConfirmedAt != nil:
+			code := strings.TrimSpace(c.PostForm("code"))
+			if code == "" {
+				renderLogin(c, http.StatusUnauthorized, loginPage{Req: req, Email: email, Error: "Unesite kod iz aplikacije za 2FA."})
+				return
+			}
+			if err := o.mfa.verifyCode(u.ID, code); err != nil {
+				if !errors.Is(err, errInvalidMFACode) {
+					log.Printf("[oidcAuthorize] verify code err: %v", err)
+				} else {
+					if ferr := o.th.Fail(c, email); ferr != nil {
+						log.Printf("[oidcAuthorize] record failure err: %v", ferr)
+					}
+					audit(c, o.db, types.AuthEvent{Type: types.EventLoginMFA, Outcome: types.OutcomeFailure, Reason: "invalid_code", SubjectID: &u.ID, Email: email})
+				}
+				renderLogin(c, http.StatusUnauthorized, loginPage{Req: req, Email: email, Error: "Pogresan 2FA kod."})
+				return
+			}
+		case o.mfa.required[u.Role]:
+			// Upis 2FA ide kroz eUprava aplikaciju, ne kroz tudji sajt
+			renderLogin(c, http.StatusForbidden, loginPage{Req: req, Email: email, Error: "Nalog mora prvo da ukljuci 2FA u eUprava aplikaciji."})
+			return
+		}
+		if err := o.th.Success(email); err != nil {
+			log.Printf("[oidcAuthorize] reset attempts err: %v", err)
+		}
+		auditLoginSuccess(c, o.db, types.EventLogin, u, types.OutcomeSuccess, "oidc:"+req.ClientID)
+
+		raw, err := randomToken()
+		if err != nil {
+			renderLogin(c, http.StatusInternalServerError, loginPage{Req: req, Email: email, Error: "Greska na serveru."})
+			return
+		}
+		now := time.Now()
+		if err := o.db.Create(&types.OIDCAuthCode{
+			CodeHash:      hashToken(raw),
+			ClientID:      req.ClientID,
+			UserID:        u.ID,
+			RedirectURI:   req.RedirectURI,
+			Scope:         req.Scope,
+			Nonce:         req.Nonce,
+			CodeChallenge: req.CodeChallenge,
+			AuthTime:      now,
+			ExpiresAt:     now.Add(oidcCodeTTL),
+		}).Error; err != nil {
+			log.Printf("[oidcAuthorize] save code err: %v", err)
+			renderLogin(c, http.StatusInternalServerError, loginPage{Req: req, Email: email, Error: "Greska na serveru."})
+			return
+		}
+
+		redirectWith(c, req.RedirectURI, url.Values{"code": {raw}, "state": {req.State}})
+	}
+}
+
+// oidcToken menja authorization code za ID i access token.
+func oidcToken(o *OIDC) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "no-store")
+		c.Header("Pragma", "no-cache")
+
+		if c.PostForm("grant_type") != "authorization_code" {
+			oauthError(c, http.StatusBadRequest, "unsupported_grant_type", "only authorization_code is supported")
+			return
+		}
+		client, ok := o.authenticateClient(c)
+		if !ok {
+			return
+		}
+
+		// Code se trosi atomski: drugi pokusaj sa istim code-om ne nalazi red
+		now := time.Now()
+		var ac types.OIDCAuthCode
+		if err := o.db.Raw(`
+			UPDATE oidc_auth_codes SET used_at = ?
+			WHERE code_hash = ? AND used_at IS NULL AND expires_at > ?
+			RETURNING *`,
+			now, hashToken(c.PostForm("code")), now,
+		).Scan(&ac).Error; err != nil {
+			oauthError(c, http.StatusInternalServerError, "server_error", "failed to load code")
+			return
+		}
+		if ac.CodeHash == "" || ac.ClientID != client.ClientID || ac.RedirectURI != c.PostForm("redirect_uri") {
+			oauthError(c, http.StatusBadRequest, "invalid_grant", "invalid or expired code")
+			return
+		}
+		if !pkceMatches(c.PostForm("code_verifier"), ac.CodeChallenge) {
+			oauthError(c, http.StatusBadRequest, "invalid_grant", "code_verifier does not match")
+			return
+		}
+
+		var u types.User
+		if err := o.db.First(&u, "id = ?", ac.UserID).Error; err != nil || !u.Status.CanSignIn() {
+			oauthError(c, http.StatusBadRequest, "invalid_grant", "account is not active")
+			return
+		}
+
+		access, err := o.signAccessToken(u, client.ClientID, ac.Scope)
+		if err != nil {
+			oauthError(c, http.StatusInternalServerError, "server_error", "signing failed")
+			return
+		}
+		idToken, err := o.signIDToken(u, client.ClientID, ac)
+		if err != nil {
+			oauthError(c, http.StatusInternalServerError, "server_error", "signing failed")
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"access_token": access,
+			"token_type":   "Bearer",
+			"expires_in":   int64(accessTokenTTL.Seconds()),
+			"id_token":     idToken,
+			"scope":        ac.Scope,
+		})
+	}
+}
+
+// oidcUserinfo vraca claim-ove naloga prema scope-u iz access tokena.
+func oidcUserinfo(o *OIDC) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		raw, ok := middleware.BearerToken(c)
+		if !ok {
+			c.Header("WWW-Authenticate", `Bearer`)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_token"})
+			return
+		}
+
+		var claims oidcAccessClaims
+		_, err := jwt.ParseWithClaims(raw, &claims, func(t *jwt.Token) (any, error) {
+			kid, _ := t.Header["kid"].(string)
+			if k, ok := o.ks.Lookup(kid); ok {
+				return k, nil
+			}
+			return nil, errors.New("unknown kid")
+		},
+			jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg()}),
+			jwt.WithIssuer(o.issuer),
+			jwt.WithExpirationRequired(),
+		)
+		uid, perr := strconv.ParseUint(claims.Subject, 10, 64)
+		if err != nil || perr != nil || claims.Purpose != oidcAccessPurpose {
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_token"})
+			return
+		}
+
+		// Odjava sa svih uredjaja i gasenje naloga vaze i za tokene izdate klijentima
+		revoked, err := middleware.IsRevoked(o.db, uint(uid), claims.ID, claims.IssuedAt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+			return
+		}
+		var u types.User
+		if revoked || o.db.First(&u, "id = ?", uid).Error != nil || !u.Status.CanSignIn() {
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid_token"})
+			return
+		}
+
+		c.JSON(http.StatusOK, userClaims(u, claims.Scope))
+	}
+}
+
+/* ===================== Helpers ===================== */
+
+// authorizeRequest proverava klijenta i redirect_uri. Dok redirect_uri nije potvrdjen,
+// greska se prikazuje korisniku; posle toga ide nazad klijentu (?error=...).
+func (o *OIDC) authorizeRequest(c *gin.Context) (authorizeReq, bool) {
+	f := c.Request.FormValue
+	req := authorizeReq{
+		ClientID:      f("client_id"),
+		RedirectURI:   f("redirect_uri"),
+		State:         f("state"),
+		Nonce:         f("nonce"),
+		CodeChallenge: f("code_challenge"),
+	}
+
+	var client types.OIDCClient
+	if err := o.db.Preload("RedirectURIs").First(&client, "client_id = ?", req.ClientID).Error; err != nil {
+		renderLogin(c, http.StatusBadRequest, loginPage{Fatal: "Nepoznata aplikacija (client_id)."})
+		return req, false
+	}
+	registered := false
+	for _, r := range client.RedirectURIs {
+		if r.URI == req.RedirectURI {
+			registered = true
+			break
+		}
+	}
+	if !registered {
+		renderLogin(c, http.StatusBadRequest, loginPage{Fatal: "Adresa za povratak (redirect_uri) nije registrovana za ovu aplikaciju."})
+		return req, false
+	}
+	req.ClientName = client.Name
+
+	fail := func(code, desc string) (authorizeReq, bool) {
+		redirectWith(c, req.RedirectURI, url.Values{"error": {code}, "error_description": {desc}, "state": {req.State}})
+		return req, false
+	}
+	if f("response_type") != "code" {
+		return fail("unsupported_response_type", "only response_type=code is supported")
+	}
+	if req.CodeChallenge == "" || f("code_challenge_method") != "S256" {
+		return fail("invalid_request", "PKCE with code_challenge_method=S256 is required")
+	}
+
+	// Zadrzavaju se samo scope-ovi koje klijent sme da trazi; openid je obavezan
+	allowed := strings.Fields(client.Scopes)
+	var scopes []string
+	for _, s := range strings.Fields(f("scope")) {
+		if contains(allowed, s) && !contains(scopes, s) {
+			scopes = append(scopes, s)
+		}
+	}
+	if !contains(scopes, "openid") {
+		return fail("invalid_scope", "scope must include openid")
+	}
+	req.Scope = strings.Join(scopes, " ")
+	return req, true
+}
+
+// authenticateClient: poverljivi klijent salje secret (Basic ili u formi), javni samo client_id.
+func (o *OIDC) authenticateClient(c *gin.Context) (types.OIDCClient, bool) {
+	id, secret, basic := c.Request.BasicAuth()
+	if !basic {
+		id, secret = c.PostForm("client_id"), c.PostForm("client_secret")
+	}
+
+	var client types.OIDCClient
+	err := o.db.First(&client, "client_id = ?", id).Error
+	if err == nil && !client.Public {
+		if subtle.ConstantTimeCompare([]byte(hashToken(secret)), []byte(client.SecretHash)) != 1 {
+			err = errors.New("bad secret")
+		}
+	}
+	if err != nil {
+		if basic {
+			c.Header("WWW-Authenticate", `Basic realm="oidc"`)
+		}
+		oauthError(c, http.StatusUnauthorized, "invalid_client", "client authentication failed")
+		return types.OIDCClient{}, false
+	}
+	return client, true
+}
+
+func (o *OIDC) signAccessToken(u types.User, clientID, scope string) (string, error) {
+	now := time.Now()
+	key := o.ks.Signing()
+	tok := jwt.NewWithClaims(jwt.SigningMethodEdDSA, oidcAccessClaims{
+		Scope:    scope,
+		ClientID: clientID,
+		Purpose:  oidcAccessPurpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.FormatUint(uint64(u.ID), 10),
+			Issuer:    o.issuer,
+			Audience:  jwt.ClaimStrings{o.issuer + "/oidc/userinfo"},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(accessTokenTTL)),
+			ID:        uuid.NewString(),
+		},
+	})
+	tok.Header["kid"] = key.ID
+	return tok.SignedString(key.Private)
+}
+
+func (o *OIDC) signIDToken(u types.User, clientID string, ac types.OIDCAuthCode) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":       o.issuer,
+		"aud":       clientID,
+		"azp":       clientID,
+		"iat":       now.Unix(),
+		"exp":       now.Add(accessTokenTTL).Unix(),
+		"auth_time": ac.AuthTime.Unix(),
+	}
+	if ac.Nonce != "" {
+		claims["nonce"] = ac.Nonce
+	}
+	for k, v := range userClaims(u, ac.Scope) {
+		claims[k] = v
+	}
+
+	key := o.ks.Signing()
+	tok := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	tok.Header["kid"] = key.ID
+	return tok.SignedString(key.Private)
+}
+
+// userClaims: sub je id naloga (email se menja), ostalo samo uz odgovarajuci scope.
+func userClaims(u types.User, scope string) gin.H {
+	out := gin.H{"sub": strconv.FormatUint(uint64(u.ID), 10)}
+	scopes := strings.Fields(scope)
+	if contains(scopes, "email") {
+		out["email"] = u.Email
+		out["email_verified"] = u.Status != types.StatusPendingVerification
+	}
+	if contains(scopes, "profile") {
+		out["name"] = strings.TrimSpace(u.FirstName + " " + u.LastName)
+		out["given_name"] = u.FirstName
+		out["family_name"] = u.LastName
+	}
+	return out
+}
+
+// pkceMatches: BASE64URL(SHA256(code_verifier)) == code_challenge (RFC 7636, S256).
+func pkceMatches(verifier, challenge string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	got := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(got), []byte(challenge)) == 1
+}
+
+func redirectWith(c *gin.Context, target string, params url.Values) {
+	u, err := url.Parse(target)
+	if err != nil {
+		renderLogin(c, http.StatusBadRequest, loginPage{Fatal: "Neispravna adresa za povratak."})
+		return
+	}
+	q := u.Query()
+	for k, v := range params {
+		if len(v) > 0 && v[0] != "" {
+			q[k] = v
+		}
+	}
+	u.RawQuery = q.Encode()
+
+	status := http.StatusFound
+	if c.Request.Method == http.MethodPost {
+		status = http.StatusSeeOther
+	}
+	c.Redirect(status, u.String())
+}
+
+func oauthError(c *gin.Context, status int, code, desc string) {
+	c.JSON(status, gin.H{"error": code, "error_description": desc})
+}
+
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+/* ===================== Login page ===================== */
+
+type loginPage struct {
+	Req   authorizeReq
+	Email string
+	Error string
+	Fatal string // greska posle koje forma nema smisla (nepoznat klijent)
+}
+
+var loginTmpl = template.Must(template.New("login").Parse(`<!doctype html>
+<html lang="sr">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>eUprava - prijava</title>
+<style>
+body { font-family: system-ui, sans-serif; background: #f4f5f7; display: flex; justify-content: center; padding-top: 10vh; }
+main { background: #fff; padding: 2rem; border-radius: 8px; width: 22rem; box-shadow: 0 1px 4px rgba(0,0,0,.1); }
+label { display: block; margin-top: 1rem; font-size: .9rem; }
+input { width: 100%; padding: .5rem; box-sizing: border-box; }
+button { margin-top: 1.5rem; width: 100%; padding: .6rem; }
+.error { color: #b00020; }
+</style>
+</head>
+<body>
+<main>
+<h1>eUprava</h1>
+{{if .Fatal}}
+<p class="error">{{.Fatal}}</p>
+{{else}}
+<p>Aplikacija <strong>{{.Req.ClientName}}</strong> trazi prijavu eUprava nalogom.</p>
+{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+<form method="post">
+<input type="hidden" name="response_type" value="code">
+<input type="hidden" name="client_id" value="{{.Req.ClientID}}">
+<input type="hidden" name="redirect_uri" value="{{.Req.RedirectURI}}">
+<input type="hidden" name="scope" value="{{.Req.Scope}}">
+<input type="hidden" name="state" value="{{.Req.State}}">
+<input type="hidden" name="nonce" value="{{.Req.Nonce}}">
+<input type="hidden" name="code_challenge" value="{{.Req.CodeChallenge}}">
+<input type="hidden" name="code_challenge_method" value="S256">
+<label>Email <input type="email" name="email" value="{{.Email}}" required autofocus></label>
+<label>Lozinka <input type="password" name="password" required></label>
+<label>2FA kod (ako je ukljucen) <input name="code" inputmode="numeric" autocomplete="one-time-code"></label>
+<button type="submit">Prijavi se</button>
+</form>
+{{end}}
+</main>
+</body>
+</html>
+`))
+
+func renderLogin(c *gin.Context, status int, p loginPage) {
+	// Forma sa lozinkom ne sme u tudji iframe
+	c.Header("X-Frame-Options", "DENY")
+	c.Header("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; frame-ancestors 'none'")
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(status)
+	if err := loginTmpl.Execute(c.Writer, p); err != nil {
+		log.Printf("[oidc] render login err: %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+package user
+
+import (
+	"auth/types"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// createOIDCClient (admin) registruje klijenta; secret se vraca samo ovde.
+func createOIDCClient(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req types.OIDCClientReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
+			return
+		}
+		req.Name = strings.TrimSpace(req.Name)
+		if req.Name == "" || len(req.RedirectURIs) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name and redirectUris are required"})
+			return
+		}
+
+		scopes := []string{"openid"}
+		for _, s := range req.Scopes {
+			if !contains(oidcScopes, s) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "unknown scope", "scope": s})
+				return
+			}
+			if !contains(scopes, s) {
+				scopes = append(scopes, s)
+			}
+		}
+
+		idBuf := make([]byte, 12)
+		if _, err := rand.Read(idBuf); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create client"})
+			return
+		}
+		client := types.OIDCClient{
+			ClientID: hex.EncodeToString(idBuf),
+			Name:     req.Name,
+			Public:   req.Public,
+			Scopes:   strings.Join(scopes, " "),
+		}
+		for _, raw := range req.RedirectURIs {
+			if !validRedirectURI(raw) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid redirect uri", "uri": raw})
+				return
+			}
+			client.RedirectURIs = append(client.RedirectURIs, types.OIDCRedirectURI{URI: raw})
+		}
+
+		var secret string
+		if !req.Public {
+			var err error
+			if secret, err = randomToken(); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create client"})
+				return
+			}
+			client.SecretHash = hashToken(secret)
+		}
+
+		if err := db.WithContext(c.Request.Context()).Create(&client).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save client"})
+			return
+		}
+		c.JSON(http.StatusCreated, types.OIDCClientCreatedResp{OIDCClient: client, ClientSecret: secret})
+	}
+}
+
+func listOIDCClients(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var clients []types.OIDCClient
+		if err := db.WithContext(c.Request.Context()).
+			Preload("RedirectURIs").
+			Order("name").
+			Find(&clients).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load clients"})
+			return
+		}
+		c.JSON(http.StatusOK, clients)
+	}
+}
+
+// deleteOIDCClient brise klijenta; redirect adrese i neiskorisceni code-ovi idu kaskadno.
+// Vec izdati tokeni vaze do isteka (najvise accessTokenTTL).
+func deleteOIDCClient(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		res := db.WithContext(c.Request.Context()).Delete(&types.OIDCClient{}, "client_id = ?", c.Param("id"))
+		if res.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete client"})
+			return
+		}
+		if res.RowsAffected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "client not found"})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// validRedirectURI: apsolutna adresa bez fragmenta; http samo za lokalni razvoj.
+func validRedirectURI(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || u.Fragment != "" {
+		return false
+	}
+	switch u.Scheme {
+	case "https":
+		return true
+	case "http":
+		h := u.Hostname()
+		return h == "localhost" || h == "127.0.0.1"
+	}
+	return false
+}
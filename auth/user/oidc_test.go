@@ -0,0 +1,258 @@
+package user
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"auth/config"
+	"auth/keys"
+	"auth/types"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Lokalni test klijent (npr. biblioteka) prolazi ceo tok: authorize -> code + PKCE -> token -> userinfo.
+// Baza je SQLite u memoriji (vidi openTestDB).
+
+const (
+	testIssuer      = "http://auth.test"
+	testClientID    = "library"
+	testRedirectURI = "https://library.test/callback"
+	testEmail       = "ana@student.test"
+	testPassword    = "correct horse battery"
+)
+
+type oidcTestEnv struct {
+	srv *httptest.Server
+	ks  *keys.Store
+}
+
+func newOIDCTestEnv(t *testing.T) *oidcTestEnv {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	db := openTestDB(t,
+		&types.User{}, &types.UserTOTP{}, &types.LoginAttempt{}, &types.AuthEvent{},
+		&types.RevokedToken{}, &types.TokenCutoff{},
+		&types.OIDCClient{}, &types.OIDCRedirectURI{}, &types.OIDCAuthCode{},
+	)
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	if err := db.Create(&types.User{
+		Email: testEmail, Password: string(hash), FirstName: "Ana", LastName: "Jovanovic",
+		Role: "STUDENT", Status: types.StatusActive,
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&types.OIDCClient{
+		ClientID: testClientID, Name: "Biblioteka", Public: true, Scopes: "openid profile email",
+		RedirectURIs: []types.OIDCRedirectURI{{URI: testRedirectURI}},
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	ks, err := keys.Open(t.TempDir(), time.Hour, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := NewOIDC(db, ks, NewMFA(db, ks, testIssuer, nil), NewThrottle(db, config.Config{}), testIssuer)
+
+	r := gin.New()
+	r.POST("/oidc/authorize", oidcAuthorize(o))
+	r.POST("/oidc/token", oidcToken(o))
+	r.GET("/oidc/userinfo", oidcUserinfo(o))
+
+	env := &oidcTestEnv{srv: httptest.NewServer(r), ks: ks}
+	t.Cleanup(env.srv.Close)
+	return env
+}
+
+// authorize prijavljuje korisnika i vraca code iz preusmerenja na redirect_uri.
+func (e *oidcTestEnv) authorize(t *testing.T, challenge string) string {
+	t.Helper()
+	res := e.postAuthorize(t, testRedirectURI, challenge)
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusFound && res.StatusCode != http.StatusSeeOther {
+		t.Fatalf("authorize: status %d, want redirect", res.StatusCode)
+	}
+	loc, err := url.Parse(res.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := loc.Scheme + "://" + loc.Host + loc.Path; got != testRedirectURI {
+		t.Fatalf("authorize: redirected to %q", got)
+	}
+	if loc.Query().Get("state") != "st-1" {
+		t.Fatalf("authorize: state not echoed: %q", loc.RawQuery)
+	}
+	code := loc.Query().Get("code")
+	if code == "" {
+		t.Fatalf("authorize: no code in %q", loc.RawQuery)
+	}
+	return code
+}
+
+func (e *oidcTestEnv) postAuthorize(t *testing.T, redirectURI, challenge string) *http.Response {
+	t.Helper()
+	form := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {testClientID},
+		"redirect_uri":          {redirectURI},
+		"scope":                 {"openid email profile"},
+		"state":                 {"st-1"},
+		"nonce":                 {"n-1"},
+		"code_challenge":        {challenge},
+		"code_challenge_method": {"S256"},
+		"email":                 {testEmail},
+		"password":              {testPassword},
+	}
+	httpc := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	res, err := httpc.PostForm(e.srv.URL+"/oidc/authorize", form)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func (e *oidcTestEnv) token(t *testing.T, code, redirectURI, verifier string) (int, map[string]any) {
+	t.Helper()
+	res, err := http.PostForm(e.srv.URL+"/oidc/token", url.Values{
+		"grant_type":    {"authorization_code"},
+		"client_id":     {testClientID},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {verifier},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	var body map[string]any
+	_ = json.NewDecoder(res.Body).Decode(&body)
+	return res.StatusCode, body
+}
+
+func newPKCE(t *testing.T) (verifier, challenge string) {
+	t.Helper()
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		t.Fatal(err)
+	}
+	verifier = base64.RawURLEncoding.EncodeToString(buf)
+	sum := sha256.Sum256([]byte(verifier))
+	return verifier, base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func TestOIDCAuthorizationCodeFlow(t *testing.T) {
+	env := newOIDCTestEnv(t)
+	verifier, challenge := newPKCE(t)
+
+	code := env.authorize(t, challenge)
+	status, tok := env.token(t, code, testRedirectURI, verifier)
+	if status != http.StatusOK {
+		t.Fatalf("token: status %d, body %v", status, tok)
+	}
+	access, _ := tok["access_token"].(string)
+	rawID, _ := tok["id_token"].(string)
+	if access == "" || rawID == "" || tok["token_type"] != "Bearer" {
+		t.Fatalf("token: unexpected body %v", tok)
+	}
+
+	// ID token: potpis kljucem iz JWKS, aud = klijent, nonce iz authorize zahteva
+	var idc jwt.MapClaims
+	if _, err := jwt.ParseWithClaims(rawID, &idc, func(tk *jwt.Token) (any, error) {
+		kid, _ := tk.Header["kid"].(string)
+		if k, ok := env.ks.Lookup(kid); ok {
+			return k, nil
+		}
+		return nil, fmt.Errorf("unknown kid %q", kid)
+	}, jwt.WithIssuer(testIssuer), jwt.WithAudience(testClientID)); err != nil {
+		t.Fatalf("id_token: %v", err)
+	}
+	if idc["nonce"] != "n-1" || idc["email"] != testEmail {
+		t.Fatalf("id_token: unexpected claims %v", idc)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, env.srv.URL+"/oidc/userinfo", nil)
+	req.Header.Set("Authorization", "Bearer "+access)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	var info map[string]any
+	_ = json.NewDecoder(res.Body).Decode(&info)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("userinfo: status %d, body %v", res.StatusCode, info)
+	}
+	if info["sub"] != idc["sub"] || info["email"] != testEmail || info["given_name"] != "Ana" {
+		t.Fatalf("userinfo: unexpected claims %v", info)
+	}
+
+	// ID token nije access token za userinfo
+	req.Header.Set("Authorization", "Bearer "+rawID)
+	res2, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res2.Body.Close()
+	if res2.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("userinfo with id_token: status %d, want 401", res2.StatusCode)
+	}
+}
+
+func TestOIDCTokenRejects(t *testing.T) {
+	env := newOIDCTestEnv(t)
+
+	t.Run("wrong code_verifier", func(t *testing.T) {
+		_, challenge := newPKCE(t)
+		other, _ := newPKCE(t)
+		code := env.authorize(t, challenge)
+		status, body := env.token(t, code, testRedirectURI, other)
+		if status != http.StatusBadRequest || body["error"] != "invalid_grant" {
+			t.Fatalf("status %d, body %v; want 400 invalid_grant", status, body)
+		}
+	})
+
+	t.Run("reused code", func(t *testing.T) {
+		verifier, challenge := newPKCE(t)
+		code := env.authorize(t, challenge)
+		if status, body := env.token(t, code, testRedirectURI, verifier); status != http.StatusOK {
+			t.Fatalf("first exchange: status %d, body %v", status, body)
+		}
+		status, body := env.token(t, code, testRedirectURI, verifier)
+		if status != http.StatusBadRequest || body["error"] != "invalid_grant" {
+			t.Fatalf("second exchange: status %d, body %v; want 400 invalid_grant", status, body)
+		}
+	})
+
+	t.Run("mismatched redirect_uri", func(t *testing.T) {
+		verifier, challenge := newPKCE(t)
+		code := env.authorize(t, challenge)
+		status, body := env.token(t, code, "https://library.test/other", verifier)
+		if status != http.StatusBadRequest || body["error"] != "invalid_grant" {
+			t.Fatalf("status %d, body %v; want 400 invalid_grant", status, body)
+		}
+	})
+
+	t.Run("unregistered redirect_uri at authorize", func(t *testing.T) {
+		_, challenge := newPKCE(t)
+		res := env.postAuthorize(t, "https://evil.test/callback", challenge)
+		res.Body.Close()
+		// Greska se prikazuje korisniku; nema preusmerenja na neregistrovanu adresu
+		if res.StatusCode != http.StatusBadRequest || strings.Contains(res.Header.Get("Location"), "evil") {
+			t.Fatalf("status %d, location %q; want 400 without redirect", res.StatusCode, res.Header.Get("Location"))
+		}
+	})
+}
@@ -0,0 +1,197 @@
+package user
+
+import (
+	"auth/mail"
+	"auth/pwpolicy"
+	"auth/types"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const resetTokenTTL = 30 * time.Minute
+
+var errResetTokenInvalid = errors.New("invalid or expired reset token")
+
+// passwordPolicyError prekida reset u transakciji pre nego sto se token iskoristi,
+// da bi korisnik mogao da pokusa ponovo istim linkom.
+type passwordPolicyError struct {
+	violations []pwpolicy.Violation
+}
+
+func (e *passwordPolicyError) Error() string { return "password does not meet policy" }
+
+// rejectPassword vraca 400 sa kodovima prekrsaja (klijent ih prevodi); false = lozinka je u redu.
+func rejectPassword(c *gin.Context, violations []pwpolicy.Violation) bool {
+	if len(violations) == 0 {
+		return false
+	}
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":      "password does not meet policy",
+		"code":       "PASSWORD_POLICY",
+		"violations": violations,
+	})
+	return true
+}
+
+func identityOf(u types.User) pwpolicy.Identity {
+	return pwpolicy.Identity{Email: u.Email, FirstName: u.FirstName, LastName: u.LastName}
+}
+
+// forgotPassword uvek vraca 202, da se preko odgovora ne bi moglo proveriti koji mejl postoji.
+func forgotPassword(db *gorm.DB, mailer mail.Sender, publicURL string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req types.ForgotPasswordReq
+		if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Email) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "email is required"})
+			return
+		}
+		accepted := gin.H{"message": "if the account exists, a reset link has been sent"}
+
+		u, err := getUserByEmailAndPassword(db, strings.TrimSpace(strings.ToLower(req.Email)))
+		if err != nil || !u.Status.CanSignIn() {
+			c.JSON(http.StatusAccepted, accepted)
+			return
+		}
+
+		if err := sendResetLink(c.Request.Context(), db, mailer, publicURL, u); err != nil {
+			log.Printf("[forgotPassword] err: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create reset token"})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, accepted)
+	}
+}
+
+// sendResetLink pravi jednokratni token i salje link; greska slanja mejla se samo loguje.
+func sendResetLink(ctx context.Context, db *gorm.DB, mailer mail.Sender, publicURL string, u types.User) error {
+	raw, err := createResetToken(db.WithContext(ctx), u.ID, resetTokenTTL)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", strings.TrimRight(publicURL, "/"), url.QueryEscape(raw))
+	msg := mail.Message{
+		To:      u.Email,
+		Subject: "eGovernment - reset lozinke",
+		Body: fmt.Sprintf("Zdravo %s,\n\nza novu lozinku otvorite link ispod (vazi %d minuta):\n%s\n\nAko niste trazili reset, ignorisite ovu poruku.\n",
+			u.FirstName, int(resetTokenTTL.Minutes()), link),
+	}
+	if err := mailer.Send(ctx, msg); err != nil {
+		log.Printf("[sendResetLink] send mail err: %v", err)
+	}
+	return nil
+}
+
+func resetPassword(db *gorm.DB, pw *pwpolicy.Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req types.ResetPasswordReq
+		if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Token) == "" || req.NewPassword == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "token and newPassword are required"})
+			return
+		}
+
+		hash, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to hash password"})
+			return
+		}
+
+		var userID uint
+		err = db.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+			var t types.PasswordResetToken
+			if err := tx.Where("token_hash = ?", hashToken(req.Token)).First(&t).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return errResetTokenInvalid
+				}
+				return err
+			}
+			if time.Now().After(t.ExpiresAt) {
+				return errResetTokenInvalid
+			}
+			var u types.User
+			if err := tx.First(&u, "id = ?", t.UserID).Error; err != nil {
+				return err
+			}
+			if v := pw.Check(req.NewPassword, identityOf(u)); len(v) > 0 {
+				return &passwordPolicyError{v}
+			}
+
+			// Uslov na used_at cini token jednokratnim i kod paralelnih zahteva
+			res := tx.Model(&types.PasswordResetToken{}).
+				Where("id = ? AND used_at IS NULL", t.ID).
+				Update("used_at", time.Now())
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				return errResetTokenInvalid
+			}
+			userID = t.UserID
+
+			if err := tx.Model(&types.User{}).Where("id = ?", t.UserID).Update("password", string(hash)).Error; err != nil {
+				return err
+			}
+			// Link je stigao na mejl naloga, pa reset ujedno potvrdjuje adresu (uvezeni nalozi se tako aktiviraju)
+			if err := tx.Model(&types.User{}).
+				Where("id = ? AND status = ?", t.UserID, types.StatusPendingVerification).
+				Update("status", types.StatusActive).Error; err != nil {
+				return err
+			}
+			// Ostali neiskorisceni linkovi i sve postojece sesije prestaju da vaze
+			if err := tx.Model(&types.PasswordResetToken{}).
+				Where("user_id = ? AND used_at IS NULL", t.UserID).
+				Update("used_at", time.Now()).Error; err != nil {
+				return err
+			}
+			return revokeAllForUser(tx, t.UserID)
+		})
+
+		var policyErr *passwordPolicyError
+		switch {
+		case err == nil:
+			audit(c, db, types.AuthEvent{Type: types.EventPasswordReset, Outcome: types.OutcomeSuccess, SubjectID: &userID})
+			c.Status(http.StatusNoContent)
+		case errors.As(err, &policyErr):
+			rejectPassword(c, policyErr.violations)
+		case errors.Is(err, errResetTokenInvalid):
+			audit(c, db, types.AuthEvent{Type: types.EventPasswordReset, Outcome: types.OutcomeFailure, Reason: "invalid_token"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			log.Printf("[resetPassword] err: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reset password"})
+		}
+	}
+}
+
+func createResetToken(db *gorm.DB, userID uint, ttl time.Duration) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	raw := base64.RawURLEncoding.EncodeToString(buf)
+
+	t := types.PasswordResetToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		TokenHash: hashToken(raw),
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := db.Create(&t).Error; err != nil {
+		return "", err
+	}
+	return raw, nil
+}
@@ -0,0 +1,61 @@
+package user
+
+import (
+	"auth/keys"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// purposeClaims su kratkotrajni tokeni za jednu namenu (potvrda mejla, 2FA korak).
+// Potpisani su istim kljucem kao access token, ali ih RequireAuth odbija jer nemaju role/id.
+type purposeClaims struct {
+	Email   string `json:"email,omitempty"`
+	Purpose string `json:"purpose"`
+	jwt.RegisteredClaims
+}
+
+var errInvalidPurposeToken = errors.New("invalid or expired token")
+
+func signPurposeToken(ks *keys.Store, issuer, purpose string, userID uint, email string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	key := ks.Signing()
+	tok := jwt.NewWithClaims(jwt.SigningMethodEdDSA, purposeClaims{
+		Email:   email,
+		Purpose: purpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.FormatUint(uint64(userID), 10),
+			Issuer:    issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	})
+	tok.Header["kid"] = key.ID
+	return tok.SignedString(key.Private)
+}
+
+// parsePurposeToken vraca id korisnika i mejl iz tokena, samo ako je namena ista.
+func parsePurposeToken(ks *keys.Store, issuer, purpose, raw string) (uint, string, error) {
+	var claims purposeClaims
+	_, err := jwt.ParseWithClaims(raw, &claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		if k, ok := ks.Lookup(kid); ok {
+			return k, nil
+		}
+		return nil, errors.New("unknown kid")
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil || claims.Purpose != purpose {
+		return 0, "", errInvalidPurposeToken
+	}
+	id, err := strconv.ParseUint(claims.Subject, 10, 64)
+	if err != nil {
+		return 0, "", errInvalidPurposeToken
+	}
+	return uint(id), claims.Email, nil
+}
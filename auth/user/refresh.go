@@ -0,0 +1,135 @@
+package user
+
+import (
+	"auth/keys"
+	"auth/types"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var errRefreshReused = errors.New("refresh token reuse detected")
+
+// newRefreshToken pravi nasumican (opaque) token; u bazu ide samo hash.
+func newRefreshToken(userID uint, family uuid.UUID) (types.RefreshToken, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return types.RefreshToken{}, "", err
+	}
+	raw := base64.RawURLEncoding.EncodeToString(buf)
+
+	return types.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		FamilyID:  family,
+		TokenHash: hashToken(raw),
+		ExpiresAt: time.Now().Add(refreshTokenTTL),
+	}, raw, nil
+}
+
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// revokeFamily gasi sve jos aktivne tokene iz iste porodice i njenu sesiju.
+func revokeFamily(db *gorm.DB, family uuid.UUID) error {
+	now := time.Now()
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&types.RefreshToken{}).
+			Where("family_id = ? AND revoked_at IS NULL", family).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&types.Session{}).
+			Where("id = ? AND revoked_at IS NULL", family).
+			Update("revoked_at", now).Error
+	})
+}
+
+func refresh(db *gorm.DB, issuer string, ks *keys.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req types.RefreshReq
+		if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.RefreshToken) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "refresh_token is required"})
+			return
+		}
+
+		var resp types.LoginResp
+		err := db.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+			var old types.RefreshToken
+			if err := tx.Where("token_hash = ?", hashToken(req.RefreshToken)).First(&old).Error; err != nil {
+				return err
+			}
+
+			// Vec rotiran token je ponovo poslat: neko ima kopiju, gasimo ceo lanac
+			if old.RevokedAt != nil {
+				return errRefreshReused
+			}
+			if time.Now().After(old.ExpiresAt) {
+				return gorm.ErrRecordNotFound
+			}
+
+			var u types.User
+			if err := tx.First(&u, "id = ?", old.UserID).Error; err != nil {
+				return err
+			}
+			if !u.Status.CanSignIn() {
+				return gorm.ErrRecordNotFound
+			}
+
+			next, nextID, err := issueTokens(c, tx, u, issuer, ks, old.FamilyID)
+			if err != nil {
+				return err
+			}
+
+			// Uslov na revoked_at sprecava da dva paralelna zahteva rotiraju isti token
+			res := tx.Model(&types.RefreshToken{}).
+				Where("id = ? AND revoked_at IS NULL", old.ID).
+				Updates(map[string]any{"revoked_at": time.Now(), "replaced_by": nextID})
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				return errRefreshReused
+			}
+
+			resp = next
+			return nil
+		})
+
+		switch {
+		case err == nil:
+			c.JSON(http.StatusOK, resp)
+		case errors.Is(err, errRefreshReused):
+			// Van transakcije, da revokacija ne bi bila ponistena rollback-om
+			if rerr := revokeFamilyByToken(db, req.RefreshToken); rerr != nil {
+				log.Printf("[refresh] revoke family err: %v", rerr)
+			}
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token reused"})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+		default:
+			log.Printf("[refresh] err: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to refresh token"})
+		}
+	}
+}
+
+func revokeFamilyByToken(db *gorm.DB, raw string) error {
+	var rt types.RefreshToken
+	if err := db.Where("token_hash = ?", hashToken(raw)).First(&rt).Error; err != nil {
+		return err
+	}
+	return revokeFamily(db, rt.FamilyID)
+}
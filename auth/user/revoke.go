@@ -0,0 +1,161 @@
+package user
+
+import (
+	"auth/middleware"
+	"auth/types"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// logout opoziva trenutni access token (po jti) i njegovu sesiju; za starije tokene
+// bez sid-a sesija se nalazi preko poslatog refresh tokena.
+// Admin njime zavrsava i impersonaciju.
+func logout(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := middleware.ClaimsFrom(c)
+		if !ok || claims.RegisteredClaims.ID == "" || claims.ExpiresAt == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "token has no jti"})
+			return
+		}
+
+		var req types.LogoutReq
+		if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
+			return
+		}
+
+		err := db.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+			rt := types.RevokedToken{
+				JTI:       claims.RegisteredClaims.ID,
+				UserID:    claims.ID,
+				ExpiresAt: claims.ExpiresAt.Time,
+			}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rt).Error; err != nil {
+				return err
+			}
+			if sid, err := uuid.Parse(claims.Sid); err == nil {
+				return revokeFamily(tx, sid)
+			}
+
+			if raw := strings.TrimSpace(req.RefreshToken); raw != "" {
+				var old types.RefreshToken
+				err := tx.Where("token_hash = ? AND user_id = ?", hashToken(raw), claims.ID).First(&old).Error
+				if err == nil {
+					return revokeFamily(tx, old.FamilyID)
+				}
+				if !errors.Is(err, gorm.ErrRecordNotFound) {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			log.Printf("[logout] err: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to logout"})
+			return
+		}
+		if claims.Act != nil {
+			audit(c, db, types.AuthEvent{Type: types.EventImpersonationEnd, Outcome: types.OutcomeSuccess, SubjectID: &claims.ID, Email: claims.Subject})
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// revokeSessions (admin) ponistava sve izdate tokene korisnika, npr. posle promene uloge.
+func revokeSessions(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+			return
+		}
+
+		if err := revokeAllForUser(db.WithContext(c.Request.Context()), uint(id)); err != nil {
+			log.Printf("[revokeSessions] err: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke sessions"})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// revokeAllForUser postavlja cutoff na sada i gasi sve refresh tokene i sesije korisnika.
+func revokeAllForUser(db *gorm.DB, userID uint) error {
+	now := time.Now()
+	return db.Transaction(func(tx *gorm.DB) error {
+		cut := types.TokenCutoff{UserID: userID, NotBefore: now}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"not_before"}),
+		}).Create(&cut).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&types.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&types.Session{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", now).Error
+	})
+}
+
+// revocations vraca opoziv koji je jos relevantan (tokeni koji nisu istekli).
+// Interni endpoint: student-housing i open-data ga kesiraju; proxy ga ne izlaze spolja.
+func revocations(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		now := time.Now()
+
+		var jtis []string
+		if err := db.Model(&types.RevokedToken{}).
+			Where("expires_at > ?", now).
+			Pluck("jti", &jtis).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load revocations"})
+			return
+		}
+
+		var cuts []types.TokenCutoff
+		if err := db.Where("not_before > ?", now.Add(-accessTokenTTL)).Find(&cuts).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load revocations"})
+			return
+		}
+
+		// Access tokeni odjavljene sesije isticu najkasnije accessTokenTTL posle odjave
+		var sessions []string
+		if err := db.Model(&types.Session{}).
+			Where("revoked_at > ?", now.Add(-accessTokenTTL)).
+			Pluck("id::text", &sessions).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load revocations"})
+			return
+		}
+		if sessions == nil {
+			sessions = []string{}
+		}
+
+		users := make(map[string]int64, len(cuts))
+		for _, cut := range cuts {
+			users[strconv.FormatUint(uint64(cut.UserID), 10)] = cut.NotBefore.Unix()
+		}
+		if jtis == nil {
+			jtis = []string{}
+		}
+
+		c.JSON(http.StatusOK, types.RevocationList{
+			JTIs:        jtis,
+			Users:       users,
+			Sessions:    sessions,
+			GeneratedAt: now.Unix(),
+		})
+	}
+}
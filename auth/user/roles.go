@@ -0,0 +1,201 @@
+package user
+
+import (
+	"auth/rbac"
+	"auth/types"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Izmene uloga i domova vaze za tokene izdate posle izmene; postojeci access
+// tokeni ih dobijaju pri sledecem /refresh (najkasnije posle accessTokenTTL).
+
+func listPermissions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, types.AllPermissions)
+	}
+}
+
+func listRoles(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var roles []types.RoleDefinition
+		if err := db.WithContext(c.Request.Context()).
+			Preload("Permissions", func(tx *gorm.DB) *gorm.DB { return tx.Order("permission") }).
+			Order("name").
+			Find(&roles).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load roles"})
+			return
+		}
+		c.JSON(http.StatusOK, roles)
+	}
+}
+
+func createRole(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req types.RoleReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
+			return
+		}
+		name := types.Role(strings.ToUpper(strings.TrimSpace(req.Name)))
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+			return
+		}
+		perms, ok := parsePermissions(c, req.Permissions)
+		if !ok {
+			return
+		}
+
+		role := types.RoleDefinition{Name: name, Description: req.Description}
+		err := db.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+			res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&role)
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				return errRoleExists
+			}
+			return replaceRolePermissions(tx, name, perms)
+		})
+		if errors.Is(err, errRoleExists) {
+			c.JSON(http.StatusConflict, gin.H{"error": "role already exists"})
+			return
+		}
+		if err != nil {
+			log.Printf("[createRole] err: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create role"})
+			return
+		}
+		role.Permissions = perms
+		c.JSON(http.StatusCreated, role)
+	}
+}
+
+// setRolePermissions zamenjuje ceo skup dozvola uloge.
+func setRolePermissions(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := types.Role(strings.ToUpper(c.Param("name")))
+		if name == types.Admin {
+			// ADMIN uvek ima ceo katalog (vidi rbac.Seed); inace bi admin mogao sam sebi da zakljuca sistem
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ADMIN permissions cannot be changed"})
+			return
+		}
+
+		var req types.RoleReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
+			return
+		}
+		perms, ok := parsePermissions(c, req.Permissions)
+		if !ok {
+			return
+		}
+
+		err := db.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+			var role types.RoleDefinition
+			if err := tx.First(&role, "name = ?", name).Error; err != nil {
+				return err
+			}
+			return replaceRolePermissions(tx, name, perms)
+		})
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "role not found"})
+			return
+		}
+		if err != nil {
+			log.Printf("[setRolePermissions] err: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update role"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"name": name, "permissions": perms})
+	}
+}
+
+// setDormScopes zamenjuje listu domova na koje je korisnik ogranicen; prazna lista skida ogranicenje.
+func setDormScopes(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		u, ok := loadUserParam(c, db)
+		if !ok {
+			return
+		}
+		var req types.DormScopesReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "bad request"})
+			return
+		}
+
+		err := db.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("user_id = ?", u.ID).Delete(&types.UserDormScope{}).Error; err != nil {
+				return err
+			}
+			if len(req.DormIDs) == 0 {
+				return nil
+			}
+			rows := make([]types.UserDormScope, 0, len(req.DormIDs))
+			for _, d := range req.DormIDs {
+				rows = append(rows, types.UserDormScope{UserID: u.ID, DormID: d})
+			}
+			return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+		})
+		if err != nil {
+			log.Printf("[setDormScopes] err: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update dorm scopes"})
+			return
+		}
+
+		dorms, err := rbac.DormScopes(db, u.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load dorm scopes"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"userId": u.ID, "dormIds": dorms})
+	}
+}
+
+/* ===================== Helpers ===================== */
+
+var errRoleExists = errors.New("role exists")
+
+func parsePermissions(c *gin.Context, raw []string) ([]types.RolePermission, bool) {
+	seen := map[string]bool{}
+	var names []string
+	for _, p := range raw {
+		p = strings.TrimSpace(p)
+		if !rbac.IsKnownPermission(p) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown permission", "permission": p})
+			return nil, false
+		}
+		if !seen[p] {
+			seen[p] = true
+			names = append(names, p)
+		}
+	}
+	sort.Strings(names)
+
+	perms := make([]types.RolePermission, 0, len(names))
+	for _, p := range names {
+		perms = append(perms, types.RolePermission{Permission: types.Permission(p)})
+	}
+	return perms, true
+}
+
+func replaceRolePermissions(tx *gorm.DB, role types.Role, perms []types.RolePermission) error {
+	if err := tx.Where("role_name = ?", role).Delete(&types.RolePermission{}).Error; err != nil {
+		return err
+	}
+	if len(perms) == 0 {
+		return nil
+	}
+	for i := range perms {
+		perms[i].RoleName = role
+	}
+	return tx.Create(&perms).Error
+}
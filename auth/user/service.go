@@ -1,19 +1,35 @@
 package user
 
 import (
+	"auth/keys"
+	"auth/pwpolicy"
+	"auth/rbac"
 	"auth/types"
 	"errors"
+	"log"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgconn"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+const (
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 7 * 24 * time.Hour
+)
+
+// MaxSignedTokenTTL je najduze trajanje JWT-a koji auth potpisuje (access, OIDC, masinski,
+// potvrda mejla, 2FA, impersonacija). Toliko posle rotacije stari kljuc ostaje u JWKS.
+func MaxSignedTokenTTL() time.Duration {
+	return max(accessTokenTTL, machineTokenTTL, verifyTokenTTL, mfaTokenTTL, impersonationTTL)
+}
+
 func getUserByEmailAndPassword(db *gorm.DB, email string) (types.User, error) {
 	var u types.User
 	if err := db.Where("email = ?", email).First(&u).Error; err != nil {
@@ -25,7 +41,7 @@ func getUserByEmailAndPassword(db *gorm.DB, email string) (types.User, error) {
 	return u, nil
 }
 
-func createUser(db *gorm.DB) gin.HandlerFunc {
+func createUser(db *gorm.DB, verifier *Verifier, pw *pwpolicy.Policy) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var in types.User
 		if err := c.ShouldBindJSON(&in); err != nil {
@@ -40,6 +56,9 @@ func createUser(db *gorm.DB) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "email and password are required"})
 			return
 		}
+		if rejectPassword(c, pw.Check(in.Password, pwpolicy.Identity{Email: in.Email, FirstName: in.FirstName, LastName: in.LastName})) {
+			return
+		}
 
 		hash, err := bcrypt.GenerateFromPassword([]byte(in.Password), bcrypt.DefaultCost)
 		if err != nil {
@@ -53,11 +72,13 @@ func createUser(db *gorm.DB) gin.HandlerFunc {
 			FirstName: in.FirstName,
 			LastName:  in.LastName,
 			Role:      "STUDENT",
+			Status:    types.StatusPendingVerification,
 		}
 
 		if err := db.WithContext(c.Request.Context()).Create(&u).Error; err != nil {
 			var pgErr *pgconn.PgError
 			if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+				audit(c, db, types.AuthEvent{Type: types.EventRegister, Outcome: types.OutcomeFailure, Reason: "email_exists", Email: u.Email})
 				c.JSON(http.StatusConflict, gin.H{"error": "email already exists"})
 				return
 			}
@@ -65,14 +86,21 @@ func createUser(db *gorm.DB) gin.HandlerFunc {
 			return
 		}
 
+		audit(c, db, types.AuthEvent{Type: types.EventRegister, Outcome: types.OutcomeSuccess, SubjectID: &u.ID, Email: u.Email})
+
+		if err := verifier.Send(c.Request.Context(), u); err != nil {
+			log.Printf("[createUser] send verification err: %v", err)
+		}
+
 		c.JSON(http.StatusCreated, types.User{
-			ID:    u.ID,
-			Email: u.Email,
+			ID:     u.ID,
+			Email:  u.Email,
+			Status: u.Status,
 		})
 	}
 }
 
-func login(db *gorm.DB, issuer string, secret []byte) gin.HandlerFunc {
+func login(db *gorm.DB, issuer string, ks *keys.Store, mfa *MFA, th *Throttle) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req types.LoginReq
 		if err := c.ShouldBindJSON(&req); err != nil {
@@ -89,40 +117,178 @@ func login(db *gorm.DB, issuer string, secret []byte) gin.HandlerFunc {
 			return
 		}
 
-		u, err := getUserByEmailAndPassword(db, email)
+		u, err := authenticate(c, db, th, email, req.Password)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+			writeAuthError(c, err)
 			return
 		}
 
-		if err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(req.Password)); err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		// Drugi korak: access token se izdaje tek posle TOTP koda (POST /login/2fa)
+		challenged, err := mfa.Challenge(c, u)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check 2fa"})
 			return
 		}
-
-		now := time.Now()
-		exp := now.Add(15 * time.Minute)
-
-		claims := jwt.MapClaims{
-			"sub":  u.Email,
-			"iss":  issuer,
-			"role": u.Role,
-			"id":   u.ID,
-			"iat":  now.Unix(),
-			"exp":  exp.Unix(),
+		// Kod 2FA naloga brojac se resetuje tek posle tacnog koda
+		if challenged {
+			auditLoginSuccess(c, db, types.EventLogin, u, types.OutcomeChallenged, "")
+			return
+		}
+		if err := th.Success(email); err != nil {
+			log.Printf("[login] reset attempts err: %v", err)
 		}
 
-		tok := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-		signed, err := tok.SignedString(secret)
+		resp, _, err := issueTokens(c, db, u, issuer, ks, uuid.New())
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "signing failed"})
 			return
 		}
 
-		c.JSON(http.StatusOK, types.LoginResp{
-			AccessToken: signed,
-			ExpiresIn:   int64(15 * 60),
-			TokenType:   "Bearer",
-		})
+		auditLoginSuccess(c, db, types.EventLogin, u, types.OutcomeSuccess, "")
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+var (
+	errInvalidCredentials = errors.New("invalid credentials")
+	errAccountDisabled    = errors.New("account disabled")
+	errEmailNotVerified   = errors.New("email not verified")
+)
+
+// authenticate je provera lozinke koju dele /login i OIDC forma za prijavu: zastita
+// od pogadjanja, lozinka i stanje naloga. Drugi korak (2FA) je na pozivaocu.
+func authenticate(c *gin.Context, db *gorm.DB, th *Throttle, email, password string) (types.User, error) {
+	d, err := th.deny(c.ClientIP(), email)
+	if err != nil {
+		return types.User{}, err
+	}
+	if d != nil {
+		auditLoginFailure(c, db, email, types.User{}, d)
+		return types.User{}, d
+	}
+
+	u, err := getUserByEmailAndPassword(db, email)
+	if err == nil {
+		err = bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
+	}
+	if err != nil {
+		if ferr := th.Fail(c, email); ferr != nil {
+			log.Printf("[login] record failure err: %v", ferr)
+		}
+		auditLoginFailure(c, db, email, u, errInvalidCredentials)
+		return types.User{}, errInvalidCredentials
+	}
+
+	// Lozinka je tacna, pa smemo da otkrijemo stanje naloga
+	if !u.Status.CanSignIn() {
+		auditLoginFailure(c, db, email, u, errAccountDisabled)
+		return types.User{}, errAccountDisabled
+	}
+	if u.Status == types.StatusPendingVerification {
+		auditLoginFailure(c, db, email, u, errEmailNotVerified)
+		return types.User{}, errEmailNotVerified
 	}
+	return u, nil
+}
+
+// writeAuthError prevodi gresku iz authenticate u JSON odgovor /login-a.
+func writeAuthError(c *gin.Context, err error) {
+	var d *throttleDenial
+	switch {
+	case errors.As(err, &d):
+		reject(c, d.status, d.code, d.msg, d.retry)
+	case errors.Is(err, errInvalidCredentials):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+	case errors.Is(err, errAccountDisabled):
+		c.JSON(http.StatusForbidden, gin.H{"error": "account disabled", "code": "ACCOUNT_DISABLED"})
+	case errors.Is(err, errEmailNotVerified):
+		c.JSON(http.StatusForbidden, gin.H{"error": "email not verified", "code": "EMAIL_NOT_VERIFIED"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check login attempts"})
+	}
+}
+
+// signAccessToken potpisuje kratkotrajni access token sa claim-ovima koje citaju ostali servisi.
+// Dozvole se razresavaju pri izdavanju, pa izmena uloge stize do servisa najkasnije za accessTokenTTL.
+// sid vezuje token za sesiju, da bi odjava sesije ugasila i access token.
+func signAccessToken(db *gorm.DB, u types.User, issuer string, ks *keys.Store, sid uuid.UUID) (string, error) {
+	claims, err := accessClaims(db, u, issuer, accessTokenTTL)
+	if err != nil {
+		return "", err
+	}
+	claims["sid"] = sid.String()
+	return signClaims(ks, claims)
+}
+
+func accessClaims(db *gorm.DB, u types.User, issuer string, ttl time.Duration) (jwt.MapClaims, error) {
+	perms, err := rbac.Permissions(db, u.Role)
+	if err != nil {
+		return nil, err
+	}
+	dorms, err := rbac.DormScopes(db, u.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	exp := now.Add(ttl)
+
+	claims := jwt.MapClaims{
+		"sub":   u.Email,
+		"iss":   issuer,
+		"role":  u.Role,
+		"id":    u.ID,
+		"iat":   now.Unix(),
+		"exp":   exp.Unix(),
+		"jti":   uuid.NewString(),
+		"perms": perms,
+	}
+	if len(dorms) > 0 {
+		claims["dorms"] = dorms
+	}
+	return claims, nil
+}
+
+func signClaims(ks *keys.Store, claims jwt.MapClaims) (string, error) {
+	key := ks.Signing()
+	tok := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	tok.Header["kid"] = key.ID
+	return tok.SignedString(key.Private)
+}
+
+func jwks(ks *keys.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Verifikatori kesiraju; kratak max-age da nova rotacija brzo stigne do njih
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, ks.JWKS())
+	}
+}
+
+// issueTokens vraca access token i novi refresh token (i njegov id) u zadatoj porodici.
+// issueTokens izdaje par tokena za sesiju family (nova porodica = novi login) i belezi
+// uredjaj i IP sa kog je sesija poslednji put koriscena.
+func issueTokens(c *gin.Context, db *gorm.DB, u types.User, issuer string, ks *keys.Store, family uuid.UUID) (types.LoginResp, uuid.UUID, error) {
+	signed, err := signAccessToken(db, u, issuer, ks, family)
+	if err != nil {
+		return types.LoginResp{}, uuid.Nil, err
+	}
+
+	rt, raw, err := newRefreshToken(u.ID, family)
+	if err != nil {
+		return types.LoginResp{}, uuid.Nil, err
+	}
+	if err := db.Create(&rt).Error; err != nil {
+		return types.LoginResp{}, uuid.Nil, err
+	}
+	if err := touchSession(c, db, u.ID, family, rt.ExpiresAt); err != nil {
+		return types.LoginResp{}, uuid.Nil, err
+	}
+
+	return types.LoginResp{
+		AccessToken:      signed,
+		ExpiresIn:        int64(accessTokenTTL.Seconds()),
+		TokenType:        "Bearer",
+		RefreshToken:     raw,
+		RefreshExpiresIn: int64(refreshTokenTTL.Seconds()),
+	}, rt.ID, nil
 }
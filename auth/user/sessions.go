@@ -0,0 +1,195 @@
+package user
+
+import (
+	"auth/middleware"
+	"auth/types"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Sesije: jedan login = jedna porodica refresh tokena. Opozvana sesija gubi refresh
+// token odmah, a njeni access tokeni padaju preko sid-a u /revocations.
+
+var errSessionNotFound = errors.New("session not found")
+
+func listMySessions(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, _ := middleware.UserID(c)
+		listSessions(c, db, uid)
+	}
+}
+
+// revokeMySession odjavljuje jednu sesiju (moze i trenutnu).
+func revokeMySession(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, _ := middleware.UserID(c)
+		revokeSessionParam(c, db, uid)
+	}
+}
+
+// revokeMySessions je "odjavi me svuda"; sa ?keepCurrent=true ostaje samo trenutna sesija.
+func revokeMySessions(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, _ := middleware.UserID(c)
+		claims, _ := middleware.ClaimsFrom(c)
+
+		var err error
+		if c.Query("keepCurrent") == "true" && claims.Sid != "" {
+			err = db.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+				var ids []uuid.UUID
+				if err := tx.Model(&types.Session{}).
+					Where("user_id = ? AND revoked_at IS NULL AND id <> ?", uid, claims.Sid).
+					Pluck("id", &ids).Error; err != nil {
+					return err
+				}
+				for _, id := range ids {
+					if err := revokeFamily(tx, id); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		} else {
+			// Cutoff gasi i access tokene izdate pre uvodjenja sesija (bez sid-a)
+			err = revokeAllForUser(db.WithContext(c.Request.Context()), uid)
+		}
+		if err != nil {
+			log.Printf("[revokeMySessions] err: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke sessions"})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// listUserSessions (admin) vraca aktivne sesije korisnika :id.
+func listUserSessions(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		u, ok := loadUserParam(c, db)
+		if !ok {
+			return
+		}
+		listSessions(c, db, u.ID)
+	}
+}
+
+// revokeUserSession (admin) odjavljuje jednu sesiju korisnika :id.
+func revokeUserSession(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		u, ok := loadUserParam(c, db)
+		if !ok {
+			return
+		}
+		revokeSessionParam(c, db, u.ID)
+	}
+}
+
+/* ===================== Helpers ===================== */
+
+// touchSession pravi sesiju pri loginu, a pri svakoj rotaciji pomera last_used_at i istek.
+func touchSession(c *gin.Context, db *gorm.DB, userID uint, id uuid.UUID, expires time.Time) error {
+	now := time.Now()
+	ua := c.Request.UserAgent()
+	if len(ua) > maxUserAgentChars {
+		ua = ua[:maxUserAgentChars]
+	}
+	s := types.Session{
+		ID:         id,
+		UserID:     userID,
+		UserAgent:  ua,
+		IP:         c.ClientIP(),
+		CreatedAt:  now,
+		LastUsedAt: now,
+		LastIP:     c.ClientIP(),
+		ExpiresAt:  expires,
+	}
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"last_used_at", "last_ip", "expires_at"}),
+	}).Create(&s).Error
+}
+
+func listSessions(c *gin.Context, db *gorm.DB, userID uint) {
+	var sessions []types.Session
+	if err := db.WithContext(c.Request.Context()).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_used_at DESC").
+		Find(&sessions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load sessions"})
+		return
+	}
+
+	claims, _ := middleware.ClaimsFrom(c)
+	items := make([]types.SessionInfo, 0, len(sessions))
+	for _, s := range sessions {
+		items = append(items, types.SessionInfo{
+			Session: s,
+			Device:  describeDevice(s.UserAgent),
+			Current: s.ID.String() == claims.Sid,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
+
+func revokeSessionParam(c *gin.Context, db *gorm.DB, userID uint) {
+	id, err := uuid.Parse(c.Param("sid"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session id"})
+		return
+	}
+	err = db.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+		var s types.Session
+		if err := tx.First(&s, "id = ? AND user_id = ?", id, userID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errSessionNotFound
+			}
+			return err
+		}
+		return revokeFamily(tx, s.ID)
+	})
+	switch {
+	case err == nil:
+		c.Status(http.StatusNoContent)
+	case errors.Is(err, errSessionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		log.Printf("[revokeSession] err: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke session"})
+	}
+}
+
+// describeDevice pravi kratak opis ("Firefox on Windows") za prikaz; pun user agent ostaje u odgovoru.
+func describeDevice(ua string) string {
+	if ua == "" {
+		return "Unknown device"
+	}
+	browser := "Unknown browser"
+	// Redosled je bitan: Edge i Opera se predstavljaju i kao Chrome, Chrome i kao Safari
+	for _, b := range []struct{ token, name string }{
+		{"Edg/", "Edge"}, {"OPR/", "Opera"}, {"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"}, {"Safari/", "Safari"}, {"okhttp", "Android app"},
+		{"curl/", "curl"}, {"PostmanRuntime", "Postman"},
+	} {
+		if strings.Contains(ua, b.token) {
+			browser = b.name
+			break
+		}
+	}
+	for _, o := range []struct{ token, name string }{
+		{"Android", "Android"}, {"iPhone", "iOS"}, {"iPad", "iPadOS"},
+		{"Windows", "Windows"}, {"Mac OS X", "macOS"}, {"Linux", "Linux"},
+	} {
+		if strings.Contains(ua, o.token) {
+			return browser + " on " + o.name
+		}
+	}
+	return browser
+}
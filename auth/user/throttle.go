@@ -0,0 +1,186 @@
+package user
+
+import (
+	"auth/config"
+	"auth/types"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	attemptAccount = "account"
+	attemptIP      = "ip"
+)
+
+// Throttle prati neuspele login pokusaje po nalogu i po IP adresi.
+type Throttle struct {
+	db  *gorm.DB
+	cfg config.Config
+}
+
+func NewThrottle(db *gorm.DB, cfg config.Config) *Throttle {
+	return &Throttle{db: db, cfg: cfg}
+}
+
+// Check vraca false (i upisuje 423/429 sa Retry-After) ako pokusaj sada nije dozvoljen.
+func (t *Throttle) Check(c *gin.Context, email string) (bool, error) {
+	d, err := t.deny(c.ClientIP(), email)
+	if err != nil {
+		return false, err
+	}
+	if d != nil {
+		reject(c, d.status, d.code, d.msg, d.retry)
+		return false, nil
+	}
+	return true, nil
+}
+
+// throttleDenial opisuje zasto pokusaj nije dozvoljen; OIDC forma ga prikazuje kao stranu.
+type throttleDenial struct {
+	status int
+	code   string
+	msg    string
+	retry  time.Duration
+}
+
+func (d *throttleDenial) Error() string { return d.msg }
+
+func (t *Throttle) deny(clientIP, email string) (*throttleDenial, error) {
+	now := time.Now()
+
+	acc, err := t.load(attemptAccount, email)
+	if err != nil {
+		return nil, err
+	}
+	if acc != nil && acc.LockedUntil != nil && now.Before(*acc.LockedUntil) {
+		return &throttleDenial{http.StatusLocked, "ACCOUNT_LOCKED", "account temporarily locked", acc.LockedUntil.Sub(now)}, nil
+	}
+	if wait := t.backoff(acc, now); wait > 0 {
+		return &throttleDenial{http.StatusTooManyRequests, "LOGIN_BACKOFF", "too many failed attempts, try again later", wait}, nil
+	}
+
+	ip, err := t.load(attemptIP, clientIP)
+	if err != nil {
+		return nil, err
+	}
+	if ip != nil && ip.LockedUntil != nil && now.Before(*ip.LockedUntil) {
+		return &throttleDenial{http.StatusTooManyRequests, "IP_THROTTLED", "too many failed attempts from this address", ip.LockedUntil.Sub(now)}, nil
+	}
+	return nil, nil
+}
+
+// Fail belezi neuspeh za nalog i IP i zakljucava ih kada predju prag.
+func (t *Throttle) Fail(c *gin.Context, email string) error {
+	if err := t.record(attemptAccount, email, t.cfg.LoginMaxFailures); err != nil {
+		return err
+	}
+	return t.record(attemptIP, c.ClientIP(), t.cfg.LoginIPMaxFailures)
+}
+
+// Success resetuje brojac naloga. IP brojac se ne dira: inace bi napadac sa jednim
+// sopstvenim nalogom mogao da ga resetuje izmedju pokusaja; on istice posle prozora.
+func (t *Throttle) Success(email string) error {
+	return t.db.Delete(&types.LoginAttempt{}, "kind = ? AND key = ?", attemptAccount, normalizeEmail(email)).Error
+}
+
+// Unlock (admin) brise brojac i zakljucavanje naloga.
+func (t *Throttle) Unlock(email string) error {
+	return t.Success(email)
+}
+
+func (t *Throttle) load(kind, key string) (*types.LoginAttempt, error) {
+	if kind == attemptAccount {
+		key = normalizeEmail(key)
+	}
+	var a types.LoginAttempt
+	if err := t.db.First(&a, "kind = ? AND key = ?", kind, key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if time.Since(a.LastFailureAt) > t.cfg.LoginFailureWindow && (a.LockedUntil == nil || time.Now().After(*a.LockedUntil)) {
+		return nil, nil
+	}
+	return &a, nil
+}
+
+// backoff: prvih LoginFreeAttempts neuspeha bez cekanja, zatim base * 2^n (najvise LoginBackoffMax).
+func (t *Throttle) backoff(a *types.LoginAttempt, now time.Time) time.Duration {
+	if a == nil || a.Failures < t.cfg.LoginFreeAttempts {
+		return 0
+	}
+	n := a.Failures - t.cfg.LoginFreeAttempts
+	wait := time.Duration(float64(t.cfg.LoginBackoffBase) * math.Pow(2, float64(n)))
+	if wait > t.cfg.LoginBackoffMax || wait <= 0 {
+		wait = t.cfg.LoginBackoffMax
+	}
+	return time.Until(a.LastFailureAt.Add(wait))
+}
+
+func (t *Throttle) record(kind, key string, max int) error {
+	if kind == attemptAccount {
+		key = normalizeEmail(key)
+	}
+	now := time.Now()
+	windowStart := now.Add(-t.cfg.LoginFailureWindow)
+
+	// Atomski upsert: stari brojac (van prozora) krece od 1
+	var failures int
+	if err := t.db.Raw(`
+		INSERT INTO login_attempts (kind, key, failures, last_failure_at)
+		VALUES (?, ?, 1, ?)
+		ON CONFLICT (kind, key) DO UPDATE SET
+			failures = CASE WHEN login_attempts.last_failure_at < ? THEN 1 ELSE login_attempts.failures + 1 END,
+			last_failure_at = EXCLUDED.last_failure_at
+		RETURNING failures`,
+		kind, key, now, windowStart,
+	).Scan(&failures).Error; err != nil {
+		return err
+	}
+
+	if max > 0 && failures >= max {
+		until := now.Add(t.cfg.LoginLockout)
+		return t.db.Model(&types.LoginAttempt{}).
+			Where("kind = ? AND key = ?", kind, key).
+			Update("locked_until", until).Error
+	}
+	return nil
+}
+
+// unlockUser (admin) otkljucava nalog i brise brojac neuspeha.
+func unlockUser(db *gorm.DB, th *Throttle) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		u, ok := loadUserParam(c, db)
+		if !ok {
+			return
+		}
+		if err := th.Unlock(u.Email); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unlock account"})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+/* ===================== Helpers ===================== */
+
+func reject(c *gin.Context, status int, code, msg string, retry time.Duration) {
+	secs := int(math.Ceil(retry.Seconds()))
+	if secs < 1 {
+		secs = 1
+	}
+	c.Header("Retry-After", fmt.Sprintf("%d", secs))
+	c.JSON(status, gin.H{"error": msg, "code": code, "retryAfter": secs})
+}
+
+func normalizeEmail(e string) string {
+	return strings.TrimSpace(strings.ToLower(e))
+}
@@ -0,0 +1,178 @@
+package user
+
+import (
+	"auth/keys"
+	"auth/mail"
+	"auth/types"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	verifyTokenTTL     = 48 * time.Hour
+	verifyEmailPurpose = "verify_email"
+)
+
+// Verifier salje i proverava linkove za potvrdu mejla.
+type Verifier struct {
+	db        *gorm.DB
+	ks        *keys.Store
+	mailer    mail.Sender
+	issuer    string
+	publicURL string
+}
+
+func NewVerifier(db *gorm.DB, ks *keys.Store, mailer mail.Sender, issuer, publicURL string) *Verifier {
+	return &Verifier{db: db, ks: ks, mailer: mailer, issuer: issuer, publicURL: strings.TrimRight(publicURL, "/")}
+}
+
+// Send potpisuje link i salje ga korisniku.
+func (v *Verifier) Send(ctx context.Context, u types.User) error {
+	// Token je vezan za mejl, pa link prestaje da vazi ako se mejl promeni
+	signed, err := signPurposeToken(v.ks, v.issuer, verifyEmailPurpose, u.ID, u.Email, verifyTokenTTL)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/verify-email?token=%s", v.publicURL, url.QueryEscape(signed))
+	return v.mailer.Send(ctx, mail.Message{
+		To:      u.Email,
+		Subject: "eGovernment - potvrda email adrese",
+		Body: fmt.Sprintf("Zdravo %s,\n\nda biste aktivirali nalog, otvorite link ispod (vazi %d sati):\n%s\n",
+			u.FirstName, int(verifyTokenTTL.Hours()), link),
+	})
+}
+
+// verifyEmail prima token iz linka (query ?token= ili JSON body).
+func verifyEmail(v *Verifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		raw := c.Query("token")
+		if raw == "" {
+			var req types.VerifyEmailReq
+			_ = c.ShouldBindJSON(&req)
+			raw = strings.TrimSpace(req.Token)
+		}
+		if raw == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "token is required"})
+			return
+		}
+
+		id, email, err := parsePurposeToken(v.ks, v.issuer, verifyEmailPurpose, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Samo nalog koji ceka potvrdu; link ne sme ponovo aktivirati onemogucen ili obrisan nalog
+		res := v.db.WithContext(c.Request.Context()).Model(&types.User{}).
+			Where("id = ? AND email = ? AND status = ?", id, email, types.StatusPendingVerification).
+			Update("status", types.StatusActive)
+		if res.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify email"})
+			return
+		}
+		if res.RowsAffected == 0 {
+			// Ponovljen klik na link vec potvrdjenog naloga nije greska
+			var active int64
+			v.db.WithContext(c.Request.Context()).Model(&types.User{}).
+				Where("id = ? AND email = ? AND status = ?", id, email, types.StatusActive).Count(&active)
+			if active > 0 {
+				c.JSON(http.StatusOK, gin.H{"status": types.StatusActive})
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid verification token"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": types.StatusActive})
+	}
+}
+
+// resendVerification (javno) ne otkriva da li nalog postoji.
+func resendVerification(v *Verifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req types.ResendVerificationReq
+		if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Email) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "email is required"})
+			return
+		}
+
+		u, err := getUserByEmailAndPassword(v.db, strings.TrimSpace(strings.ToLower(req.Email)))
+		if err == nil && u.Status == types.StatusPendingVerification {
+			if err := v.Send(c.Request.Context(), u); err != nil {
+				log.Printf("[resendVerification] send err: %v", err)
+			}
+		}
+		c.JSON(http.StatusAccepted, gin.H{"message": "if the account is pending verification, a new link has been sent"})
+	}
+}
+
+// adminResendVerification (admin) ponovo salje link za dati nalog.
+func adminResendVerification(v *Verifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		u, ok := loadUserParam(c, v.db)
+		if !ok {
+			return
+		}
+		if u.Status != types.StatusPendingVerification {
+			c.JSON(http.StatusConflict, gin.H{"error": "account is already verified"})
+			return
+		}
+		if err := v.Send(c.Request.Context(), u); err != nil {
+			log.Printf("[adminResendVerification] send err: %v", err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "failed to send email"})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// forceVerify (admin) aktivira nalog bez linka; samo nalog koji ceka potvrdu
+// (onemogucen nalog se vraca preko /users/:id/enable).
+func forceVerify(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		u, ok := loadUserParam(c, db)
+		if !ok {
+			return
+		}
+		res := db.WithContext(c.Request.Context()).Model(&types.User{}).
+			Where("id = ? AND status = ?", u.ID, types.StatusPendingVerification).
+			Update("status", types.StatusActive)
+		if res.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify user"})
+			return
+		}
+		if res.RowsAffected == 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "account is not pending verification", "status": u.Status})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"id": u.ID, "status": types.StatusActive})
+	}
+}
+
+func loadUserParam(c *gin.Context, db *gorm.DB) (types.User, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return types.User{}, false
+	}
+	var u types.User
+	if err := db.WithContext(c.Request.Context()).First(&u, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return types.User{}, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load user"})
+		return types.User{}, false
+	}
+	return u, true
+}
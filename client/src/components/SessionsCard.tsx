@@ -0,0 +1,92 @@
+import { useCallback, useEffect, useState } from "react";
+import { listMySessions, revokeMySession, revokeMySessions, Session } from "../services/housing";
+import { useLogout } from "../state/login/useLogout";
+
+// Uredjaji na kojima je korisnik prijavljen; odjava jednog ili svih ostalih
+export default function SessionsCard() {
+  const [sessions, setSessions] = useState<Session[]>([]);
+  const [error, setError] = useState<string | null>(null);
+  const logout = useLogout();
+
+  const load = useCallback(async () => {
+    try {
+      setSessions(await listMySessions());
+      setError(null);
+    } catch {
+      setError("Failed to load sessions.");
+    }
+  }, []);
+
+  useEffect(() => { load(); }, [load]);
+
+  const onRevoke = async (s: Session) => {
+    try {
+      await revokeMySession(s.id);
+      if (s.current) {
+        logout();
+        return;
+      }
+      await load();
+    } catch {
+      setError("Failed to sign out the session.");
+    }
+  };
+
+  const onRevokeOthers = async () => {
+    try {
+      await revokeMySessions(true);
+      await load();
+    } catch {
+      setError("Failed to sign out other sessions.");
+    }
+  };
+
+  return (
+    <div className="mt-6 rounded-2xl border border-gray-200 bg-white p-5 shadow-sm">
+      <div className="flex items-center justify-between">
+        <div>
+          <h2 className="text-lg font-semibold text-gray-900">Sessions</h2>
+          <p className="mt-1 text-sm text-gray-500">Devices where you are signed in.</p>
+        </div>
+        {sessions.some((s) => !s.current) && (
+          <button
+            type="button"
+            onClick={onRevokeOthers}
+            className="rounded-xl border border-gray-300 px-3 py-2 text-sm font-medium text-gray-700 hover:bg-gray-50"
+          >
+            Sign out everywhere else
+          </button>
+        )}
+      </div>
+
+      {error && <p className="mt-3 text-sm text-red-600">{error}</p>}
+
+      <ul className="mt-4 divide-y divide-gray-100">
+        {sessions.map((s) => (
+          <li key={s.id} className="flex items-center justify-between py-3 text-sm">
+            <div>
+              <div className="font-medium text-gray-900">
+                {s.device}
+                {s.current && <span className="ml-2 text-xs text-emerald-600">This device</span>}
+              </div>
+              <div className="text-xs text-gray-500">
+                {s.lastIp || s.ip || "Unknown IP"} · last active {new Date(s.lastUsedAt).toLocaleString()} ·
+                signed in {new Date(s.createdAt).toLocaleDateString()}
+              </div>
+            </div>
+            <button
+              type="button"
+              onClick={() => onRevoke(s)}
+              className="text-xs font-medium text-red-600 hover:underline"
+            >
+              Sign out
+            </button>
+          </li>
+        ))}
+        {sessions.length === 0 && !error && (
+          <li className="py-3 text-sm text-gray-500">No active sessions.</li>
+        )}
+      </ul>
+    </div>
+  );
+}
@@ -2,10 +2,15 @@ import type { ApplicationStatus } from "../../models/housing";
 
 export default function StatusBadge({ s }: { s: ApplicationStatus }) {
   const map: Record<ApplicationStatus, string> = {
+    DRAFT:     "bg-gray-50 text-gray-700 ring-1 ring-inset ring-gray-200",
     SUBMITTED: "bg-blue-50 text-blue-700 ring-1 ring-inset ring-blue-200",
+    UNDER_REVIEW: "bg-indigo-50 text-indigo-700 ring-1 ring-inset ring-indigo-200",
     ACCEPTED:  "bg-emerald-50 text-emerald-700 ring-1 ring-inset ring-emerald-200",
+    WAITLISTED: "bg-violet-50 text-violet-700 ring-1 ring-inset ring-violet-200",
     REJECTED:  "bg-rose-50 text-rose-700 ring-1 ring-inset ring-rose-200",
     RESERVED:  "bg-amber-50 text-amber-700 ring-1 ring-inset ring-amber-200",
+    WITHDRAWN: "bg-gray-50 text-gray-500 ring-1 ring-inset ring-gray-200",
+    CANCELLED: "bg-gray-50 text-gray-500 ring-1 ring-inset ring-gray-200",
   };
   return (
     <span className={`px-2.5 py-1 rounded-full text-xs font-semibold ${map[s]}`}>
@@ -1,8 +1,36 @@
 export type ApplicationStatus =
+  | "DRAFT"
   | "SUBMITTED"
+  | "UNDER_REVIEW"
   | "ACCEPTED"
+  | "WAITLISTED"
   | "REJECTED"
-  | "RESERVED";
+  | "RESERVED"
+  | "WITHDRAWN"
+  | "CANCELLED";
+
+// Status prijave se menja samo tranzicijama (POST /applications/:id/transitions)
+export type ApplicationTransitionName =
+  | "submit"
+  | "review"
+  | "accept"
+  | "waitlist"
+  | "reject"
+  | "reserve"
+  | "withdraw"
+  | "cancel"
+  | "reopen";
+
+export type ApplicationTransition = {
+  id: string;
+  applicationId: string;
+  transition: ApplicationTransitionName | "create";
+  fromStatus: ApplicationStatus | "";
+  toStatus: ApplicationStatus;
+  actorId: number;
+  reason?: string;
+  createdAt: string;
+};
 
 export type Student = {
   id: number;
@@ -10,6 +38,7 @@ export type Student = {
   firstName: string;
   lastName: string;
   faculty: string;
+  yearOfStudy?: number;
   email: string;
 };
 
@@ -27,12 +56,144 @@ export type Room = {
   dormId: string;
 };
 
+export type ApplicationEvidence = {
+  gpa: number;
+  ectsEarned: number;
+  householdIncome: number;
+  householdMembers: number;
+  distanceKm: number;
+  socialCategories: string[];
+};
+
+export type ScoringCriterionKind =
+  | "GPA"
+  | "YEAR_OF_STUDY"
+  | "ECTS"
+  | "INCOME_PER_MEMBER"
+  | "DISTANCE"
+  | "SOCIAL_CATEGORY";
+
+export type ScoringCriterion = {
+  kind: ScoringCriterionKind;
+  weight: number;
+  min?: number;
+  max?: number;
+  invert?: boolean;
+  categories?: Record<string, number>;
+};
+
+export type ScoreBreakdown = {
+  items: {
+    kind: ScoringCriterionKind;
+    value: number;
+    normalized: number;
+    weight: number;
+    points: number;
+    note?: string;
+  }[];
+  total: number;
+  computedAt: string;
+};
+
 export type Application = {
   id: string;
+  // Racuna servis iz evidence i kriterijuma konkursa
   points: number;
   status: ApplicationStatus;
   studentId: number;
   roomId?: string | null;
+  competitionId?: string | null;
+  evidence?: ApplicationEvidence | null;
+  // Domovi konkursa po redu zelje; prazno = bilo koji
+  dormPreferences?: string[];
+  scoreBreakdown?: ScoreBreakdown | null;
+};
+
+export type CompetitionPhase = "UPCOMING" | "OPEN" | "CLOSED";
+
+export type Competition = {
+  id: string;
+  name: string;
+  academicYear: string;
+  opensAt: string;
+  closesAt: string;
+  faculties: string[];
+  yearsOfStudy: number[];
+  criteria: ScoringCriterion[];
+  facultyQuotas: Record<string, number>;
+  dorms: { dormId: string; quota: number }[];
+  phase: CompetitionPhase;
+};
+
+export type AllocationOutcome = "ACCEPTED" | "WAITLISTED" | "REJECTED";
+
+export type AllocationResult = {
+  applicationId: string;
+  rank: number;
+  studentId: number;
+  faculty: string;
+  points: number;
+  outcome: AllocationOutcome;
+  dormId?: string;
+  roomId?: string;
+  reason?: string;
+};
+
+export type AllocationRun = {
+  id: string;
+  competitionId: string;
+  dryRun: boolean;
+  accepted: number;
+  waitlisted: number;
+  rejected: number;
+  createdBy: number;
+  createdAt: string;
+  results?: AllocationResult[];
+};
+
+// Objavljene rang liste: privremena (sa rokom za zalbe) i konacna
+export type RankingKind = "PROVISIONAL" | "FINAL";
+
+export type RankingRow = {
+  rank: number;
+  index: string;
+  points: number;
+};
+
+export type RankingList = {
+  id: string;
+  competitionId: string;
+  version: number;
+  kind: RankingKind;
+  runId: string;
+  appealsCloseAt?: string;
+  publishedAt: string;
+  rows?: RankingRow[];
+};
+
+export type AppealStatus = "SUBMITTED" | "UNDER_REVIEW" | "ACCEPTED" | "REJECTED";
+
+export type AppealAttachment = {
+  id: string;
+  fileName: string;
+  contentType: string;
+  size: number;
+  createdAt: string;
+};
+
+export type Appeal = {
+  id: string;
+  listId: string;
+  competitionId: string;
+  applicationId: string;
+  studentId: number;
+  text: string;
+  status: AppealStatus;
+  response?: string;
+  reviewedBy?: number;
+  reviewedAt?: string;
+  createdAt: string;
+  attachments?: AppealAttachment[];
 };
 
 export type Payment = {
@@ -0,0 +1,21 @@
+// models/passwordPolicy.ts - kodovi koje auth vraca kada lozinka ne prolazi politiku
+export type PasswordViolation = {
+  code: string;
+  message: string;
+};
+
+const messages: Record<string, string> = {
+  PASSWORD_TOO_SHORT: "Password is too short.",
+  PASSWORD_TOO_LONG: "Password is too long (at most 72 bytes).",
+  PASSWORD_CONTAINS_EMAIL: "Password must not contain your email address.",
+  PASSWORD_CONTAINS_NAME: "Password must not contain your first or last name.",
+  PASSWORD_BREACHED: "This password is too common or has appeared in a data breach. Choose another one.",
+};
+
+// passwordPolicyMessage vraca poruku za korisnika ili null ako greska nije od politike lozinki
+export function passwordPolicyMessage(data: any): string | null {
+  if (data?.code !== "PASSWORD_POLICY" || !Array.isArray(data?.violations)) return null;
+  return (data.violations as PasswordViolation[])
+    .map((v) => messages[v.code] ?? v.message)
+    .join(" ");
+}
@@ -11,7 +11,7 @@ import {
   deleteRoom,
   listApplications,
   createApplication,
-  updateApplication,
+  transitionApplication,
   deleteApplication,
   listPayments,
   createPayment,
@@ -19,6 +19,7 @@ import {
 } from "../services/housing";
 import type {
   ApplicationStatus,
+  ApplicationTransitionName,
   Dorm,
   Room,
   Student,
@@ -120,7 +121,7 @@ const DangerBtn = ({
 );
 
 const StatusBadge = ({ s }: { s: ApplicationStatus }) => {
-  const map: Record<ApplicationStatus, string> = {
+  const map: Partial<Record<ApplicationStatus, string>> = {
     SUBMITTED: "bg-blue-50 text-blue-700 ring-1 ring-inset ring-blue-200",
     ACCEPTED:
       "bg-emerald-50 text-emerald-700 ring-1 ring-inset ring-emerald-200",
@@ -587,7 +588,6 @@ function ApplicationsTab() {
     await createApplication({
       studentId: String(fd.get("studentId") || ""),
       roomId: (String(fd.get("roomId") || "") || undefined) as any,
-      points: Number(fd.get("points") || 0),
       status: String(fd.get("status") || "SUBMITTED") as ApplicationStatus,
     } as any);
     e.currentTarget.reset();
@@ -595,8 +595,33 @@ function ApplicationsTab() {
     load();
   }
 
-  async function onUpdate(a: Application, newStatus: ApplicationStatus) {
-    await updateApplication(a.id, { status: newStatus });
+  // Tranzicije koje staff radi, po statusu prijave (isto kao na serveru)
+  const staffTransitions: Partial<Record<ApplicationStatus, ApplicationTransitionName[]>> = {
+    DRAFT: ["submit"],
+    SUBMITTED: ["review", "reject"],
+    UNDER_REVIEW: ["accept", "waitlist", "reject"],
+    WAITLISTED: ["accept", "reject"],
+    ACCEPTED: ["reserve", "cancel"],
+    RESERVED: ["cancel"],
+  };
+
+  async function onTransition(a: Application, t: ApplicationTransitionName) {
+    const extra: { reason?: string; roomId?: string } = {};
+    if (t === "reject" || t === "cancel") {
+      const reason = window.prompt("Razlog");
+      if (!reason) return;
+      extra.reason = reason;
+    }
+    if (t === "reserve") {
+      const roomId = window.prompt("ID sobe");
+      if (!roomId) return;
+      extra.roomId = roomId;
+    }
+    try {
+      await transitionApplication(a.id, t, extra);
+    } catch (err: any) {
+      alert(err?.response?.data?.error ?? "Tranzicija nije uspela");
+    }
     load();
   }
 
@@ -698,24 +723,15 @@ function ApplicationsTab() {
 
                   <td className="px-3 py-2 text-right">
                     <div className="flex gap-2 justify-end flex-wrap">
-                      {(
-                        [
-                          "SUBMITTED",
-                          "ACCEPTED",
-                          "REJECTED",
-                          "RESERVED",
-                        ] as ApplicationStatus[]
-                      )
-                        .filter((s) => s !== a.status)
-                        .map((s) => (
-                          <button
-                            key={s}
-                            className="rounded-xl border px-3 py-1.5 text-xs font-medium hover:bg-gray-50"
-                            onClick={() => onUpdate(a, s)}
-                          >
-                            Set {s}
-                          </button>
-                        ))}
+                      {(staffTransitions[a.status] ?? []).map((t) => (
+                        <button
+                          key={t}
+                          className="rounded-xl border px-3 py-1.5 text-xs font-medium hover:bg-gray-50"
+                          onClick={() => onTransition(a, t)}
+                        >
+                          {t}
+                        </button>
+                      ))}
                       <DangerBtn
                         onClick={() => deleteApplication(a.id).then(load)}
                       >
@@ -809,13 +825,6 @@ function ApplicationsTab() {
             </div>
           </div>
 
-          <div className="grid md:grid-cols-2 gap-3">
-            <div className="grid gap-1">
-              <Label>Points</Label>
-              <Input type="number" min={0} name="points" />
-            </div>
-          </div>
-
           <PrimaryBtn type="submit">Create</PrimaryBtn>
         </form>
       </Card>
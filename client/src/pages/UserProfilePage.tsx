@@ -5,6 +5,8 @@ import Footer from "../components/footer";
 import { CameraIcon } from "@heroicons/react/24/outline";
 import { useLogin } from "../state/login/useLogin";
 import { updateStudent, changeStudentPassword } from "../services/housing";
+import { passwordPolicyMessage } from "../models/passwordPolicy";
+import SessionsCard from "../components/SessionsCard";
 
 type UserProfile = {
   firstName: string;
@@ -102,8 +104,7 @@ const UserProfilePage: React.FC = () => {
     firstName: profile.firstName ?? "",
     lastName: profile.lastName ?? "",
     username: profile.username ?? "",
-    faculty: profile.faculty ?? "",
-    index: profile.index ?? "",
+    // fakultet i indeks su podaci o upisu; menja ih samo studentska sluzba
   });
 
   const saveProfile = async (e: React.FormEvent) => {
@@ -180,6 +181,7 @@ const UserProfilePage: React.FC = () => {
       setConfirmPass("");
     } catch (err: any) {
       const txt =
+        passwordPolicyMessage(err?.response?.data) ||
         err?.response?.data?.error ||
         err?.response?.data?.message ||
         err?.message ||
@@ -294,9 +296,8 @@ const UserProfilePage: React.FC = () => {
                   </label>
                   <input
                     value={profile.faculty || ""}
-                    onChange={onField("faculty")}
-                    placeholder="e.g., ETF"
-                    className="w-full rounded-xl border border-gray-300 px-3 py-2 text-sm focus:outline-none focus:ring-2 focus:ring-emerald-500 focus:border-emerald-500"
+                    readOnly
+                    className="w-full rounded-xl border border-gray-200 bg-gray-50 px-3 py-2 text-sm text-gray-700"
                   />
                 </div>
                 <div>
@@ -305,9 +306,8 @@ const UserProfilePage: React.FC = () => {
                   </label>
                   <input
                     value={profile.index || ""}
-                    onChange={onField("index")}
-                    placeholder="e.g., 2021/0001"
-                    className="w-full rounded-xl border border-gray-300 px-3 py-2 text-sm focus:outline-none focus:ring-2 focus:ring-emerald-500 focus:border-emerald-500"
+                    readOnly
+                    className="w-full rounded-xl border border-gray-200 bg-gray-50 px-3 py-2 text-sm text-gray-700"
                   />
                 </div>
                 <div>
@@ -400,6 +400,8 @@ const UserProfilePage: React.FC = () => {
                 </button>
               </div>
             </form>
+
+            <SessionsCard />
           </section>
         </div>
       </main>
@@ -1,6 +1,6 @@
 import { useEffect, useState } from "react";
 import Card from "../../components/ui/Card";
-import { Input, Label, Select } from "../../components/ui/Form";
+import { Label, Select } from "../../components/ui/Form";
 import { PrimaryBtn, DangerBtn } from "../../components/ui/Buttons";
 import StatusBadge from "../../components/ui/StatusBadge";
 import {
@@ -8,19 +8,31 @@ import {
   listDorms,
   listRooms,
   listApplications,
+  listCompetitions,
   createApplication,
-  updateApplication,
+  transitionApplication,
   deleteApplication,
 } from "../../services/housing";
-import type { ApplicationStatus, Dorm, Room, Student, Application } from "../../models/housing";
+import type { ApplicationStatus, Competition, ApplicationTransitionName, Dorm, Room, Student, Application } from "../../models/housing";
 
 export default function ApplicationsPage() {
   const [rows, setRows] = useState<Application[]>([]);
   const [filters, setFilters] = useState<{ studentId?: string; dormId?: string; status?: ApplicationStatus; }>({});
-  const statusOptions: ApplicationStatus[] = ["SUBMITTED", "ACCEPTED", "REJECTED", "RESERVED"];
+  const statusOptions: ApplicationStatus[] = ["DRAFT", "SUBMITTED", "UNDER_REVIEW", "ACCEPTED", "WAITLISTED", "REJECTED", "RESERVED", "WITHDRAWN", "CANCELLED"];
+  // Tranzicije koje staff radi, po statusu prijave (isto kao na serveru)
+  const staffTransitions: Partial<Record<ApplicationStatus, ApplicationTransitionName[]>> = {
+    DRAFT: ["submit"],
+    SUBMITTED: ["review", "reject"],
+    UNDER_REVIEW: ["accept", "waitlist", "reject"],
+    WAITLISTED: ["accept", "reopen", "reject"],
+    REJECTED: ["reopen"],
+    ACCEPTED: ["reserve", "cancel"],
+    RESERVED: ["cancel"],
+  };
 
   const [students, setStudents] = useState<Student[]>([]);
   const [dorms, setDorms] = useState<Dorm[]>([]);
+  const [competitions, setCompetitions] = useState<Competition[]>([]);
   const [rooms, setRooms] = useState<Room[]>([]);
 
   useEffect(() => {
@@ -29,6 +41,8 @@ export default function ApplicationsPage() {
       setStudents(s.rows);
       const d = await listDorms(1, 1000);
       setDorms(d.rows);
+      const comp = await listCompetitions({ phase: "OPEN", page: 1, pageSize: 100 });
+      setCompetitions(comp.rows);
     })();
   }, []);
 
@@ -54,9 +68,9 @@ export default function ApplicationsPage() {
     e.preventDefault();
     const fd = new FormData(e.currentTarget);
     await createApplication({
+      competitionId: String(fd.get("competitionId") || ""),
       studentId: Number(fd.get("studentId") || 0),
       roomId: (String(fd.get("roomId") || "") || undefined) as any,
-      points: Number(fd.get("points") || 0),
       status: String(fd.get("status") || "SUBMITTED") as ApplicationStatus,
       createdAt: "" as any,
     } as any);
@@ -65,8 +79,23 @@ export default function ApplicationsPage() {
     load();
   }
 
-  async function onUpdate(a: Application, newStatus: ApplicationStatus) {
-    await updateApplication(a.id, { status: newStatus });
+  async function onTransition(a: Application, t: ApplicationTransitionName) {
+    const extra: { reason?: string; roomId?: string } = {};
+    if (t === "reject" || t === "cancel" || t === "reopen") {
+      const reason = window.prompt("Razlog");
+      if (!reason) return;
+      extra.reason = reason;
+    }
+    if (t === "reserve") {
+      const roomId = window.prompt("ID sobe");
+      if (!roomId) return;
+      extra.roomId = roomId;
+    }
+    try {
+      await transitionApplication(a.id, t, extra);
+    } catch (err: any) {
+      alert(err?.response?.data?.error ?? "Tranzicija nije uspela");
+    }
     load();
   }
 
@@ -138,17 +167,15 @@ export default function ApplicationsPage() {
                       <td className="px-3 py-2">{/* createdAt render ako ga dobijaš */}</td>
                       <td className="px-3 py-2 text-right">
                         <div className="flex gap-2 justify-end flex-wrap">
-                          {(["SUBMITTED", "ACCEPTED", "REJECTED", "RESERVED"] as ApplicationStatus[])
-                            .filter((s) => s !== a.status)
-                            .map((s) => (
-                              <button
-                                key={s}
-                                className="rounded-xl border px-3 py-1.5 text-xs font-medium hover:bg-gray-50"
-                                onClick={() => onUpdate(a, s)}
-                              >
-                                Set {s}
-                              </button>
-                            ))}
+                          {(staffTransitions[a.status] ?? []).map((t) => (
+                            <button
+                              key={t}
+                              className="rounded-xl border px-3 py-1.5 text-xs font-medium hover:bg-gray-50"
+                              onClick={() => onTransition(a, t)}
+                            >
+                              {t}
+                            </button>
+                          ))}
                           <DangerBtn onClick={() => deleteApplication(a.id).then(load)}>Delete</DangerBtn>
                         </div>
                       </td>
@@ -165,6 +192,15 @@ export default function ApplicationsPage() {
 
         <Card title="Create application">
           <form onSubmit={onCreate} className="grid gap-3 max-w-2xl">
+            <div className="grid gap-1">
+              <Label>Competition</Label>
+              <select name="competitionId" required className="w-full rounded-xl border border-gray-300 px-3 py-2 text-sm focus:outline-none focus:ring-2 focus:ring-indigo-500" defaultValue="">
+                <option value="" disabled>Select open competition…</option>
+                {competitions.map((c) => (
+                  <option key={c.id} value={c.id}>{c.name} ({c.academicYear}, do {new Date(c.closesAt).toLocaleDateString()})</option>
+                ))}
+              </select>
+            </div>
             <div className="grid md:grid-cols-2 gap-3">
               <div className="grid gap-1">
                 <Label>Student</Label>
@@ -207,18 +243,11 @@ export default function ApplicationsPage() {
               <div className="grid gap-1">
                 <Label>Status</Label>
                 <Select name="status" defaultValue="SUBMITTED">
-                  {statusOptions.map((s) => <option key={s} value={s}>{s}</option>)}
+                  {(["DRAFT", "SUBMITTED"] as ApplicationStatus[]).map((s) => <option key={s} value={s}>{s}</option>)}
                 </Select>
               </div>
             </div>
 
-            <div className="grid md:grid-cols-2 gap-3">
-              <div className="grid gap-1">
-                <Label>Points</Label>
-                <Input type="number" min={0} name="points" />
-              </div>
-            </div>
-
             <PrimaryBtn type="submit">Create</PrimaryBtn>
           </form>
         </Card>
@@ -6,7 +6,7 @@ import { jwtDecode } from "jwt-decode";
 import Card from "../../components/ui/Card";
 import { Input } from "../../components/ui/Form";
 import { PrimaryBtn } from "../../components/ui/Buttons";
-import { listStudents, listUsers, updateUserRole } from "../../services/housing";
+import { listStudents, listUsers, updateUserRole, startImpersonation } from "../../services/housing";
 
 type AnyJwt = Record<string, any>;
 
@@ -140,6 +140,18 @@ export default function UsersRolesPage() {
     }
   };
 
+  const onViewAs = async (u: User) => {
+    const reason = window.prompt(`Reason for viewing the app as ${u.email}:`);
+    if (!reason || reason.trim().length < 5) return;
+    try {
+      await startImpersonation(u.id, reason.trim());
+      // ceo reload, da bi sve komponente procitale novi token
+      window.location.assign("/student/applications");
+    } catch (e: any) {
+      setError((s) => ({ ...s, [u.id]: e?.response?.data?.error || e?.message || "Failed to start impersonation" }));
+    }
+  };
+
   if (!isAdmin) {
     return (
       <div className="p-6">
@@ -202,6 +214,7 @@ export default function UsersRolesPage() {
                   <th className="px-3 py-2 text-left">Faculty</th>
                   <th className="px-3 py-2 text-left">Role</th>
                   <th className="px-3 py-2 text-right">Status</th>
+                  <th className="px-3 py-2 text-right"></th>
                 </tr>
               </thead>
               <tbody className="divide-y divide-gray-100">
@@ -238,12 +251,22 @@ export default function UsersRolesPage() {
                           <span className="text-xs text-emerald-600">✔ Saved</span>
                         )}
                       </td>
+                      <td className="px-3 py-2 text-right">
+                        {u.role === "STUDENT" && (
+                          <button
+                            className="text-xs text-indigo-600 hover:underline"
+                            onClick={() => onViewAs(u)}
+                          >
+                            View as
+                          </button>
+                        )}
+                      </td>
                     </tr>
                   );
                 })}
                 {rows.length === 0 && (
                   <tr>
-                    <td className="px-3 py-6 text-center text-gray-500" colSpan={6}>No results</td>
+                    <td className="px-3 py-6 text-center text-gray-500" colSpan={7}>No results</td>
                   </tr>
                 )}
               </tbody>
@@ -9,15 +9,24 @@ import {
   listRooms,
   listApplications,
   createApplication,
-  updateApplication,
+  transitionApplication,
   deleteApplication,
 } from "../../services/housing";
-import type { ApplicationStatus, Dorm, Room, Student, Application } from "../../models/housing";
+import type { ApplicationStatus, ApplicationTransitionName, Dorm, Room, Student, Application } from "../../models/housing";
 
 export default function ApplicationsPageStaff() {
   const [rows, setRows] = useState<Application[]>([]);
   const [filters, setFilters] = useState<{ studentId?: string; dormId?: string; status?: ApplicationStatus; }>({});
-  const statusOptions: ApplicationStatus[] = ["SUBMITTED", "ACCEPTED", "REJECTED", "RESERVED"];
+  const statusOptions: ApplicationStatus[] = ["DRAFT", "SUBMITTED", "UNDER_REVIEW", "ACCEPTED", "WAITLISTED", "REJECTED", "RESERVED", "WITHDRAWN", "CANCELLED"];
+  // Tranzicije koje staff radi, po statusu prijave (isto kao na serveru)
+  const staffTransitions: Partial<Record<ApplicationStatus, ApplicationTransitionName[]>> = {
+    DRAFT: ["submit"],
+    SUBMITTED: ["review", "reject"],
+    UNDER_REVIEW: ["accept", "waitlist", "reject"],
+    WAITLISTED: ["accept", "reject"],
+    ACCEPTED: ["reserve", "cancel"],
+    RESERVED: ["cancel"],
+  };
 
   const [students, setStudents] = useState<Student[]>([]);
   const [dorms, setDorms] = useState<Dorm[]>([]);
@@ -56,7 +65,6 @@ export default function ApplicationsPageStaff() {
     await createApplication({
       studentId: Number(fd.get("studentId") || 0),
       roomId: (String(fd.get("roomId") || "") || undefined) as any,
-      points: Number(fd.get("points") || 0),
       status: String(fd.get("status") || "SUBMITTED") as ApplicationStatus,
       createdAt: "" as any,
     } as any);
@@ -65,8 +73,23 @@ export default function ApplicationsPageStaff() {
     load();
   }
 
-  async function onUpdate(a: Application, newStatus: ApplicationStatus) {
-    await updateApplication(a.id, { status: newStatus });
+  async function onTransition(a: Application, t: ApplicationTransitionName) {
+    const extra: { reason?: string; roomId?: string } = {};
+    if (t === "reject" || t === "cancel") {
+      const reason = window.prompt("Razlog");
+      if (!reason) return;
+      extra.reason = reason;
+    }
+    if (t === "reserve") {
+      const roomId = window.prompt("ID sobe");
+      if (!roomId) return;
+      extra.roomId = roomId;
+    }
+    try {
+      await transitionApplication(a.id, t, extra);
+    } catch (err: any) {
+      alert(err?.response?.data?.error ?? "Tranzicija nije uspela");
+    }
     load();
   }
 
@@ -138,17 +161,15 @@ export default function ApplicationsPageStaff() {
                       <td className="px-3 py-2">{/* createdAt render ako ga dobijaš */}</td>
                       <td className="px-3 py-2 text-right">
                         <div className="flex gap-2 justify-end flex-wrap">
-                          {(["SUBMITTED", "ACCEPTED", "REJECTED", "RESERVED"] as ApplicationStatus[])
-                            .filter((s) => s !== a.status)
-                            .map((s) => (
-                              <button
-                                key={s}
-                                className="rounded-xl border px-3 py-1.5 text-xs font-medium hover:bg-gray-50"
-                                onClick={() => onUpdate(a, s)}
-                              >
-                                Set {s}
-                              </button>
-                            ))}
+                          {(staffTransitions[a.status] ?? []).map((t) => (
+                            <button
+                              key={t}
+                              className="rounded-xl border px-3 py-1.5 text-xs font-medium hover:bg-gray-50"
+                              onClick={() => onTransition(a, t)}
+                            >
+                              {t}
+                            </button>
+                          ))}
                           <DangerBtn onClick={() => deleteApplication(a.id).then(load)}>Delete</DangerBtn>
                         </div>
                       </td>
@@ -1,6 +1,7 @@
 import { NavLink, Outlet } from "react-router-dom";
 import Header from "../../components/header";
 import Footer from "../../components/footer";
+import { isImpersonating, stopImpersonation } from "../../services/housing";
 
 const NavItem = ({ to, label, icon }: { to: string; label: string; icon?: React.ReactNode }) => (
     <NavLink
@@ -20,6 +21,23 @@ export default function StudentLayout() {
         <div className="min-h-screen bg-gray-50">
             <Header />
 
+            {isImpersonating() && (
+                <div className="bg-amber-100 border-b border-amber-300 text-amber-900 text-sm">
+                    <div className="mx-auto max-w-7xl px-4 py-2 flex items-center justify-between">
+                        <span>You are viewing the app as this student (read-only).</span>
+                        <button
+                            className="font-medium underline"
+                            onClick={async () => {
+                                await stopImpersonation();
+                                window.location.assign("/admin/students");
+                            }}
+                        >
+                            Stop
+                        </button>
+                    </div>
+                </div>
+            )}
+
             {/* Layout */}
             <div className="mx-auto max-w-7xl px-4 py-6 grid grid-cols-12 gap-6">
                 {/* Sidebar */}
@@ -12,11 +12,13 @@ import {
   listDorms,
   listRooms,
   listApplications,
+  listCompetitions,
   createApplication,
   deleteApplication,
+  transitionApplication,
 } from "../../services/housing";
 
-import type { ApplicationStatus, Dorm, Room, Application } from "../../models/housing";
+import type { ApplicationStatus, Competition, Dorm, Room, Application } from "../../models/housing";
 
 type AnyJwt = Record<string, any>;
 
@@ -45,6 +47,7 @@ export default function ApplicationsPageStudent() {
   const statusOptions: ApplicationStatus[] = ["SUBMITTED", "ACCEPTED", "REJECTED", "RESERVED"];
 
   const [dorms, setDorms] = useState<Dorm[]>([]);
+  const [competitions, setCompetitions] = useState<Competition[]>([]);
   const [rooms, setRooms] = useState<Room[]>([]);
   const [createDormId, setCreateDormId] = useState("");
 
@@ -57,6 +60,8 @@ export default function ApplicationsPageStudent() {
     (async () => {
       const d = await listDorms(1, 1000);
       setDorms(d.rows);
+      const comp = await listCompetitions({ phase: "OPEN", page: 1, pageSize: 100 });
+      setCompetitions(comp.rows);
     })();
   }, []);
 
@@ -115,10 +120,19 @@ export default function ApplicationsPageStudent() {
     const roomId = roomIdRaw ? roomIdRaw : null;
 
     await createApplication({
+      competitionId: String(fd.get("competitionId") || ""),
       studentId: Number(myStudentId),        // ✅ string/UUID iz tokena (NE Number(...))
       roomId,                        // ✅ null ili UUID string
-      points: Number(fd.get("points") || 0),
       status: "SUBMITTED",
+      // bodove racuna servis iz ovih podataka
+      evidence: {
+        gpa: Number(fd.get("gpa") || 0),
+        ectsEarned: Number(fd.get("ectsEarned") || 0),
+        householdIncome: Number(fd.get("householdIncome") || 0),
+        householdMembers: Number(fd.get("householdMembers") || 0),
+        distanceKm: Number(fd.get("distanceKm") || 0),
+        socialCategories: [],
+      },
     } as any);
 
     e.currentTarget.reset();
@@ -222,6 +236,14 @@ export default function ApplicationsPageStudent() {
                     </td> */}
                     <td className="px-3 py-2 text-right">
                       <div className="flex gap-2 justify-end flex-wrap">
+                        {(["DRAFT", "SUBMITTED", "UNDER_REVIEW", "WAITLISTED", "ACCEPTED", "RESERVED"] as ApplicationStatus[]).includes(a.status) && (
+                          <button
+                            className="rounded-xl border px-3 py-1.5 text-xs font-medium hover:bg-gray-50"
+                            onClick={() => transitionApplication(a.id, "withdraw").then(load)}
+                          >
+                            Withdraw
+                          </button>
+                        )}
                         <DangerBtn onClick={() => deleteApplication(a.id).then(load)}>Delete</DangerBtn>
                       </div>
                     </td>
@@ -237,6 +259,15 @@ export default function ApplicationsPageStudent() {
 
         <Card title="Create application" >
           <form onSubmit={onCreate} className="grid gap-3 max-w-2xl">
+            <div className="grid gap-1">
+              <Label>Competition</Label>
+              <select name="competitionId" required className="w-full rounded-xl border border-gray-300 px-3 py-2 text-sm focus:outline-none focus:ring-2 focus:ring-indigo-500" defaultValue="">
+                <option value="" disabled>Select open competition…</option>
+                {competitions.map((c) => (
+                  <option key={c.id} value={c.id}>{c.name} ({c.academicYear}, do {new Date(c.closesAt).toLocaleDateString()})</option>
+                ))}
+              </select>
+            </div>
             <div className="grid md:grid-cols-2 gap-3">
               <div className="grid gap-1">
                 <Label>Dorm (filter rooms)</Label>
@@ -269,8 +300,24 @@ export default function ApplicationsPageStudent() {
 
             <div className="grid md:grid-cols-2 gap-3">
               <div className="grid gap-1">
-                <Label>Points</Label>
-                <Input type="number" min={0} name="points" />
+                <Label>GPA</Label>
+                <Input type="number" min={6} max={10} step="0.01" name="gpa" />
+              </div>
+              <div className="grid gap-1">
+                <Label>ECTS earned</Label>
+                <Input type="number" min={0} name="ectsEarned" />
+              </div>
+              <div className="grid gap-1">
+                <Label>Household income (monthly, RSD)</Label>
+                <Input type="number" min={0} name="householdIncome" />
+              </div>
+              <div className="grid gap-1">
+                <Label>Household members</Label>
+                <Input type="number" min={1} name="householdMembers" />
+              </div>
+              <div className="grid gap-1">
+                <Label>Distance from home (km)</Label>
+                <Input type="number" min={0} name="distanceKm" />
               </div>
 
               <div className="grid gap-1">
@@ -1,4 +1,6 @@
 import { HttpService } from "./axios";
+import Cookies from "js-cookie";
+import type { LoginResponse } from "../models/login";
 const api = HttpService.getInstance();
 
 import type {
@@ -6,9 +8,19 @@ import type {
   Dorm,
   Room,
   Application,
+  ApplicationEvidence,
   Payment,
   Pagination,
   ApplicationStatus,
+  ApplicationTransition,
+  ApplicationTransitionName,
+  Competition,
+  CompetitionPhase,
+  AllocationRun,
+  RankingKind,
+  RankingList,
+  Appeal,
+  AppealStatus,
 } from "../models/housing";
 import { User, UserRole } from "../pages/admin/StudentsPage";
 
@@ -28,22 +40,84 @@ export async function listStudents(q = "", page = 1, pageSize = 10) {
 }
 
 
+// Nalozi i uloge su u auth servisu (admin API)
 export async function listUsers(q: string, page = 1, pageSize = 50): Promise<{ rows: User[] }> {
-  const qs = new URLSearchParams({ q, page: String(page), pageSize: String(pageSize) }).toString();
-  const res = await fetch(`http://localhost:8000/api/student-housing/api/users`);
-  if (!res.ok) throw new Error("Failed to list users");
-  return res.json();
+  const data = await api.get<{ items: User[] }>("/auth/users", { q, page, pageSize });
+  return { rows: data.items ?? [] };
 }
 
 export async function updateUserRole(userId: string, role: UserRole): Promise<User> {
-  const res = await fetch(`http://localhost:8000/api/student-housing/api/users/${userId}/role`, {
-    method: "PATCH",
-    headers: { "Content-Type": "application/json" },
-    body: JSON.stringify({ role }),
+  return api.put<User, { role: UserRole }>(`/auth/users/${userId}/role`, { role });
+}
+
+// SESSIONS - uredjaji na kojima je korisnik prijavljen (auth servis)
+export type Session = {
+  id: string;
+  device: string;
+  userAgent: string;
+  ip: string;
+  lastIp: string;
+  createdAt: string;
+  lastUsedAt: string;
+  current: boolean;
+};
+
+export async function listMySessions() {
+  const data = await api.get<{ items: Session[] }>("/auth/me/sessions");
+  return data.items ?? [];
+}
+
+export async function revokeMySession(id: string) {
+  await api.delete(`/auth/me/sessions/${id}`);
+}
+
+// keepCurrent=true odjavljuje sve ostale uredjaje, a ovaj ostaje prijavljen
+export async function revokeMySessions(keepCurrent: boolean) {
+  await api.delete("/auth/me/sessions", undefined, { keepCurrent });
+}
+
+// IMPERSONATION - admin gleda aplikaciju kao student (samo citanje). Adminov token
+// se cuva sa strane i vraca kad se impersonacija zavrsi.
+type ImpersonationResponse = LoginResponse & { user: { id: number; email: string } };
+
+export async function startImpersonation(userId: string, reason: string) {
+  const data = await api.post<ImpersonationResponse, { reason: string }>(
+    `/auth/impersonate/${userId}`,
+    { reason }
+  );
+  const adminToken = Cookies.get("auth.token");
+  if (adminToken) {
+    Cookies.set("auth.adminToken", adminToken, { sameSite: "lax", secure: window.location.protocol === "https:", path: "/" });
+  }
+  Cookies.set("auth.token", data.access_token, {
+    sameSite: "lax",
+    secure: window.location.protocol === "https:",
+    expires: new Date(Date.now() + data.expires_in * 1000),
+    path: "/",
   });
-  if (!res.ok) throw new Error("Failed to update role");
-  return res.json();
+  return data.user;
+}
+
+export function isImpersonating() {
+  return !!Cookies.get("auth.adminToken");
+}
+
+export async function stopImpersonation() {
+  try {
+    // logout opoziva token za impersonaciju i belezi kraj u dnevniku
+    await api.post("/auth/logout", {});
+  } catch {
+    // token je mozda vec istekao; admin se svejedno vraca na svoj nalog
+  }
+  const adminToken = Cookies.get("auth.adminToken");
+  Cookies.remove("auth.adminToken", { path: "/" });
+  if (adminToken) {
+    Cookies.set("auth.token", adminToken, { sameSite: "lax", secure: window.location.protocol === "https:", path: "/" });
+  } else {
+    Cookies.remove("auth.token");
+  }
 }
+
 // CREATE
 export async function createStudent(payload: Omit<Student, "id">) {
   const data = await api.post<Student, Omit<Student, "id">>(
@@ -73,16 +147,23 @@ export async function updateStudent(id: string, payload: Partial<Student>) {
   return data;
 }
 
-// 🔽🔽 DODATO: CHANGE PASSWORD (PATCH)
+// CHANGE PASSWORD - lozinkom upravlja auth servis; stare sesije se gase,
+// pa cuvamo novi access token koji auth vrati
 export async function changeStudentPassword(
-  id: string,
+  _id: string,
   oldPassword: string,
   newPassword: string
 ) {
-  await api.patch<void, { oldPassword: string; newPassword: string }>(
-    `/student-housing/api/students/${id}`,
+  const data = await api.post<LoginResponse, { oldPassword: string; newPassword: string }>(
+    "/auth/password/change",
     { oldPassword, newPassword }
   );
+  Cookies.set("auth.token", data.access_token, {
+    sameSite: "lax",
+    secure: window.location.protocol === "https:",
+    expires: data.expires_in ? new Date(Date.now() + data.expires_in * 1000) : undefined,
+    path: "/",
+  });
 }
 
 // ------- Dorms -------
@@ -127,6 +208,90 @@ export async function deleteRoom(id: string) {
   await api.delete(`/student-housing/api/rooms/${id}`);
 }
 
+// ------- Competitions -------
+type CompetitionInput = Omit<Competition, "id" | "phase">;
+
+export async function listCompetitions(
+  params: { academicYear?: string; phase?: CompetitionPhase; page?: number; pageSize?: number } = {}
+) {
+  const data = await api.get<Pagination<Competition>>(
+    "/student-housing/api/competitions",
+    params
+  );
+  return { rows: data.items ?? [], pagination: data.pagination };
+}
+export async function createCompetition(payload: CompetitionInput) {
+  return api.post<Competition, CompetitionInput>("/student-housing/api/competitions", payload);
+}
+export async function updateCompetition(id: string, payload: CompetitionInput) {
+  return api.put<Competition, CompetitionInput>(`/student-housing/api/competitions/${id}`, payload);
+}
+export async function deleteCompetition(id: string) {
+  await api.delete(`/student-housing/api/competitions/${id}`);
+}
+export async function extendCompetition(id: string, closesAt: string) {
+  return api.post<Competition, { closesAt: string }>(
+    `/student-housing/api/competitions/${id}/extend`,
+    { closesAt }
+  );
+}
+// Rangiranje i raspodela; sa dryRun se vraca predlog bez izmena
+export async function allocateCompetition(id: string, dryRun = false) {
+  return api.post<AllocationRun, undefined>(
+    `/student-housing/api/competitions/${id}/allocate`,
+    undefined,
+    dryRun ? { dryRun: "true" } : undefined
+  );
+}
+export async function listAllocationRuns(id: string, page = 1, pageSize = 20) {
+  const data = await api.get<Pagination<AllocationRun>>(
+    `/student-housing/api/competitions/${id}/allocations`,
+    { page, pageSize }
+  );
+  return { rows: data.items ?? [], pagination: data.pagination };
+}
+export async function getAllocationRun(id: string, runId: string) {
+  return api.get<AllocationRun>(`/student-housing/api/competitions/${id}/allocations/${runId}`);
+}
+
+// ------- Ranking lists & appeals -------
+export async function listRankingLists(id: string, kind?: RankingKind, page = 1, pageSize = 20) {
+  const data = await api.get<Pagination<RankingList>>(
+    `/student-housing/api/competitions/${id}/rankings`,
+    { kind, page, pageSize }
+  );
+  return { rows: data.items ?? [], pagination: data.pagination };
+}
+// version je broj ili "latest"
+export async function getRankingList(id: string, version: number | "latest" = "latest", kind?: RankingKind) {
+  return api.get<RankingList>(`/student-housing/api/competitions/${id}/rankings/${version}`, { kind });
+}
+export async function publishRankingList(
+  id: string,
+  payload: { kind: RankingKind; runId?: string; appealsCloseAt?: string }
+) {
+  return api.post<RankingList, typeof payload>(`/student-housing/api/competitions/${id}/rankings`, payload);
+}
+export async function submitAppeal(id: string, text: string, files: File[] = []) {
+  const fd = new FormData();
+  fd.append("text", text);
+  files.forEach((f) => fd.append("files", f));
+  return api.post<Appeal, FormData>(`/student-housing/api/competitions/${id}/appeals`, fd, undefined, true);
+}
+export async function listAppeals(id: string, status?: AppealStatus, page = 1, pageSize = 20) {
+  const data = await api.get<Pagination<Appeal>>(
+    `/student-housing/api/competitions/${id}/appeals`,
+    { status, page, pageSize }
+  );
+  return { rows: data.items ?? [], pagination: data.pagination };
+}
+export async function reviewAppeal(id: string, decision: AppealStatus, response?: string) {
+  return api.post<Appeal, { decision: AppealStatus; response?: string }>(
+    `/student-housing/api/appeals/${id}/review`,
+    { decision, response }
+  );
+}
+
 // ------- Applications -------
 export async function listApplications(
   params: {
@@ -150,14 +315,31 @@ export async function createApplication(payload: Omit<Application, "id">) {
   );
   return data;
 }
-export async function updateApplication(
+type ApplicationUpdate = { evidence?: ApplicationEvidence; dormPreferences?: string[] };
+
+// Menja podatke za bodovanje i zeljene domove; bodove preracunava servis, a status i soba idu kroz transitionApplication
+export async function updateApplication(id: string, payload: ApplicationUpdate) {
+  const data = await api.put<Application, ApplicationUpdate>(
+    `/student-housing/api/applications/${id}`,
+    payload
+  );
+  return data;
+}
+export async function transitionApplication(
   id: string,
-  payload: Partial<Pick<Application, "points" | "status" | "roomId">>
+  transition: ApplicationTransitionName,
+  extra: { reason?: string; roomId?: string } = {}
 ) {
-  const data = await api.put<
+  const data = await api.post<
     Application,
-    Partial<Pick<Application, "points" | "status" | "roomId">>
-  >(`/student-housing/api/applications/${id}`, payload);
+    { transition: ApplicationTransitionName; reason?: string; roomId?: string }
+  >(`/student-housing/api/applications/${id}/transitions`, { transition, ...extra });
+  return data;
+}
+export async function listApplicationTransitions(id: string) {
+  const data = await api.get<{ items: ApplicationTransition[]; allowed: ApplicationTransitionName[] }>(
+    `/student-housing/api/applications/${id}/transitions`
+  );
   return data;
 }
 export async function deleteApplication(id: string) {
@@ -1,5 +1,6 @@
 import { useCallback } from "react";
 import { HttpService } from "../../services/axios";
+import { passwordPolicyMessage } from "../../models/passwordPolicy";
 
 // prilagodi vrednosti ako tvoj backend koristi drugačije nazive rola
 export type Role = "ADMIN" | "STAFF" | "STUDENT";
@@ -32,6 +33,7 @@ export function useRegister() {
       return res;
     } catch (err: any) {
       const apiMsg =
+        passwordPolicyMessage(err?.response?.data) ||
         err?.response?.data?.error ||
         err?.response?.data?.message ||
         err?.message ||
@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -11,21 +12,70 @@ type Config struct {
 	ServiceHost string
 	ServicePort int
 
+	// Baza je zajednicka sa ostalim servisima; open-data poseduje samo svoje tabele
+	DBHost string
+	DBPort int
+	DBUser string
+	DBPass string
+	DBName string
+	// MIGRATE_ON_START=false kada se migracije pokrecu posebno (`server migrate up`)
+	MigrateOnStart bool
+
 	// Housing upstream (u docker mreži - vidi docker-compose ispod)
 	HousingBaseURL   string        // npr. http://student-housing-service:8080
 	HousingTimeout   time.Duration // default 3s
 	EnableCORS       bool
+
+	// JWT iz auth servisa; javni kljucevi se vuku sa JWKS endpointa
+	JWTIssuer      string
+	JWKSURL        string
+	RevocationsURL string
+
+	// Masinski token za pozive ka housing-u (client credentials kod auth-a)
+	AuthTokenURL        string
+	HousingClientID     string
+	HousingClientSecret string
+
+	// Interni endpointi auth-a (provera API kljuceva)
+	AuthBaseURL string
+
+	// Limiti po minutu: anonimno (po IP adresi) i po tier-u API kljuca
+	RateLimits map[string]int
+
+	// Proksiji cijem X-Forwarded-For verujemo (anonimni limit je po IP adresi)
+	TrustedProxies []string
 }
 
 func GetConfig() *Config {
 	port, _ := strconv.Atoi(envOr("SERVICE_PORT", "8081"))
 	timeoutMs, _ := strconv.Atoi(envOr("HOUSING_TIMEOUT_MS", "3000"))
+	dbPort, _ := strconv.Atoi(envOr("DB_PORT", "5432"))
 	cfg := &Config{
 		ServiceHost:      envOr("SERVICE_HOST", "0.0.0.0"),
 		ServicePort:      port,
+		DBHost:           os.Getenv("DB_HOST"),
+		DBPort:           dbPort,
+		DBUser:           os.Getenv("DB_USER"),
+		DBPass:           os.Getenv("DB_PASS"),
+		DBName:           os.Getenv("DB_NAME"),
+		MigrateOnStart:   envOr("MIGRATE_ON_START", "true") == "true",
 		HousingBaseURL:   envOr("HOUSING_BASE_URL", "http://student-housing-service:8080"),
 		HousingTimeout:   time.Duration(timeoutMs) * time.Millisecond,
 		EnableCORS:       envOr("ENABLE_CORS", "false") == "true",
+		JWTIssuer:        envOr("ISSUER", "demo-auth"),
+		JWKSURL:          envOr("AUTH_JWKS_URL", "http://auth-service:8080/.well-known/jwks.json"),
+		RevocationsURL:   envOr("AUTH_REVOCATIONS_URL", "http://auth-service:8080/revocations"),
+		AuthTokenURL:        envOr("AUTH_TOKEN_URL", "http://auth-service:8080/oauth/token"),
+		HousingClientID:     envOr("OPEN_DATA_CLIENT_ID", "open-data"),
+		HousingClientSecret: os.Getenv("OPEN_DATA_CLIENT_SECRET"),
+		AuthBaseURL:         envOr("AUTH_BASE_URL", "http://auth-service:8080"),
+		RateLimits: map[string]int{
+			"ANONYMOUS": envInt("RATE_LIMIT_ANONYMOUS", 30),
+			"FREE":      envInt("RATE_LIMIT_FREE", 120),
+			"STANDARD":  envInt("RATE_LIMIT_STANDARD", 600),
+			"PARTNER":   envInt("RATE_LIMIT_PARTNER", 3000),
+		},
+		TrustedProxies: splitList(os.Getenv("TRUSTED_PROXIES")),
 	}
 	if cfg.HousingBaseURL == "" {
 		log.Fatal("HOUSING_BASE_URL is required")
@@ -33,6 +83,24 @@ func GetConfig() *Config {
 	return cfg
 }
 
+func envInt(k string, def int) int {
+	n, err := strconv.Atoi(envOr(k, strconv.Itoa(def)))
+	if err != nil {
+		log.Fatalf("Couldn't parse %s: %v", k, err)
+	}
+	return n
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
 func envOr(k, def string) string {
 	if v := os.Getenv(k); v != "" {
 		return v
@@ -1,12 +1,24 @@
 package data
 
 import (
+	"context"
+	"embed"
 	"fmt"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+
+	"shared/migrate"
 )
 
+// Service je ime pod kojim se migracije ovog servisa vode u schema_migrations.
+// Sva tri servisa dele bazu, pa svaki ima svoj niz verzija.
+const Service = "open-data"
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+var migrations = migrate.New(Service, migrationFiles)
 
 func InitDB(host, user, password, dbname string, port int) (*gorm.DB, error) {
 	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=disable", host, user, password, dbname, port)
@@ -17,17 +29,12 @@ func InitDB(host, user, password, dbname string, port int) (*gorm.DB, error) {
 	return db, nil
 }
 
-// func AutoMigrate(db *gorm.DB) error {
-
-// 	err := db.AutoMigrate(
-	
-// 		//OVDE DODAJ NOVI TIp
-// 		//TODO
-// 	)
-// 	if err != nil {
-// 		return err
-// 	}
-
-// 	return nil
-// }
+// MigrateUp primenjuje sve neprimenjene migracije servisa.
+func MigrateUp(ctx context.Context, db *gorm.DB) (int, error) {
+	return migrations.Up(ctx, db)
+}
 
+// RunMigrateCommand obradjuje `server migrate [up|down [N]|status]`.
+func RunMigrateCommand(ctx context.Context, db *gorm.DB, args []string) error {
+	return migrations.RunCommand(ctx, db, args)
+}
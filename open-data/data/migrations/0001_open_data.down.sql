@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS availability_snapshots;
+DROP TABLE IF EXISTS price_plans;
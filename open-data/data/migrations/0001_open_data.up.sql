@@ -0,0 +1,20 @@
+-- Tabele koje poseduje open-data. Domovi, sobe, prijave i uplate su tabele
+-- student-housing servisa i ovde se samo citaju (bez stranih kljuceva preko servisa).
+
+CREATE TABLE IF NOT EXISTS price_plans (
+    id            uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    dorm_id       uuid          NOT NULL,
+    room_type     text          NOT NULL,
+    monthly_price numeric(12,2) NOT NULL,
+    currency      varchar(3)    NOT NULL DEFAULT 'RSD',
+    updated_at    timestamptz   NOT NULL DEFAULT now(),
+    UNIQUE (dorm_id, room_type)
+);
+
+CREATE TABLE IF NOT EXISTS availability_snapshots (
+    dorm_id    uuid   NOT NULL,
+    date       date   NOT NULL,
+    total_beds bigint NOT NULL,
+    free_beds  bigint NOT NULL,
+    PRIMARY KEY (dorm_id, date)
+);
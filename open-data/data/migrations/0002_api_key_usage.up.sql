@@ -0,0 +1,8 @@
+-- Potrosnja API kljuceva po danu; kljucevi zive u auth servisu (bez stranog kljuca)
+
+CREATE TABLE IF NOT EXISTS api_key_usage (
+    key_id   uuid   NOT NULL,
+    day      date   NOT NULL,
+    requests bigint NOT NULL,
+    PRIMARY KEY (key_id, day)
+);
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"open-data/upstream"
+	"shared/middleware"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jung-kurt/gofpdf"
@@ -76,7 +77,9 @@ func (h *DormsHandler) ListStudents(c *gin.Context) {
 	// Ostavili smo JSON endpoint ako ti treba real, ali frontend ga ne koristi.
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	size, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
-	resp, err := h.Housing.ListStudents(c.Request.Context(), page, size)
+	// Licni podaci: housing dobija token korisnika (student:read), ne masinski
+	token, _ := middleware.BearerToken(c)
+	resp, err := h.Housing.ListStudents(c.Request.Context(), token, page, size)
 	if err != nil {
 		c.JSON(http.StatusBadGateway, gin.H{"error": "upstream error", "details": err.Error()})
 		return
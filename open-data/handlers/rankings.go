@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"open-data/types"
+	"open-data/upstream"
+
+	"github.com/gin-gonic/gin"
+)
+
+/* ========================== RANKING LISTS (real) ========================== */
+
+// Objavljene rang liste konkursa sa student-housing-a; CSV nudi sam housing, ovde je PDF.
+
+var (
+	uuidRe    = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	versionRe = regexp.MustCompile(`^([1-9][0-9]*|latest)$`)
+)
+
+func (h *DormsHandler) GetRankingList(c *gin.Context) {
+	list, ok := h.rankingList(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+func (h *DormsHandler) RankingListPDF(c *gin.Context) {
+	download := c.Query("download") == "1"
+	list, ok := h.rankingList(c)
+	if !ok {
+		return
+	}
+
+	title := "Provisional ranking list"
+	if list.Vrsta == "FINAL" {
+		title = "Final ranking list"
+	}
+	pdf := newPDF(fmt.Sprintf("%s (v%d)", title, list.Verzija))
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.Cell(0, 6, "Published: "+list.Objavljena)
+	pdf.Ln(6)
+	if list.RokZalbe != "" {
+		pdf.Cell(0, 6, "Appeals close: "+list.RokZalbe)
+		pdf.Ln(6)
+	}
+	pdf.Ln(4)
+
+	widths := []float64{20.0, 60.0, 30.0}
+	headerRow(pdf, widths, []string{"Rank", "Index", "Points"})
+	for _, r := range list.Redovi {
+		row(pdf, 7.0, widths, []string{
+			fmt.Sprintf("%d", r.Rang),
+			truncate(pdf, r.Indeks, widths[1]-2),
+			fmt.Sprintf("%.2f", r.Bodovi),
+		})
+	}
+
+	name := fmt.Sprintf("ranking_%s_v%d.pdf", strings.ToLower(list.Vrsta), list.Verzija)
+	writePDFResponse(c, pdf, name, download)
+}
+
+func (h *DormsHandler) rankingList(c *gin.Context) (*types.ODRankingList, bool) {
+	id, version := c.Param("competitionId"), c.Param("version")
+	if !uuidRe.MatchString(id) || !versionRe.MatchString(version) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid competition id or version"})
+		return nil, false
+	}
+	list, err := h.Housing.GetRankingList(c.Request.Context(), id, version)
+	if errors.Is(err, upstream.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ranking list not found"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "upstream error", "details": err.Error()})
+		return nil, false
+	}
+	return list, true
+}
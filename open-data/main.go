@@ -1,41 +1,91 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
 	"open-data/config"
+	"open-data/data"
 	"open-data/handlers"
+	"open-data/quota"
+	"open-data/types"
 	"open-data/upstream"
-
-	"github.com/gin-gonic/gin"
+	"shared/middleware"
 )
 
 func main() {
 	cfg := config.GetConfig()
 
+	db, err := data.InitDB(cfg.DBHost, cfg.DBUser, cfg.DBPass, cfg.DBName, cfg.DBPort)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to connect to database: %v", err))
+	}
+
+	// `server migrate [up|down N|status]` radi samo migracije i izlazi
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := data.RunMigrateCommand(context.Background(), db, os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if cfg.MigrateOnStart {
+		if _, err := data.MigrateUp(context.Background(), db); err != nil {
+			panic(err)
+		}
+	}
+
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.Default()
 
-	if err := router.SetTrustedProxies(nil); err != nil {
+	// Iza nginx-a: bez poverenja u X-Forwarded-For svi anonimni pozivi bi delili IP proksija
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
 		panic("Error setting trusted proxies")
 	}
 
 	// Upstream klijent ka student-housing servisu
-	housingClient := upstream.NewHousingClient(cfg.HousingBaseURL, cfg.HousingTimeout)
+	// Zbirni podaci sa housing-a traze masinski token; bez secret-a rade samo javne rute
+	var tokens *upstream.TokenSource
+	if cfg.HousingClientSecret != "" {
+		tokens = upstream.NewTokenSource(cfg.AuthTokenURL, cfg.HousingClientID, cfg.HousingClientSecret, types.ScopeHousingStatsRead, cfg.HousingTimeout)
+	} else {
+		log.Println("OPEN_DATA_CLIENT_SECRET not set: housing stats will be unavailable")
+	}
+	housingClient := upstream.NewHousingClient(cfg.HousingBaseURL, cfg.HousingTimeout, tokens)
 	dormsHandler := handlers.NewDormsHandler(housingClient)
 
+	// API kljucevi: provera preko auth-a, limit po tier-u, potrosnja po kljucu
+	usage := quota.NewUsage(db)
+	go usage.Run(context.Background(), 30*time.Second)
+	guard := quota.NewGuard(upstream.NewAuthClient(cfg.AuthBaseURL, cfg.HousingTimeout), cfg.RateLimits, usage)
+	go guard.Run(context.Background(), time.Minute)
+
 	// Health
 	router.GET("/healthz", func(c *gin.Context) { c.JSON(200, gin.H{"ok": true}) })
 
-	api := router.Group("")
+	api := router.Group("", guard.Middleware())
 	{
+		// Potrosnja kljuca kojim je poziv stigao
+		api.GET("/usage", quota.MyUsage(db))
+
 		// Dorms
 		api.GET("/dorms", dormsHandler.ListDorms)
 		api.GET("/dorms.pdf", dormsHandler.DormsPDF)
 
-		// Students
-		api.GET("/students", dormsHandler.ListStudents)
-		api.GET("/students.pdf", dormsHandler.StudentsPDF)
+		// Students (PII - samo pozivaoci sa dozvolom student:read)
+		staff := api.Group("",
+			middleware.RequireAuth(cfg.JWTIssuer,
+				middleware.NewJWKS(cfg.JWKSURL, 10*time.Minute),
+				middleware.NewRevocations(cfg.RevocationsURL, 30*time.Second),
+			),
+			middleware.RequirePermission(types.PermStudentRead),
+		)
+		staff.GET("/students", dormsHandler.ListStudents)
+		staff.GET("/students.pdf", dormsHandler.StudentsPDF)
 
 		// Price plans
 		api.GET("/price-plans", dormsHandler.ListPricePlans)
@@ -52,6 +102,10 @@ func main() {
 		// Payment stats
 		api.GET("/payment-stats", dormsHandler.ListPaymentStats)
 		api.GET("/payment-stats.pdf", dormsHandler.PaymentStatsPDF)
+
+		// Objavljene rang liste konkursa (:version je broj ili "latest")
+		api.GET("/rankings/:competitionId/:version", dormsHandler.GetRankingList)
+		api.GET("/rankings/:competitionId/:version/pdf", dormsHandler.RankingListPDF)
 	}
 
 	url := fmt.Sprintf("%s:%d", cfg.ServiceHost, cfg.ServicePort)
@@ -0,0 +1,220 @@
+package quota
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"open-data/upstream"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Kljucevi u gin.Context za kljuc kojim je zahtev stigao
+const (
+	CtxAPIKeyID = "apiKeyID"
+	CtxTier     = "apiKeyTier"
+)
+
+// Anonimni pozivi (bez kljuca) se broje po IP adresi pod ovim tier-om
+const TierAnonymous = "ANONYMOUS"
+
+const (
+	headerAPIKey = "X-API-Key"
+	queryAPIKey  = "api_key"
+
+	window       = time.Minute
+	keyCacheTTL  = time.Minute
+	maxCacheSize = 10000
+)
+
+// Guard proverava API kljuc (preko auth-a, sa kesom), sprovodi limit tier-a po minutu
+// i broji zahteve po kljucu. Brojaci limita su u memoriji (jedna instanca open-data).
+type Guard struct {
+	auth   *upstream.AuthClient
+	limits map[string]int // tier -> zahteva u minuti
+	usage  *Usage
+
+	mu      sync.Mutex
+	keys    map[string]cachedKey // sha256(kljuc) -> rezultat provere
+	windows map[string]*counter  // "key:<id>" ili "ip:<adresa>"
+}
+
+type cachedKey struct {
+	info  *upstream.APIKeyInfo // nil = nepoznat ili opozvan kljuc
+	until time.Time
+}
+
+type counter struct {
+	start time.Time
+	n     int
+}
+
+func NewGuard(auth *upstream.AuthClient, limits map[string]int, usage *Usage) *Guard {
+	return &Guard{
+		auth:    auth,
+		limits:  limits,
+		usage:   usage,
+		keys:    map[string]cachedKey{},
+		windows: map[string]*counter{},
+	}
+}
+
+// Middleware prihvata kljuc iz X-API-Key zaglavlja ili ?api_key= parametra.
+// Pogresan kljuc je 401 (ne pada tiho na anonimni limit).
+func (g *Guard) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tier, bucket := TierAnonymous, "ip:"+c.ClientIP()
+
+		if raw := apiKey(c); raw != "" {
+			info, err := g.lookup(c.Request.Context(), raw)
+			if errors.Is(err, upstream.ErrUnknownAPIKey) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid api key"})
+				return
+			}
+			if err != nil {
+				log.Printf("[quota] verify api key err: %v", err)
+				c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "api key check unavailable"})
+				return
+			}
+			tier, bucket = info.Tier, "key:"+info.ID
+			c.Set(CtxAPIKeyID, info.ID)
+			c.Set(CtxTier, info.Tier)
+		}
+
+		limit, ok := g.limits[tier]
+		if !ok {
+			// Novi tier iz auth-a za koji open-data nema podesavanje dobija anonimni limit
+			limit = g.limits[TierAnonymous]
+		}
+		remaining, reset, allowed := g.take(bucket, limit)
+		c.Header("X-RateLimit-Limit", fmt.Sprint(limit))
+		c.Header("X-RateLimit-Remaining", fmt.Sprint(remaining))
+		c.Header("X-RateLimit-Reset", fmt.Sprint(seconds(reset)))
+		if !allowed {
+			c.Header("Retry-After", fmt.Sprint(seconds(reset)))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error":      "rate limit exceeded",
+				"code":       "RATE_LIMITED",
+				"tier":       tier,
+				"retryAfter": seconds(reset),
+			})
+			return
+		}
+
+		if id, ok := c.Get(CtxAPIKeyID); ok {
+			g.usage.Add(id.(string))
+		}
+		c.Next()
+	}
+}
+
+// Run povremeno cisti istekle prozore i kes kljuceva.
+func (g *Guard) Run(ctx context.Context, every time.Duration) {
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			g.prune(time.Now())
+		}
+	}
+}
+
+/* ===================== Helpers ===================== */
+
+func (g *Guard) lookup(ctx context.Context, raw string) (*upstream.APIKeyInfo, error) {
+	sum := sha256.Sum256([]byte(raw))
+	hash := hex.EncodeToString(sum[:])
+
+	g.mu.Lock()
+	cached, ok := g.keys[hash]
+	g.mu.Unlock()
+	if ok && time.Now().Before(cached.until) {
+		if cached.info == nil {
+			return nil, upstream.ErrUnknownAPIKey
+		}
+		return cached.info, nil
+	}
+
+	info, err := g.auth.VerifyAPIKey(ctx, raw)
+	if err != nil && !errors.Is(err, upstream.ErrUnknownAPIKey) {
+		return nil, err
+	}
+	entry := cachedKey{until: time.Now().Add(keyCacheTTL)}
+	if err == nil {
+		entry.info = &info
+	}
+
+	g.mu.Lock()
+	if len(g.keys) >= maxCacheSize {
+		// Zastita od nasumicnih kljuceva: kes se prazni umesto da raste
+		g.keys = map[string]cachedKey{}
+	}
+	g.keys[hash] = entry
+	g.mu.Unlock()
+
+	if entry.info == nil {
+		return nil, upstream.ErrUnknownAPIKey
+	}
+	return entry.info, nil
+}
+
+// take broji zahtev u prozoru od jednog minuta (fixed window).
+func (g *Guard) take(bucket string, limit int) (remaining int, reset time.Duration, ok bool) {
+	now := time.Now()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	w := g.windows[bucket]
+	if w == nil || now.Sub(w.start) >= window {
+		w = &counter{start: now}
+		g.windows[bucket] = w
+	}
+	reset = w.start.Add(window).Sub(now)
+	if w.n >= limit {
+		return 0, reset, false
+	}
+	w.n++
+	return limit - w.n, reset, true
+}
+
+func (g *Guard) prune(now time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for b, w := range g.windows {
+		if now.Sub(w.start) >= window {
+			delete(g.windows, b)
+		}
+	}
+	for h, k := range g.keys {
+		if now.After(k.until) {
+			delete(g.keys, h)
+		}
+	}
+}
+
+func apiKey(c *gin.Context) string {
+	if k := strings.TrimSpace(c.GetHeader(headerAPIKey)); k != "" {
+		return k
+	}
+	return strings.TrimSpace(c.Query(queryAPIKey))
+}
+
+func seconds(d time.Duration) int {
+	s := int(math.Ceil(d.Seconds()))
+	if s < 1 {
+		s = 1
+	}
+	return s
+}
@@ -0,0 +1,111 @@
+package quota
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// APIKeyUsage je broj zahteva jednog kljuca po danu (UTC).
+type APIKeyUsage struct {
+	KeyID    string    `gorm:"type:uuid;primaryKey" json:"keyId"`
+	Day      time.Time `gorm:"type:date;primaryKey" json:"day"`
+	Requests int64     `gorm:"not null" json:"requests"`
+}
+
+func (APIKeyUsage) TableName() string { return "api_key_usage" }
+
+// Usage sabira zahteve u memoriji i povremeno ih upisuje, da svaki zahtev ne bi pisao u bazu.
+type Usage struct {
+	db *gorm.DB
+
+	mu      sync.Mutex
+	pending map[usageKey]int64
+}
+
+type usageKey struct {
+	keyID string
+	day   time.Time
+}
+
+func NewUsage(db *gorm.DB) *Usage {
+	return &Usage{db: db, pending: map[usageKey]int64{}}
+}
+
+func (u *Usage) Add(keyID string) {
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+	u.mu.Lock()
+	u.pending[usageKey{keyID, day}]++
+	u.mu.Unlock()
+}
+
+// Run upisuje sabrane brojeve na svakih every i jos jednom pri gasenju (ctx).
+func (u *Usage) Run(ctx context.Context, every time.Duration) {
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			u.flush(context.Background())
+			return
+		case <-t.C:
+			u.flush(ctx)
+		}
+	}
+}
+
+func (u *Usage) flush(ctx context.Context) {
+	u.mu.Lock()
+	batch := u.pending
+	u.pending = map[usageKey]int64{}
+	u.mu.Unlock()
+	if len(batch) == 0 {
+		return
+	}
+
+	rows := make([]APIKeyUsage, 0, len(batch))
+	for k, n := range batch {
+		rows = append(rows, APIKeyUsage{KeyID: k.keyID, Day: k.day, Requests: n})
+	}
+	err := u.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key_id"}, {Name: "day"}},
+		DoUpdates: clause.Assignments(map[string]any{"requests": gorm.Expr("api_key_usage.requests + EXCLUDED.requests")}),
+	}).Create(&rows).Error
+	if err != nil {
+		// Brojevi se vracaju u sledeci upis umesto da se izgube
+		log.Printf("[usage] flush err: %v", err)
+		u.mu.Lock()
+		for k, n := range batch {
+			u.pending[k] += n
+		}
+		u.mu.Unlock()
+	}
+}
+
+// MyUsage vraca dnevnu potrosnju kljuca kojim je zahtev stigao (poslednjih 30 dana).
+func MyUsage(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := c.Get(CtxAPIKeyID)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "api key required"})
+			return
+		}
+		var rows []APIKeyUsage
+		since := time.Now().UTC().AddDate(0, 0, -30)
+		if err := db.WithContext(c.Request.Context()).
+			Where("key_id = ? AND day >= ?", id, since).
+			Order("day").
+			Find(&rows).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load usage"})
+			return
+		}
+		tier, _ := c.Get(CtxTier)
+		c.JSON(http.StatusOK, gin.H{"keyId": id, "tier": tier, "items": rows})
+	}
+}
@@ -44,3 +44,36 @@ type ODPaymentStats struct {
 	Sum      float64 `json:"sum"`
 	Currency string  `json:"currency"`
 }
+
+// ODRankingList je objavljena rang lista konkursa; redovi su pseudonimizovani (indeks i bodovi).
+type ODRankingList struct {
+	KonkursID  string         `json:"konkursId"`
+	Verzija    int            `json:"verzija"`
+	Vrsta      string         `json:"vrsta"` // "PROVISIONAL","FINAL"
+	Objavljena string         `json:"objavljena"`
+	RokZalbe   string         `json:"rokZalbe,omitempty"` // samo privremena lista
+	Redovi     []ODRankingRow `json:"redovi"`
+}
+
+type ODRankingRow struct {
+	Rang   int     `json:"rang"`
+	Indeks string  `json:"indeks"`
+	Bodovi float64 `json:"bodovi"`
+}
+
+// Uloge iz auth tokena (iste vrednosti kao u student-housing servisu)
+type Role string
+
+const (
+	AdminRole   Role = "ADMIN"
+	StudentRole Role = "STUDENT"
+	StaffRole   Role = "STAFF"
+)
+
+// Permission odgovara dozvolama koje auth upisuje u token (claim "perms").
+type Permission string
+
+const PermStudentRead Permission = "student:read"
+
+// Scope masinskog tokena kojim open-data cita zbirne podatke sa student-housing-a
+const ScopeHousingStatsRead = "housing:stats:read"
@@ -0,0 +1,62 @@
+package upstream
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrUnknownAPIKey: auth ne poznaje kljuc, ili je opozvan.
+var ErrUnknownAPIKey = errors.New("unknown or revoked api key")
+
+// AuthClient poziva interne endpointe auth servisa koji nisu dostupni spolja.
+type AuthClient struct {
+	base  string
+	httpc *http.Client
+}
+
+// APIKeyInfo prati types.APIKeyInfo iz auth servisa.
+type APIKeyInfo struct {
+	ID     string `json:"id"`
+	UserID uint   `json:"ownerId"`
+	Tier   string `json:"tier"`
+}
+
+func NewAuthClient(base string, timeout time.Duration) *AuthClient {
+	return &AuthClient{
+		base:  strings.TrimSuffix(base, "/"),
+		httpc: &http.Client{Timeout: timeout},
+	}
+}
+
+// VerifyAPIKey vraca vlasnika i tier aktivnog kljuca.
+func (a *AuthClient) VerifyAPIKey(ctx context.Context, key string) (APIKeyInfo, error) {
+	body, _ := json.Marshal(map[string]string{"key": key})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.base+"/internal/api-keys/verify", bytes.NewReader(body))
+	if err != nil {
+		return APIKeyInfo{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	res, err := a.httpc.Do(req)
+	if err != nil {
+		return APIKeyInfo{}, err
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		return APIKeyInfo{}, ErrUnknownAPIKey
+	case res.StatusCode >= 300:
+		return APIKeyInfo{}, fmt.Errorf("auth upstream status %d", res.StatusCode)
+	}
+	var out APIKeyInfo
+	err = json.NewDecoder(res.Body).Decode(&out)
+	return out, err
+}
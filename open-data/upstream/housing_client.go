@@ -3,6 +3,7 @@ package upstream
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -25,6 +26,53 @@ type dormDTO struct {
 	UpdatedAt string   `json:"updatedAt,omitempty"`
 }
 
+// Zbirni podaci sa /api/stats/* (masinski token sa housing:stats:read)
+type applicationStatsDTO struct {
+	DormID    string `json:"dormId"`
+	Date      string `json:"date"`
+	Submitted int    `json:"submitted"`
+	Accepted  int    `json:"accepted"`
+	Rejected  int    `json:"rejected"`
+	Reserved  int    `json:"reserved"`
+}
+
+type applicationStatsRaw struct {
+	Items      []applicationStatsDTO `json:"items"`
+	Pagination Pagination            `json:"pagination"`
+}
+
+type paymentStatsDTO struct {
+	DormID string  `json:"dormId"`
+	Date   string  `json:"date"`
+	Count  int     `json:"count"`
+	Sum    float64 `json:"sum"`
+}
+
+type paymentStatsRaw struct {
+	Items      []paymentStatsDTO `json:"items"`
+	Pagination Pagination        `json:"pagination"`
+}
+
+// Objavljena rang lista sa /api/competitions/:id/rankings/:version (javna ruta)
+type rankingListDTO struct {
+	CompetitionID  string     `json:"competitionId"`
+	Version        int        `json:"version"`
+	Kind           string     `json:"kind"`
+	PublishedAt    time.Time  `json:"publishedAt"`
+	AppealsCloseAt *time.Time `json:"appealsCloseAt"`
+	Rows           []struct {
+		Rank   int     `json:"rank"`
+		Index  string  `json:"index"`
+		Points float64 `json:"points"`
+	} `json:"rows"`
+}
+
+// ErrNotFound: housing nema trazeni zapis (404).
+var ErrNotFound = errors.New("not found upstream")
+
+// Housing uplate vodi u dinarima
+const paymentCurrency = "RSD"
+
 type dormListRaw struct {
 	Items      []dormDTO  `json:"items"`
 	Pagination Pagination `json:"pagination"`
@@ -35,7 +83,6 @@ type dormListRaw struct {
 type Student struct {
 	ID        uint   `gorm:"primaryKey" json:"ID"`
 	Email     string `gorm:"unique;not null" json:"email"`
-	Password  string `gorm:"not null" json:"password"`
 	Index     string `json:"index"`
 	FirstName string `gorm:"not null" json:"firstName"`
 	LastName  string `gorm:"not null" json:"lastName"`
@@ -78,17 +125,21 @@ type PaymentStatsListResponse struct {
 	Pagination Pagination             `json:"pagination"`
 }
 
+// HousingClient salje masinski token (tokens) uz svaki poziv; bez njega housing
+// vraca samo javne podatke (domovi). Licni podaci idu samo sa tokenom korisnika.
 type HousingClient struct {
-	base  string
-	httpc *http.Client
+	base   string
+	httpc  *http.Client
+	tokens *TokenSource
 }
 
-func NewHousingClient(base string, timeout time.Duration) *HousingClient {
+func NewHousingClient(base string, timeout time.Duration, tokens *TokenSource) *HousingClient {
 	return &HousingClient{
 		base: base,
 		httpc: &http.Client{
 			Timeout: timeout,
 		},
+		tokens: tokens,
 	}
 }
 
@@ -126,9 +177,11 @@ func (c *HousingClient) ListDorms(ctx context.Context, page, pageSize int) (*Dor
 	}, nil
 }
 
-func (c *HousingClient) ListStudents(ctx context.Context, page, pageSize int) (*StudentsListResponse, error) {
+// ListStudents prosledjuje token korisnika koji je pozvao open-data: housing
+// odbija masinske tokene na rutama sa licnim podacima.
+func (c *HousingClient) ListStudents(ctx context.Context, userToken string, page, pageSize int) (*StudentsListResponse, error) {
 	var out StudentsListResponse
-	if err := c.get(ctx, "/api/students", page, pageSize, &out); err != nil {
+	if err := c.do(ctx, userToken, "/api/students", page, pageSize, &out); err != nil {
 		return nil, err
 	}
 	return &out, nil
@@ -152,24 +205,74 @@ func (c *HousingClient) ListDailyAvailability(ctx context.Context, page, pageSiz
 }
 
 func (c *HousingClient) ListApplicationStats(ctx context.Context, page, pageSize int) (*ApplicationStatsListResponse, error) {
-	var out ApplicationStatsListResponse
-	if err := c.get(ctx, "/api/applications", page, pageSize, &out); err != nil {
+	var raw applicationStatsRaw
+	if err := c.get(ctx, "/api/stats/applications", page, pageSize, &raw); err != nil {
 		return nil, err
 	}
-	return &out, nil
+	items := make([]types.ODApplicationStats, 0, len(raw.Items))
+	for _, r := range raw.Items {
+		items = append(items, types.ODApplicationStats{
+			DomID:       r.DormID,
+			Date:        r.Date,
+			Predate:     r.Submitted,
+			Prihvacene:  r.Accepted,
+			Odbijene:    r.Rejected,
+			Rezervisane: r.Reserved,
+		})
+	}
+	return &ApplicationStatsListResponse{Items: items, Pagination: raw.Pagination}, nil
 }
 
 func (c *HousingClient) ListPaymentStats(ctx context.Context, page, pageSize int) (*PaymentStatsListResponse, error) {
-	var out PaymentStatsListResponse
-	if err := c.get(ctx, "/api/payments", page, pageSize, &out); err != nil {
+	var raw paymentStatsRaw
+	if err := c.get(ctx, "/api/stats/payments", page, pageSize, &raw); err != nil {
 		return nil, err
 	}
-	return &out, nil
+	items := make([]types.ODPaymentStats, 0, len(raw.Items))
+	for _, r := range raw.Items {
+		items = append(items, types.ODPaymentStats{
+			DomID:    r.DormID,
+			Date:     r.Date,
+			Count:    r.Count,
+			Sum:      r.Sum,
+			Currency: paymentCurrency,
+		})
+	}
+	return &PaymentStatsListResponse{Items: items, Pagination: raw.Pagination}, nil
+}
+
+// GetRankingList vraca objavljenu listu konkursa; version je broj ili "latest".
+func (c *HousingClient) GetRankingList(ctx context.Context, competitionID, version string) (*types.ODRankingList, error) {
+	var raw rankingListDTO
+	if err := c.get(ctx, path.Join("/api/competitions", competitionID, "rankings", version), 0, 0, &raw); err != nil {
+		return nil, err
+	}
+	out := &types.ODRankingList{
+		KonkursID:  raw.CompetitionID,
+		Verzija:    raw.Version,
+		Vrsta:      raw.Kind,
+		Objavljena: raw.PublishedAt.UTC().Format(time.RFC3339),
+		Redovi:     make([]types.ODRankingRow, 0, len(raw.Rows)),
+	}
+	if raw.AppealsCloseAt != nil {
+		out.RokZalbe = raw.AppealsCloseAt.UTC().Format(time.RFC3339)
+	}
+	for _, r := range raw.Rows {
+		out.Redovi = append(out.Redovi, types.ODRankingRow{Rang: r.Rank, Indeks: r.Index, Bodovi: r.Points})
+	}
+	return out, nil
 }
 
 /* ========= zajednički GET helper ========= */
 
+// get ide sa masinskim tokenom open-data servisa.
 func (c *HousingClient) get(ctx context.Context, p string, page, pageSize int, out any) error {
+	return c.do(ctx, "", p, page, pageSize, out)
+}
+
+// do salje userToken ako je zadat, inace masinski token. Na 401 sa masinskim tokenom
+// (npr. kljuc rotiran ili klijent obrisan) token se odbacuje i poziv ponavlja jednom.
+func (c *HousingClient) do(ctx context.Context, userToken, p string, page, pageSize int, out any) error {
 	u, err := url.Parse(c.base)
 	if err != nil {
 		return err
@@ -185,17 +288,38 @@ func (c *HousingClient) get(ctx context.Context, p string, page, pageSize int, o
 	}
 	u.RawQuery = q.Encode()
 
-	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
-	req.Header.Set("Accept", "application/json")
+	machine := userToken == "" && c.tokens != nil
+	for attempt := 0; ; attempt++ {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		req.Header.Set("Accept", "application/json")
 
-	res, err := c.httpc.Do(req)
-	if err != nil {
-		return err
-	}
-	defer res.Body.Close()
+		token := userToken
+		if machine {
+			if token, err = c.tokens.Token(ctx); err != nil {
+				return fmt.Errorf("housing service token: %w", err)
+			}
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
 
-	if res.StatusCode >= 300 {
-		return fmt.Errorf("housing upstream status %d", res.StatusCode)
+		res, err := c.httpc.Do(req)
+		if err != nil {
+			return err
+		}
+		if res.StatusCode == http.StatusUnauthorized && machine && attempt == 0 {
+			res.Body.Close()
+			c.tokens.Invalidate()
+			continue
+		}
+		defer res.Body.Close()
+
+		if res.StatusCode == http.StatusNotFound {
+			return ErrNotFound
+		}
+		if res.StatusCode >= 300 {
+			return fmt.Errorf("housing upstream status %d", res.StatusCode)
+		}
+		return json.NewDecoder(res.Body).Decode(out)
 	}
-	return json.NewDecoder(res.Body).Decode(out)
 }
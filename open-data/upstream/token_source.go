@@ -0,0 +1,91 @@
+package upstream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TokenSource pribavlja masinski token od auth servisa (OAuth2 client credentials)
+// i kesira ga do malo pre isteka. Bezbedan je za istovremene pozive.
+type TokenSource struct {
+	tokenURL string
+	clientID string
+	secret   string
+	scope    string
+	httpc    *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// Token se obnavlja ovoliko pre isteka, da ne bi istekao na putu do housing-a
+const tokenExpiryLeeway = 30 * time.Second
+
+func NewTokenSource(tokenURL, clientID, secret, scope string, timeout time.Duration) *TokenSource {
+	return &TokenSource{
+		tokenURL: tokenURL,
+		clientID: clientID,
+		secret:   secret,
+		scope:    scope,
+		httpc:    &http.Client{Timeout: timeout},
+	}
+}
+
+func (s *TokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && time.Now().Before(s.expires) {
+		return s.token, nil
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if s.scope != "" {
+		form.Set("scope", s.scope)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(s.clientID, s.secret)
+
+	res, err := s.httpc.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("auth token endpoint status %d", res.StatusCode)
+	}
+
+	var out struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&out); err != nil {
+		return "", err
+	}
+	if out.AccessToken == "" {
+		return "", fmt.Errorf("auth token endpoint returned no token")
+	}
+
+	s.token = out.AccessToken
+	s.expires = time.Now().Add(time.Duration(out.ExpiresIn)*time.Second - tokenExpiryLeeway)
+	return s.token, nil
+}
+
+// Invalidate odbacuje kesirani token (npr. posle 401 od housing-a).
+func (s *TokenSource) Invalidate() {
+	s.mu.Lock()
+	s.token = ""
+	s.mu.Unlock()
+}
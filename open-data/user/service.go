@@ -123,19 +123,19 @@ func GetDailyAvailability(ctx context.Context, db *gorm.DB, domID string, from,
 func GetApplicationStats(ctx context.Context, db *gorm.DB, domID string, from, to time.Time) ([]types.ODApplicationStats, error) {
 	q := db.WithContext(ctx).Raw(`
 		SELECT 
-			s.dom_id::text AS dom_id,
-			p.kreirano::date AS date,
-			SUM(CASE WHEN p.status = 'PREDATA'     THEN 1 ELSE 0 END) AS predate,
-			SUM(CASE WHEN p.status = 'PRIHVACENA'  THEN 1 ELSE 0 END) AS prihvacene,
-			SUM(CASE WHEN p.status = 'ODBIJENA'    THEN 1 ELSE 0 END) AS odbijene,
-			SUM(CASE WHEN p.status = 'REZERVISANA' THEN 1 ELSE 0 END) AS rezervisane
-		FROM prijavas p
-		LEFT JOIN sobas s ON s.id = p.soba_id
-		WHERE ($1 = '' OR s.dom_id::text = $1)
-		  AND p.kreirano::date >= $2::date
-		  AND p.kreirano::date <= $3::date
-		GROUP BY s.dom_id, p.kreirano::date
-		ORDER BY s.dom_id, p.kreirano::date;
+			s.dorm_id::text AS dom_id,
+			p.created_at::date AS date,
+			SUM(CASE WHEN p.status = 'SUBMITTED' THEN 1 ELSE 0 END) AS predate,
+			SUM(CASE WHEN p.status = 'ACCEPTED'  THEN 1 ELSE 0 END) AS prihvacene,
+			SUM(CASE WHEN p.status = 'REJECTED'  THEN 1 ELSE 0 END) AS odbijene,
+			SUM(CASE WHEN p.status = 'RESERVED'  THEN 1 ELSE 0 END) AS rezervisane
+		FROM applications p
+		LEFT JOIN rooms s ON s.id = p.room_id
+		WHERE ($1 = '' OR s.dorm_id::text = $1)
+		  AND p.created_at::date >= $2::date
+		  AND p.created_at::date <= $3::date
+		GROUP BY s.dorm_id, p.created_at::date
+		ORDER BY s.dorm_id, p.created_at::date;
 	`, domID, from, to)
 
 	type row struct {
@@ -167,19 +167,19 @@ func GetApplicationStats(ctx context.Context, db *gorm.DB, domID string, from, t
 func GetPaymentStats(ctx context.Context, db *gorm.DB, domID string, from, to time.Time, currency string) ([]types.ODPaymentStats, error) {
 	q := db.WithContext(ctx).Raw(`
 		SELECT 
-			s.dom_id::text AS dom_id,
-			u.izdato::date AS date,
-			COUNT(*)       AS count,
-			SUM(u.iznos)   AS sum,
-			$4             AS currency
-		FROM uplatnicas u
-		JOIN prijavas p ON p.id = u.prijava_id
-		LEFT JOIN sobas s ON s.id = p.soba_id
-		WHERE ($1 = '' OR s.dom_id::text = $1)
-		  AND u.izdato::date >= $2::date
-		  AND u.izdato::date <= $3::date
-		GROUP BY s.dom_id, u.izdato::date
-		ORDER BY s.dom_id, u.izdato::date;
+			s.dorm_id::text   AS dom_id,
+			u.issued_at::date AS date,
+			COUNT(*)          AS count,
+			SUM(u.amount)     AS sum,
+			$4                AS currency
+		FROM payments u
+		JOIN applications p ON p.id = u.application_id
+		LEFT JOIN rooms s ON s.id = p.room_id
+		WHERE ($1 = '' OR s.dorm_id::text = $1)
+		  AND u.issued_at::date >= $2::date
+		  AND u.issued_at::date <= $3::date
+		GROUP BY s.dorm_id, u.issued_at::date
+		ORDER BY s.dorm_id, u.issued_at::date;
 	`, domID, from, to, currency)
 
 	type row struct {
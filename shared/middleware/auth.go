@@ -0,0 +1,300 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Kljucevi pod kojima se podaci o pozivaocu cuvaju u gin.Context
+const (
+	CtxUserID = "userID"
+	CtxRole   = "role"
+	CtxEmail  = "email"
+	CtxPerms  = "perms"
+	CtxDorms  = "dorms"
+
+	CtxClientID = "clientID"
+
+	// ID admina kada zahtev stize sa tokenom za impersonaciju
+	CtxActorID = "actorID"
+
+	ctxImpersonationAllowed = "impersonationAllowed"
+)
+
+// Paket dele student-housing i open-data. Uloge i dozvole su tipovi iz types paketa
+// servisa, pa ih helperi primaju kao bilo koji string tip.
+
+// Claims prati token koji izdaje auth servis (login). Polje ID je korisnicki id; jti je RegisteredClaims.ID.
+// Perms su efektivne dozvole uloge, Dorms domovi na koje je korisnik ogranicen (prazno = svi).
+// Masinski token (client credentials) umesto ID/Role nosi ClientID i Scope.
+type Claims struct {
+	ID       uint     `json:"id"`
+	Role     string   `json:"role"`
+	Perms    []string `json:"perms,omitempty"`
+	Dorms    []string `json:"dorms,omitempty"`
+	ClientID string   `json:"client_id,omitempty"`
+	Scope    string   `json:"scope,omitempty"`
+	// Sid je sesija u auth-u iz koje je token izdat (odjava sesije gasi i token)
+	Sid string `json:"sid,omitempty"`
+	// Act je admin koji radi u ime korisnika (impersonacija iz auth-a, RFC 8693 "act")
+	Act *Actor `json:"act,omitempty"`
+	jwt.RegisteredClaims
+}
+
+type Actor struct {
+	ID      uint   `json:"id"`
+	Subject string `json:"sub"`
+}
+
+// AllowImpersonation dozvoljava izmenu (POST/PUT/PATCH/DELETE) i sa tokenom za
+// impersonaciju. Ide ispred RequireAuth; bez nje takve rute vracaju 403.
+func AllowImpersonation(c *gin.Context) {
+	c.Set(ctxImpersonationAllowed, true)
+	c.Next()
+}
+
+// RequireAuth proverava Bearer token (EdDSA potpis preko JWKS, issuer, exp, opoziv)
+// i stavlja id/role pozivaoca u context.
+func RequireAuth(issuer string, keys *JWKS, revoked *Revocations) gin.HandlerFunc {
+	parser := newParser(issuer)
+
+	return func(c *gin.Context) {
+		claims, ok := parseBearer(c, parser, keys)
+		if !ok {
+			return
+		}
+		// Rute iza RequireAuth rade sa licnim podacima; servisi za njih nemaju pristup
+		if claims.ClientID != "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "machine tokens are not accepted on this endpoint"})
+			return
+		}
+		if claims.ID == 0 || claims.Role == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
+			return
+		}
+		var iat int64
+		if claims.IssuedAt != nil {
+			iat = claims.IssuedAt.Unix()
+		}
+		if revoked.IsRevoked(c.Request.Context(), claims.RegisteredClaims.ID, claims.ID, iat) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token revoked"})
+			return
+		}
+		if claims.Sid != "" && revoked.IsSessionRevoked(c.Request.Context(), claims.Sid) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "session revoked"})
+			return
+		}
+		if act := claims.Act; act != nil {
+			if revoked.IsRevoked(c.Request.Context(), "", act.ID, iat) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token revoked"})
+				return
+			}
+			// Pristupni log vidi samo studenta; ovde ostaje ko je stvarno poslao zahtev
+			log.Printf("[impersonation] actor=%d (%s) as user=%d: %s %s", act.ID, act.Subject, claims.ID, c.Request.Method, c.Request.URL.Path)
+			if !readOnly(c.Request.Method) && !c.GetBool(ctxImpersonationAllowed) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "read-only while impersonating", "code": "IMPERSONATION_READ_ONLY"})
+				return
+			}
+			c.Set(CtxActorID, act.ID)
+		}
+
+		c.Set(CtxUserID, claims.ID)
+		c.Set(CtxRole, claims.Role)
+		c.Set(CtxEmail, claims.Subject)
+		c.Set(CtxPerms, claims.Perms)
+		c.Set(CtxDorms, claims.Dorms)
+		c.Next()
+	}
+}
+
+// RequireService propusta samo masinske tokene (client credentials) sa bar jednim od scope-ova.
+func RequireService(issuer string, keys *JWKS, revoked *Revocations, scopes ...string) gin.HandlerFunc {
+	parser := newParser(issuer)
+
+	return func(c *gin.Context) {
+		claims, ok := parseBearer(c, parser, keys)
+		if !ok {
+			return
+		}
+		if claims.ClientID == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "service token required"})
+			return
+		}
+		var iat int64
+		if claims.IssuedAt != nil {
+			iat = claims.IssuedAt.Unix()
+		}
+		if revoked.IsRevoked(c.Request.Context(), claims.RegisteredClaims.ID, 0, iat) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token revoked"})
+			return
+		}
+		granted := strings.Fields(claims.Scope)
+		for _, want := range scopes {
+			for _, g := range granted {
+				if g == want {
+					c.Set(CtxClientID, claims.ClientID)
+					c.Next()
+					return
+				}
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "insufficient scope"})
+	}
+}
+
+// RequireRoles propusta samo pozivaoce cija je uloga u listi. Ide posle RequireAuth.
+func RequireRoles[R ~string](roles ...R) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !HasRole(c, roles...) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireSelfOrRoles propusta korisnika ciji id odgovara path parametru, ili nekoga sa jednom od uloga.
+func RequireSelfOrRoles[R ~string](param string, roles ...R) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if HasRole(c, roles...) {
+			c.Next()
+			return
+		}
+		uid, ok := UserID(c)
+		if !ok || c.Param(param) != strconv.FormatUint(uint64(uid), 10) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequirePermission propusta pozivaoce koji imaju bar jednu od dozvola. Ide posle RequireAuth.
+func RequirePermission[P ~string](perms ...P) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !HasPermission(c, perms...) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireSelfOrPermission propusta korisnika ciji id odgovara path parametru, ili nekoga sa jednom od dozvola.
+func RequireSelfOrPermission[P ~string](param string, perms ...P) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if HasPermission(c, perms...) {
+			c.Next()
+			return
+		}
+		uid, ok := UserID(c)
+		if !ok || c.Param(param) != strconv.FormatUint(uint64(uid), 10) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
+		}
+		c.Next()
+	}
+}
+
+func HasPermission[P ~string](c *gin.Context, perms ...P) bool {
+	v, _ := c.Get(CtxPerms)
+	have, _ := v.([]string)
+	for _, h := range have {
+		for _, p := range perms {
+			if h == string(p) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// DormScope vraca domove na koje je pozivalac ogranicen; false znaci bez ogranicenja.
+func DormScope(c *gin.Context) ([]string, bool) {
+	v, _ := c.Get(CtxDorms)
+	dorms, _ := v.([]string)
+	return dorms, len(dorms) > 0
+}
+
+func HasRole[R ~string](c *gin.Context, roles ...R) bool {
+	role := Role(c)
+	for _, r := range roles {
+		if role == string(r) {
+			return true
+		}
+	}
+	return false
+}
+
+func UserID(c *gin.Context) (uint, bool) {
+	v, ok := c.Get(CtxUserID)
+	if !ok {
+		return 0, false
+	}
+	id, ok := v.(uint)
+	return id, ok
+}
+
+// ActorID vraca admina iza tokena za impersonaciju; false za obican token.
+func ActorID(c *gin.Context) (uint, bool) {
+	v, ok := c.Get(CtxActorID)
+	if !ok {
+		return 0, false
+	}
+	id, ok := v.(uint)
+	return id, ok
+}
+
+func Role(c *gin.Context) string {
+	v, _ := c.Get(CtxRole)
+	r, _ := v.(string)
+	return r
+}
+
+/* ===================== Helpers ===================== */
+
+func readOnly(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+func newParser(issuer string) *jwt.Parser {
+	return jwt.NewParser(
+		jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(issuer),
+		jwt.WithExpirationRequired(),
+	)
+}
+
+// parseBearer proverava potpis, issuer i exp; na gresku upisuje 401 i vraca false.
+func parseBearer(c *gin.Context, parser *jwt.Parser, keys *JWKS) (Claims, bool) {
+	raw, ok := BearerToken(c)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing bearer token"})
+		return Claims{}, false
+	}
+
+	var claims Claims
+	if _, err := parser.ParseWithClaims(raw, &claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return keys.Key(c.Request.Context(), kid)
+	}); err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return Claims{}, false
+	}
+	return claims, true
+}
+
+// BearerToken vraca token iz Authorization: Bearer zaglavlja.
+func BearerToken(c *gin.Context) (string, bool) {
+	h := c.GetHeader("Authorization")
+	if len(h) < 7 || !strings.EqualFold(h[:7], "Bearer ") {
+		return "", false
+	}
+	tok := strings.TrimSpace(h[7:])
+	return tok, tok != ""
+}
@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// JWKS kesira javne kljuceve auth servisa. Nepoznat kid izaziva ponovno
+// preuzimanje (najvise jednom u minRefresh), da rotacija proradi bez restarta.
+type JWKS struct {
+	url        string
+	httpc      *http.Client
+	ttl        time.Duration
+	minRefresh time.Duration
+
+	mu        sync.RWMutex
+	keys      map[string]ed25519.PublicKey
+	fetchedAt time.Time
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Kid string `json:"kid"`
+}
+
+func NewJWKS(url string, ttl time.Duration) *JWKS {
+	return &JWKS{
+		url:        url,
+		httpc:      &http.Client{Timeout: 3 * time.Second},
+		ttl:        ttl,
+		minRefresh: 30 * time.Second,
+		keys:       map[string]ed25519.PublicKey{},
+	}
+}
+
+// Key vraca javni kljuc za kid, po potrebi osvezavajuci kes.
+func (j *JWKS) Key(ctx context.Context, kid string) (ed25519.PublicKey, error) {
+	j.mu.RLock()
+	k, ok := j.keys[kid]
+	fresh := time.Since(j.fetchedAt) < j.ttl
+	recent := time.Since(j.fetchedAt) < j.minRefresh
+	j.mu.RUnlock()
+
+	if ok && fresh {
+		return k, nil
+	}
+	if !ok && recent {
+		return nil, errors.New("unknown kid")
+	}
+
+	if err := j.refresh(ctx); err != nil {
+		// Ako auth trenutno nije dostupan, i dalje vazi kljuc koji vec imamo
+		if ok {
+			log.Printf("[jwks] refresh failed, using cached key: %v", err)
+			return k, nil
+		}
+		return nil, err
+	}
+
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	if k, ok := j.keys[kid]; ok {
+		return k, nil
+	}
+	return nil, errors.New("unknown kid")
+}
+
+func (j *JWKS) refresh(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return err
+	}
+	res, err := j.httpc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("jwks status %d", res.StatusCode)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&set); err != nil {
+		return err
+	}
+
+	keys := make(map[string]ed25519.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "OKP" || k.Crv != "Ed25519" || k.Kid == "" {
+			continue
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			continue
+		}
+		keys[k.Kid] = ed25519.PublicKey(x)
+	}
+
+	j.mu.Lock()
+	j.keys = keys
+	j.fetchedAt = time.Now()
+	j.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Revocations kesira listu opozvanih tokena iz auth servisa (GET /revocations).
+// Opoziv stize do ovog servisa najkasnije posle ttl.
+type Revocations struct {
+	url   string
+	httpc *http.Client
+	ttl   time.Duration
+
+	mu         sync.RWMutex
+	jtis       map[string]struct{}
+	users      map[uint]int64
+	sessions   map[string]struct{}
+	fetchedAt  time.Time
+	refreshing bool
+}
+
+func NewRevocations(url string, ttl time.Duration) *Revocations {
+	return &Revocations{
+		url:      url,
+		httpc:    &http.Client{Timeout: 3 * time.Second},
+		ttl:      ttl,
+		jtis:     map[string]struct{}{},
+		users:    map[uint]int64{},
+		sessions: map[string]struct{}{},
+	}
+}
+
+// IsRevoked: jti je na listi ili je token izdat pre korisnikovog cutoff-a.
+func (r *Revocations) IsRevoked(ctx context.Context, jti string, userID uint, iat int64) bool {
+	r.refreshIfStale(ctx)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.jtis[jti]; ok && jti != "" {
+		return true
+	}
+	if nb, ok := r.users[userID]; ok && iat <= nb {
+		return true
+	}
+	return false
+}
+
+// IsSessionRevoked: sesija (claim sid) iz koje je token izdat je odjavljena.
+func (r *Revocations) IsSessionRevoked(ctx context.Context, sid string) bool {
+	r.refreshIfStale(ctx)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.sessions[sid]
+	return ok
+}
+
+// refreshIfStale osvezava listu van brave: osvezava samo jedan zahtev, ostali za to
+// vreme rade sa poslednjom listom umesto da cekaju spor auth.
+func (r *Revocations) refreshIfStale(ctx context.Context) {
+	r.mu.Lock()
+	if r.refreshing || time.Since(r.fetchedAt) < r.ttl {
+		r.mu.Unlock()
+		return
+	}
+	r.refreshing = true
+	r.mu.Unlock()
+
+	jtis, users, sessions, err := r.fetch(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.refreshing = false
+	r.fetchedAt = time.Now()
+	if err != nil {
+		// Auth nedostupan: radimo sa poslednjom poznatom listom, probamo ponovo posle ttl
+		log.Printf("[revocations] refresh failed: %v", err)
+		return
+	}
+	r.jtis, r.users, r.sessions = jtis, users, sessions
+}
+
+func (r *Revocations) fetch(ctx context.Context) (map[string]struct{}, map[uint]int64, map[string]struct{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	res, err := r.httpc.Do(req)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, nil, nil, fmt.Errorf("revocations status %d", res.StatusCode)
+	}
+
+	var list struct {
+		JTIs     []string         `json:"jtis"`
+		Users    map[string]int64 `json:"users"`
+		Sessions []string         `json:"sessions"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&list); err != nil {
+		return nil, nil, nil, err
+	}
+
+	jtis := make(map[string]struct{}, len(list.JTIs))
+	for _, j := range list.JTIs {
+		jtis[j] = struct{}{}
+	}
+	users := make(map[uint]int64, len(list.Users))
+	for k, v := range list.Users {
+		id, err := strconv.ParseUint(k, 10, 64)
+		if err != nil {
+			continue
+		}
+		users[uint(id)] = v
+	}
+
+	sessions := make(map[string]struct{}, len(list.Sessions))
+	for _, s := range list.Sessions {
+		sessions[s] = struct{}{}
+	}
+
+	return jtis, users, sessions, nil
+}
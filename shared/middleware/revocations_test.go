@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRevocationsSlowRefreshDoesNotBlockReaders(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) > 1 {
+			<-release
+		}
+		fmt.Fprint(w, `{"jtis":["revoked-jti"],"users":{"7":100},"sessions":["s1"]}`)
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	r := NewRevocations(srv.URL, 20*time.Millisecond)
+	ctx := context.Background()
+	if !r.IsRevoked(ctx, "revoked-jti", 0, 0) {
+		t.Fatal("initial load: jti should be revoked")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	go r.IsRevoked(ctx, "", 0, 0) // pokrece sporo osvezavanje
+	for calls.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	done := make(chan bool)
+	go func() { done <- r.IsRevoked(ctx, "", 7, 50) && r.IsSessionRevoked(ctx, "s1") }()
+	select {
+	case ok := <-done:
+		if !ok {
+			t.Fatal("reader should see the last known list")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("reader blocked on refresh")
+	}
+}
+
+func TestRevocationsKeepsListWhenAuthFails(t *testing.T) {
+	var fail atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"jtis":["j1"]}`)
+	}))
+	defer srv.Close()
+
+	r := NewRevocations(srv.URL, 10*time.Millisecond)
+	ctx := context.Background()
+	if !r.IsRevoked(ctx, "j1", 0, 0) {
+		t.Fatal("j1 should be revoked")
+	}
+	fail.Store(true)
+	time.Sleep(20 * time.Millisecond)
+	if !r.IsRevoked(ctx, "j1", 0, 0) {
+		t.Fatal("failed refresh must keep the last known list")
+	}
+}
@@ -0,0 +1,292 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Runner za verzionisane SQL migracije, zajednicki za sve servise. Svaki servis ugradjuje
+// svoje migrations/NNNN_ime.{up,down}.sql i vodi ih pod svojim imenom u schema_migrations.
+
+// migrationLockKey je zajednicki za sve servise: migracije se nikad ne izvrsavaju
+// paralelno, ni kada vise kontejnera startuje u isto vreme.
+const migrationLockKey int64 = 7_310_420_011
+
+// Runner primenjuje migracije jednog servisa. Files sadrzi direktorijum migrations.
+type Runner struct {
+	Service string
+	Files   fs.FS
+}
+
+func New(service string, files fs.FS) *Runner {
+	return &Runner{Service: service, Files: files}
+}
+
+// Migration je par fajlova migrations/NNNN_ime.up.sql i NNNN_ime.down.sql.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+var migrationName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migrations ucitava ugradjene migracije sortirane po verziji.
+func (r *Runner) Migrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(r.Files, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, e := range entries {
+		m := migrationName.FindStringSubmatch(e.Name())
+		if m == nil {
+			return nil, fmt.Errorf("migrations: unexpected file %q", e.Name())
+		}
+		v, _ := strconv.ParseInt(m[1], 10, 64)
+		body, err := fs.ReadFile(r.Files, path.Join("migrations", e.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[v]
+		if !ok {
+			mig = &Migration{Version: v, Name: m[2]}
+			byVersion[v] = mig
+		} else if mig.Name != m[2] {
+			return nil, fmt.Errorf("migrations: version %d used by %q and %q", v, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(body)
+		} else {
+			mig.Down = string(body)
+		}
+	}
+
+	out := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migrations: %04d_%s needs both up and down files", m.Version, m.Name)
+		}
+		out = append(out, *m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+// Up primenjuje sve neprimenjene migracije, svaku u svojoj transakciji.
+func (r *Runner) Up(ctx context.Context, db *gorm.DB) (int, error) {
+	n := 0
+	err := withMigrationLock(ctx, db, func(conn *sql.Conn) error {
+		all, applied, err := r.loadState(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, m := range all {
+			if _, ok := applied[m.Version]; ok {
+				continue
+			}
+			if err := r.apply(ctx, conn, m, true); err != nil {
+				return err
+			}
+			log.Printf("[migrate] %s: applied %04d_%s", r.Service, m.Version, m.Name)
+			n++
+		}
+		return nil
+	})
+	return n, err
+}
+
+// Down vraca poslednjih steps primenjenih migracija.
+func (r *Runner) Down(ctx context.Context, db *gorm.DB, steps int) (int, error) {
+	n := 0
+	err := withMigrationLock(ctx, db, func(conn *sql.Conn) error {
+		all, applied, err := r.loadState(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(all) - 1; i >= 0 && n < steps; i-- {
+			m := all[i]
+			if _, ok := applied[m.Version]; !ok {
+				continue
+			}
+			if err := r.apply(ctx, conn, m, false); err != nil {
+				return err
+			}
+			log.Printf("[migrate] %s: reverted %04d_%s", r.Service, m.Version, m.Name)
+			n++
+		}
+		return nil
+	})
+	return n, err
+}
+
+func (r *Runner) Statuses(ctx context.Context, db *gorm.DB) ([]MigrationStatus, error) {
+	var out []MigrationStatus
+	err := withMigrationLock(ctx, db, func(conn *sql.Conn) error {
+		all, applied, err := r.loadState(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, m := range all {
+			st := MigrationStatus{Migration: m}
+			if at, ok := applied[m.Version]; ok {
+				st.AppliedAt = &at
+			}
+			out = append(out, st)
+		}
+		return nil
+	})
+	return out, err
+}
+
+// RunCommand obradjuje `server migrate [up|down [N]|status]`.
+func (r *Runner) RunCommand(ctx context.Context, db *gorm.DB, args []string) error {
+	cmd := "up"
+	if len(args) > 0 {
+		cmd = args[0]
+	}
+
+	switch cmd {
+	case "up":
+		n, err := r.Up(ctx, db)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s: %d migration(s) applied\n", r.Service, n)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			v, err := strconv.Atoi(args[1])
+			if err != nil || v < 1 {
+				return fmt.Errorf("migrate down: invalid step count %q", args[1])
+			}
+			steps = v
+		}
+		n, err := r.Down(ctx, db, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s: %d migration(s) reverted\n", r.Service, n)
+	case "status":
+		list, err := r.Statuses(ctx, db)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range list {
+			at := "pending"
+			if s.AppliedAt != nil {
+				at = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, at)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("usage: migrate [up | down [N] | status]")
+	}
+	return nil
+}
+
+/* ===================== Helpers ===================== */
+
+// withMigrationLock drzi jednu konekciju iz pool-a dok traje rad, jer je
+// pg_advisory_lock vezan za sesiju.
+func withMigrationLock(ctx context.Context, db *gorm.DB, fn func(conn *sql.Conn) error) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+		return fmt.Errorf("migrate: acquire lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockKey)
+
+	if _, err := conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			service    varchar(50) NOT NULL,
+			version    bigint      NOT NULL,
+			name       text        NOT NULL,
+			applied_at timestamptz NOT NULL DEFAULT now(),
+			PRIMARY KEY (service, version)
+		)`); err != nil {
+		return fmt.Errorf("migrate: create schema_migrations: %w", err)
+	}
+	return fn(conn)
+}
+
+func (r *Runner) loadState(ctx context.Context, conn *sql.Conn) ([]Migration, map[int64]time.Time, error) {
+	all, err := r.Migrations()
+	if err != nil {
+		return nil, nil, err
+	}
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations WHERE service = $1", r.Service)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int64]time.Time{}
+	for rows.Next() {
+		var v int64
+		var at time.Time
+		if err := rows.Scan(&v, &at); err != nil {
+			return nil, nil, err
+		}
+		applied[v] = at
+	}
+	return all, applied, rows.Err()
+}
+
+func (r *Runner) apply(ctx context.Context, conn *sql.Conn, m Migration, up bool) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	body := m.Down
+	if up {
+		body = m.Up
+	}
+	// Bez argumenata pgx salje upit kao simple query, pa fajl moze imati vise naredbi
+	if _, err := tx.ExecContext(ctx, body); err != nil {
+		return fmt.Errorf("migrate: %04d_%s: %w", m.Version, m.Name, err)
+	}
+
+	if up {
+		_, err = tx.ExecContext(ctx, "INSERT INTO schema_migrations (service, version, name) VALUES ($1, $2, $3)", r.Service, m.Version, m.Name)
+	} else {
+		_, err = tx.ExecContext(ctx, "DELETE FROM schema_migrations WHERE service = $1 AND version = $2", r.Service, m.Version)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
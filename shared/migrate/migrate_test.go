@@ -0,0 +1,46 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestMigrationsSortedAndPaired(t *testing.T) {
+	r := New("test", fstest.MapFS{
+		"migrations/0002_second.up.sql":   {Data: []byte("CREATE TABLE b ();")},
+		"migrations/0002_second.down.sql": {Data: []byte("DROP TABLE b;")},
+		"migrations/0001_first.up.sql":    {Data: []byte("CREATE TABLE a ();")},
+		"migrations/0001_first.down.sql":  {Data: []byte("DROP TABLE a;")},
+	})
+	list, err := r.Migrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].Version != 1 || list[1].Version != 2 {
+		t.Fatalf("unexpected order: %+v", list)
+	}
+	if list[0].Name != "first" || list[0].Down != "DROP TABLE a;" {
+		t.Fatalf("unexpected migration: %+v", list[0])
+	}
+}
+
+func TestMigrationsRejectsBadSets(t *testing.T) {
+	cases := map[string]fstest.MapFS{
+		"missing down": {
+			"migrations/0001_first.up.sql": {Data: []byte("SELECT 1;")},
+		},
+		"name clash": {
+			"migrations/0001_a.up.sql":   {Data: []byte("SELECT 1;")},
+			"migrations/0001_b.down.sql": {Data: []byte("SELECT 1;")},
+		},
+		"unexpected file": {
+			"migrations/README.md": {Data: []byte("x")},
+		},
+	}
+	for name, files := range cases {
+		if _, err := New("test", files).Migrations(); err == nil || !strings.HasPrefix(err.Error(), "migrations:") {
+			t.Errorf("%s: expected migrations error, got %v", name, err)
+		}
+	}
+}
@@ -14,6 +14,11 @@ type Config struct {
 	DBUser      string
 	DBPass      string
 	DBName      string
+	// MIGRATE_ON_START=false kada se migracije pokrecu posebno (`server migrate up`)
+	MigrateOnStart bool
+	JWTIssuer      string
+	JWKSURL        string
+	AuthBaseURL    string
 }
 
 func GetConfig() Config {
@@ -23,11 +28,15 @@ func GetConfig() Config {
 	}
 
 	return Config{
-		DBHost:      os.Getenv("DB_HOST"),
-		DBUser:      os.Getenv("DB_USER"),
-		DBPass:      os.Getenv("DB_PASS"),
-		DBName:      os.Getenv("DB_NAME"),
-		ServiceHost: os.Getenv("SERVICE_HOST"),
-		ServicePort: port,
+		DBHost:         os.Getenv("DB_HOST"),
+		DBUser:         os.Getenv("DB_USER"),
+		DBPass:         os.Getenv("DB_PASS"),
+		DBName:         os.Getenv("DB_NAME"),
+		MigrateOnStart: os.Getenv("MIGRATE_ON_START") != "false",
+		ServiceHost:    os.Getenv("SERVICE_HOST"),
+		ServicePort:    port,
+		JWTIssuer:      os.Getenv("ISSUER"),
+		JWKSURL:        os.Getenv("AUTH_JWKS_URL"),
+		AuthBaseURL:    os.Getenv("AUTH_BASE_URL"),
 	}
 }
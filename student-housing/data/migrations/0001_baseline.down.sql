@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS payments;
+DROP TABLE IF EXISTS applications;
+DROP TABLE IF EXISTS rooms;
+DROP TABLE IF EXISTS dorms;
@@ -0,0 +1,37 @@
+-- Pocetna sema student-housing servisa. IF NOT EXISTS da bi baza koju je ranije
+-- napravio GORM AutoMigrate mogla da se preuzme bez gubitka podataka.
+
+CREATE TABLE IF NOT EXISTS dorms (
+    id      uuid PRIMARY KEY,
+    name    text NOT NULL,
+    address text NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS rooms (
+    id        uuid PRIMARY KEY,
+    number    text    NOT NULL,
+    capacity  bigint  NOT NULL,
+    available boolean DEFAULT true,
+    dorm_id   uuid    NOT NULL,
+    CONSTRAINT fk_dorms_rooms FOREIGN KEY (dorm_id) REFERENCES dorms (id)
+);
+
+-- student_id je id naloga iz auth servisa
+CREATE TABLE IF NOT EXISTS applications (
+    id         uuid PRIMARY KEY,
+    created_at timestamptz,
+    points     bigint,
+    status     varchar(20) NOT NULL,
+    student_id bigint      NOT NULL,
+    room_id    uuid,
+    CONSTRAINT fk_rooms_applications FOREIGN KEY (room_id) REFERENCES rooms (id)
+);
+
+CREATE TABLE IF NOT EXISTS payments (
+    id             uuid PRIMARY KEY,
+    reference      text    NOT NULL,
+    amount         decimal NOT NULL,
+    issued_at      timestamptz,
+    application_id uuid    NOT NULL UNIQUE,
+    CONSTRAINT fk_applications_payment FOREIGN KEY (application_id) REFERENCES applications (id)
+);
@@ -0,0 +1,28 @@
+-- Studentski profil vezan za nalog iz auth-a; identitet (email, ime, uloga, status)
+-- je kopija koju odrzava student.SyncProfiles.
+
+CREATE TABLE IF NOT EXISTS user_profiles (
+    user_id    bigint PRIMARY KEY,
+    email      text        NOT NULL,
+    first_name text,
+    last_name  text,
+    role       varchar(50) NOT NULL,
+    status     varchar(30),
+    "index"    text,
+    faculty    text,
+    synced_at  timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_user_profiles_role ON user_profiles (role);
+
+-- Indeks i fakultet su ranije bili kolone u users; prenose se samo ako postoje
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_schema = current_schema() AND table_name = 'users' AND column_name = 'faculty'
+    ) THEN
+        INSERT INTO user_profiles (user_id, email, first_name, last_name, role, "index", faculty, synced_at)
+        SELECT id, email, first_name, last_name, role, "index", faculty, now() FROM users
+        ON CONFLICT (user_id) DO NOTHING;
+    END IF;
+END $$;
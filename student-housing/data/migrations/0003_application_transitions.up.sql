@@ -0,0 +1,18 @@
+-- Istorija tranzicija prijava (samo dodavanje); status prijave se menja iskljucivo kroz tranzicije
+
+CREATE TABLE IF NOT EXISTS application_transitions (
+    id             uuid PRIMARY KEY,
+    application_id uuid        NOT NULL REFERENCES applications (id) ON DELETE CASCADE,
+    transition     varchar(20) NOT NULL,
+    from_status    varchar(20) NOT NULL,
+    to_status      varchar(20) NOT NULL,
+    actor_id       bigint      NOT NULL,
+    reason         text        NOT NULL DEFAULT '',
+    created_at     timestamptz NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_application_transitions_application_id ON application_transitions (application_id);
+
+-- Postojece prijave dobijaju jedan red sa trenutnim statusom; izvrsilac nije poznat (0)
+INSERT INTO application_transitions (id, application_id, transition, from_status, to_status, actor_id, reason, created_at)
+SELECT gen_random_uuid(), id, 'create', '', status, 0, 'backfill', COALESCE(created_at, now())
+FROM applications;
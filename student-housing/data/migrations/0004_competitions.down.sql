@@ -0,0 +1,5 @@
+ALTER TABLE user_profiles DROP COLUMN IF EXISTS year_of_study;
+DROP INDEX IF EXISTS idx_applications_competition_student;
+ALTER TABLE applications DROP COLUMN IF EXISTS competition_id;
+DROP TABLE IF EXISTS competition_dorms;
+DROP TABLE IF EXISTS competitions;
@@ -0,0 +1,32 @@
+-- Konkursi za smestaj (akademska godina, rok za prijave, uslovi, ponudjeni domovi i kvote)
+
+CREATE TABLE IF NOT EXISTS competitions (
+    id             uuid PRIMARY KEY,
+    name           text        NOT NULL,
+    academic_year  varchar(9)  NOT NULL,
+    opens_at       timestamptz NOT NULL,
+    closes_at      timestamptz NOT NULL,
+    faculties      jsonb       NOT NULL DEFAULT '[]',
+    years_of_study jsonb       NOT NULL DEFAULT '[]',
+    created_at     timestamptz,
+    updated_at     timestamptz,
+    CONSTRAINT chk_competitions_window CHECK (closes_at > opens_at)
+);
+CREATE INDEX IF NOT EXISTS idx_competitions_academic_year ON competitions (academic_year);
+
+CREATE TABLE IF NOT EXISTS competition_dorms (
+    competition_id uuid   NOT NULL REFERENCES competitions (id) ON DELETE CASCADE,
+    dorm_id        uuid   NOT NULL REFERENCES dorms (id),
+    quota          bigint NOT NULL CHECK (quota > 0),
+    PRIMARY KEY (competition_id, dorm_id)
+);
+
+-- Postojece prijave ostaju bez konkursa
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS competition_id uuid REFERENCES competitions (id);
+CREATE INDEX IF NOT EXISTS idx_applications_competition_id ON applications (competition_id);
+-- Jedna prijava po studentu i konkursu; povucena prijava ne smeta novoj
+CREATE UNIQUE INDEX IF NOT EXISTS idx_applications_competition_student
+    ON applications (competition_id, student_id)
+    WHERE competition_id IS NOT NULL AND status <> 'WITHDRAWN';
+
+ALTER TABLE user_profiles ADD COLUMN IF NOT EXISTS year_of_study bigint NOT NULL DEFAULT 0;
@@ -0,0 +1,6 @@
+ALTER TABLE applications DROP COLUMN IF EXISTS score_breakdown;
+ALTER TABLE applications DROP COLUMN IF EXISTS evidence;
+ALTER TABLE applications ALTER COLUMN points DROP NOT NULL;
+ALTER TABLE applications ALTER COLUMN points DROP DEFAULT;
+ALTER TABLE applications ALTER COLUMN points TYPE bigint USING round(points);
+ALTER TABLE competitions DROP COLUMN IF EXISTS criteria;
@@ -0,0 +1,12 @@
+-- Bodovanje prijava: kriterijumi po konkursu, podaci koje prilaze student i obracun po kriterijumima
+
+ALTER TABLE competitions ADD COLUMN IF NOT EXISTS criteria jsonb NOT NULL DEFAULT '[]';
+
+-- Bodovi se racunaju na dve decimale; postojeci rucno uneti bodovi ostaju kakvi jesu
+ALTER TABLE applications ALTER COLUMN points TYPE numeric(8,2) USING points;
+UPDATE applications SET points = 0 WHERE points IS NULL;
+ALTER TABLE applications ALTER COLUMN points SET DEFAULT 0;
+ALTER TABLE applications ALTER COLUMN points SET NOT NULL;
+
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS evidence jsonb;
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS score_breakdown jsonb;
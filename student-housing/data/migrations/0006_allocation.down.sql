@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS allocation_results;
+DROP TABLE IF EXISTS allocation_runs;
+ALTER TABLE competitions DROP COLUMN IF EXISTS faculty_quotas;
+ALTER TABLE applications DROP COLUMN IF EXISTS dorm_preferences;
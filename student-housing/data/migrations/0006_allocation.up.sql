@@ -0,0 +1,29 @@
+-- Rangiranje i raspodela mesta: zeljeni domovi, kvote po fakultetu i istorija pokretanja
+
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS dorm_preferences jsonb NOT NULL DEFAULT '[]';
+ALTER TABLE competitions ADD COLUMN IF NOT EXISTS faculty_quotas jsonb NOT NULL DEFAULT '{}';
+
+CREATE TABLE IF NOT EXISTS allocation_runs (
+    id             uuid PRIMARY KEY,
+    competition_id uuid        NOT NULL REFERENCES competitions (id) ON DELETE CASCADE,
+    accepted       bigint      NOT NULL,
+    waitlisted     bigint      NOT NULL,
+    rejected       bigint      NOT NULL,
+    created_by     bigint      NOT NULL,
+    created_at     timestamptz NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_allocation_runs_competition_id ON allocation_runs (competition_id);
+
+CREATE TABLE IF NOT EXISTS allocation_results (
+    run_id         uuid          NOT NULL REFERENCES allocation_runs (id) ON DELETE CASCADE,
+    application_id uuid          NOT NULL REFERENCES applications (id) ON DELETE CASCADE,
+    rank           bigint        NOT NULL,
+    student_id     bigint        NOT NULL,
+    faculty        text          NOT NULL,
+    points         numeric(8, 2) NOT NULL,
+    outcome        varchar(20)   NOT NULL,
+    dorm_id        uuid REFERENCES dorms (id),
+    room_id        uuid REFERENCES rooms (id),
+    reason         text          NOT NULL DEFAULT '',
+    PRIMARY KEY (run_id, application_id)
+);
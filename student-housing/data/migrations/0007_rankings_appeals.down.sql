@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS appeal_attachments;
+DROP TABLE IF EXISTS appeals;
+DROP TABLE IF EXISTS ranking_rows;
+DROP TABLE IF EXISTS ranking_lists;
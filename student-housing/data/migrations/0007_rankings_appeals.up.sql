@@ -0,0 +1,53 @@
+-- Objavljene rang liste (privremena i konacna, verzionisane) i zalbe na privremenu listu
+
+CREATE TABLE IF NOT EXISTS ranking_lists (
+    id               uuid PRIMARY KEY,
+    competition_id   uuid        NOT NULL REFERENCES competitions (id) ON DELETE CASCADE,
+    version          bigint      NOT NULL,
+    kind             varchar(20) NOT NULL,
+    run_id           uuid        NOT NULL REFERENCES allocation_runs (id),
+    appeals_close_at timestamptz,
+    published_by     bigint      NOT NULL,
+    published_at     timestamptz NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ranking_lists_version ON ranking_lists (competition_id, version);
+
+-- Redovi se posle objave ne menjaju
+CREATE TABLE IF NOT EXISTS ranking_rows (
+    list_id        uuid          NOT NULL REFERENCES ranking_lists (id) ON DELETE CASCADE,
+    rank           bigint        NOT NULL,
+    "index"        text          NOT NULL,
+    points         numeric(8, 2) NOT NULL,
+    application_id uuid          NOT NULL REFERENCES applications (id) ON DELETE CASCADE,
+    PRIMARY KEY (list_id, rank)
+);
+
+CREATE TABLE IF NOT EXISTS appeals (
+    id             uuid PRIMARY KEY,
+    list_id        uuid        NOT NULL REFERENCES ranking_lists (id) ON DELETE CASCADE,
+    competition_id uuid        NOT NULL REFERENCES competitions (id) ON DELETE CASCADE,
+    application_id uuid        NOT NULL REFERENCES applications (id) ON DELETE CASCADE,
+    student_id     bigint      NOT NULL,
+    text           text        NOT NULL,
+    status         varchar(20) NOT NULL,
+    response       text        NOT NULL DEFAULT '',
+    reviewed_by    bigint,
+    reviewed_at    timestamptz,
+    created_at     timestamptz NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_appeals_list_id ON appeals (list_id);
+CREATE INDEX IF NOT EXISTS idx_appeals_competition_id ON appeals (competition_id);
+CREATE INDEX IF NOT EXISTS idx_appeals_student_id ON appeals (student_id);
+-- Jedna zalba po studentu na istu listu
+CREATE UNIQUE INDEX IF NOT EXISTS idx_appeals_list_student ON appeals (list_id, student_id);
+
+CREATE TABLE IF NOT EXISTS appeal_attachments (
+    id           uuid PRIMARY KEY,
+    appeal_id    uuid        NOT NULL REFERENCES appeals (id) ON DELETE CASCADE,
+    file_name    text        NOT NULL,
+    content_type text        NOT NULL,
+    size         bigint      NOT NULL,
+    data         bytea       NOT NULL,
+    created_at   timestamptz NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_appeal_attachments_appeal_id ON appeal_attachments (appeal_id);
@@ -0,0 +1,15 @@
+ALTER TABLE application_transitions
+    DROP CONSTRAINT IF EXISTS application_transitions_application_id_fkey,
+    ADD CONSTRAINT application_transitions_application_id_fkey
+        FOREIGN KEY (application_id) REFERENCES applications (id) ON DELETE CASCADE;
+
+-- Bez kolone deleted_at obrisane prijave bi ponovo postale vidljive
+DELETE FROM applications WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_applications_competition_student;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_applications_competition_student
+    ON applications (competition_id, student_id)
+    WHERE competition_id IS NOT NULL AND status <> 'WITHDRAWN';
+
+DROP INDEX IF EXISTS idx_applications_deleted_at;
+ALTER TABLE applications DROP COLUMN IF EXISTS deleted_at;
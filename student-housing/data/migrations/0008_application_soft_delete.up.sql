@@ -0,0 +1,15 @@
+-- Brisanje prijave je logicko (deleted_at), da istorija tranzicija ostane sacuvana
+
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_applications_deleted_at ON applications (deleted_at);
+
+-- Obrisana prijava, kao i povucena, ne smeta novoj na istom konkursu
+DROP INDEX IF EXISTS idx_applications_competition_student;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_applications_competition_student
+    ON applications (competition_id, student_id)
+    WHERE competition_id IS NOT NULL AND status <> 'WITHDRAWN' AND deleted_at IS NULL;
+
+ALTER TABLE application_transitions
+    DROP CONSTRAINT IF EXISTS application_transitions_application_id_fkey,
+    ADD CONSTRAINT application_transitions_application_id_fkey
+        FOREIGN KEY (application_id) REFERENCES applications (id) ON DELETE RESTRICT;
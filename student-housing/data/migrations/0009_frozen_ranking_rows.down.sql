@@ -0,0 +1,4 @@
+ALTER TABLE ranking_rows
+    DROP CONSTRAINT IF EXISTS ranking_rows_application_id_fkey,
+    ADD CONSTRAINT ranking_rows_application_id_fkey
+        FOREIGN KEY (application_id) REFERENCES applications (id) ON DELETE CASCADE;
@@ -0,0 +1,6 @@
+-- Objavljena lista se ne menja: prijava sa liste ne sme da povuce svoje redove pri brisanju
+
+ALTER TABLE ranking_rows
+    DROP CONSTRAINT IF EXISTS ranking_rows_application_id_fkey,
+    ADD CONSTRAINT ranking_rows_application_id_fkey
+        FOREIGN KEY (application_id) REFERENCES applications (id) ON DELETE RESTRICT;
@@ -1,14 +1,20 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"shared/middleware"
 	"student-housting/config"
 	"student-housting/data"
 	"student-housting/student"
+	"student-housting/types"
+	"student-housting/upstream"
 )
 
 func main() {
@@ -19,8 +25,18 @@ func main() {
 	if err != nil {
 		panic(fmt.Sprintf("Failed to connect to database: %v", err))
 	}
-	if err = data.AutoMigrate(db); err != nil {
-		panic(err)
+
+	// `server migrate [up|down N|status]` radi samo migracije i izlazi
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := data.RunMigrateCommand(context.Background(), db, os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if cfg.MigrateOnStart {
+		if _, err := data.MigrateUp(context.Background(), db); err != nil {
+			panic(err)
+		}
 	}
 
 	// UBACI JEDNOG STUDENTA SVAKI PUT
@@ -44,12 +60,26 @@ func main() {
 
 	r.GET("/healthz", func(c *gin.Context) { c.String(200, "ok") })
 
+	// JWT iz auth servisa (javni kljucevi preko JWKS); uloge se proveravaju po rutama
+	jwks := middleware.NewJWKS(cfg.JWKSURL, 10*time.Minute)
+	revoked := middleware.NewRevocations(cfg.AuthBaseURL+"/revocations", 30*time.Second)
+	auth := middleware.RequireAuth(cfg.JWTIssuer, jwks, revoked)
+	statsReader := middleware.RequireService(cfg.JWTIssuer, jwks, revoked, types.ScopeStatsRead)
+	authClient := upstream.NewAuthClient(cfg.AuthBaseURL, 3*time.Second)
+
+	// Nalozi zive u auth-u; ovde se drzi kopija identiteta uz studentski profil
+	go student.SyncProfiles(context.Background(), db, authClient, 30*time.Second)
+
 	api := r.Group("/api")
-	student.WithStudentAPI(api, db)
-	student.WithDormAPI(api, db)
-	student.WithRoomAPI(api, db)
-	student.WithApplicationAPI(api, db)
-	student.WithPaymentAPI(api, db)
+	student.WithStudentAPI(api, db, auth, authClient)
+	student.WithDormAPI(api, db, auth)
+	student.WithRoomAPI(api, db, auth)
+	student.WithCompetitionAPI(api, db, auth)
+	student.WithApplicationAPI(api, db, auth, authClient)
+	student.WithRankingAPI(api, db, auth)
+	student.WithAppealAPI(api, db, auth)
+	student.WithPaymentAPI(api, db, auth)
+	student.WithStatsAPI(api, db, statsReader)
 
 	addr := fmt.Sprintf("%s:%d", cfg.ServiceHost, cfg.ServicePort)
 	if err := r.Run(addr); err != nil {
@@ -0,0 +1,201 @@
+// Package scoring racuna bodove prijave za smestaj po kriterijumima konkursa.
+// Svaki kriterijum nosi najvise Weight bodova; ulaz se normalizuje na [0, 1]
+// i mnozi tezinom, pa je zbir tezina ujedno maksimalan broj bodova.
+package scoring
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+type Kind string
+
+const (
+	GPA         Kind = "GPA"           // prosek ocena (6-10)
+	YearOfStudy Kind = "YEAR_OF_STUDY" // godina studija iz profila
+	// ECTS je odnos ostvarenih i ocekivanih ESPB (60 po zavrsenoj godini); prva godina dobija 1
+	ECTS            Kind = "ECTS"
+	IncomePerMember Kind = "INCOME_PER_MEMBER" // mesecni prihod domacinstva po clanu
+	Distance        Kind = "DISTANCE"          // udaljenost mesta prebivalista u km
+	SocialCategory  Kind = "SOCIAL_CATEGORY"   // zbir bodova za socijalne kategorije, najvise Weight
+)
+
+var kinds = map[Kind]bool{GPA: true, YearOfStudy: true, ECTS: true, IncomePerMember: true, Distance: true, SocialCategory: true}
+
+// Criterion je jedan kriterijum konkursa. Min/Max su granice linearne skale (vrednosti van
+// granica se odsecaju); Invert znaci da je manja vrednost bolja (npr. prihod).
+type Criterion struct {
+	Kind       Kind               `json:"kind"`
+	Weight     float64            `json:"weight"`
+	Min        float64            `json:"min,omitempty"`
+	Max        float64            `json:"max,omitempty"`
+	Invert     bool               `json:"invert,omitempty"`
+	Categories map[string]float64 `json:"categories,omitempty"`
+}
+
+// Evidence su podaci koje student prilaze uz prijavu.
+type Evidence struct {
+	GPA              float64  `json:"gpa"`
+	ECTSEarned       int      `json:"ectsEarned"`
+	HouseholdIncome  float64  `json:"householdIncome"` // ukupan mesecni prihod domacinstva, RSD
+	HouseholdMembers int      `json:"householdMembers"`
+	DistanceKm       float64  `json:"distanceKm"`
+	SocialCategories []string `json:"socialCategories"`
+}
+
+type Input struct {
+	Evidence
+	YearOfStudy int
+}
+
+// Item je doprinos jednog kriterijuma; Value je vrednost pre normalizacije.
+type Item struct {
+	Kind       Kind    `json:"kind"`
+	Value      float64 `json:"value"`
+	Normalized float64 `json:"normalized"`
+	Weight     float64 `json:"weight"`
+	Points     float64 `json:"points"`
+	Note       string  `json:"note,omitempty"`
+}
+
+// Breakdown se cuva uz prijavu zajedno sa ulazom i kriterijumima, da bi se racun mogao ponoviti.
+type Breakdown struct {
+	Items      []Item      `json:"items"`
+	Total      float64     `json:"total"`
+	Input      Input       `json:"input"`
+	Criteria   []Criterion `json:"criteria"`
+	ComputedAt time.Time   `json:"computedAt"`
+}
+
+// Default se koristi za konkurs bez sopstvenih kriterijuma (ukupno 100 bodova).
+func Default() []Criterion {
+	return []Criterion{
+		{Kind: GPA, Weight: 40, Min: 6, Max: 10},
+		{Kind: ECTS, Weight: 20, Min: 0, Max: 1},
+		{Kind: YearOfStudy, Weight: 5, Min: 1, Max: 6},
+		{Kind: IncomePerMember, Weight: 20, Min: 0, Max: 80000, Invert: true},
+		{Kind: Distance, Weight: 10, Min: 0, Max: 300},
+		{Kind: SocialCategory, Weight: 5, Categories: map[string]float64{
+			"DISABILITY": 5, "NO_PARENTS": 5, "SINGLE_PARENT": 3, "ROMA": 3, "SIBLING_STUDENT": 2,
+		}},
+	}
+}
+
+// Validate proverava kriterijume konkursa.
+func Validate(criteria []Criterion) error {
+	seen := map[Kind]bool{}
+	for _, c := range criteria {
+		if !kinds[c.Kind] {
+			return fmt.Errorf("unknown criterion %q", c.Kind)
+		}
+		if seen[c.Kind] {
+			return fmt.Errorf("duplicate criterion %s", c.Kind)
+		}
+		seen[c.Kind] = true
+		if c.Weight <= 0 {
+			return fmt.Errorf("criterion %s needs a positive weight", c.Kind)
+		}
+		if c.Kind == SocialCategory {
+			if len(c.Categories) == 0 {
+				return fmt.Errorf("criterion %s needs categories", c.Kind)
+			}
+			continue
+		}
+		if c.Max <= c.Min {
+			return fmt.Errorf("criterion %s needs max greater than min", c.Kind)
+		}
+	}
+	return nil
+}
+
+// ValidateEvidence odbacuje nemoguce vrednosti i kategorije koje konkurs ne boduje.
+func ValidateEvidence(criteria []Criterion, ev Evidence) error {
+	switch {
+	case ev.GPA != 0 && (ev.GPA < 6 || ev.GPA > 10):
+		return fmt.Errorf("gpa must be between 6 and 10")
+	case ev.ECTSEarned < 0 || ev.HouseholdIncome < 0 || ev.HouseholdMembers < 0 || ev.DistanceKm < 0:
+		return fmt.Errorf("values cannot be negative")
+	case ev.HouseholdIncome > 0 && ev.HouseholdMembers == 0:
+		return fmt.Errorf("householdMembers is required with householdIncome")
+	}
+	known := map[string]bool{}
+	for _, c := range criteria {
+		for k := range c.Categories {
+			known[k] = true
+		}
+	}
+	for _, sc := range ev.SocialCategories {
+		if !known[sc] {
+			return fmt.Errorf("unknown social category %q", sc)
+		}
+	}
+	return nil
+}
+
+// Score racuna bodove; ukupan zbir i svaka stavka su zaokruzeni na dve decimale.
+func Score(criteria []Criterion, in Input, now time.Time) Breakdown {
+	b := Breakdown{Items: make([]Item, 0, len(criteria)), Input: in, Criteria: criteria, ComputedAt: now.UTC()}
+	for _, c := range criteria {
+		it := Item{Kind: c.Kind, Weight: c.Weight}
+		switch c.Kind {
+		case GPA:
+			it.Value = in.GPA
+			if in.GPA == 0 {
+				it.Note = "not provided"
+				break
+			}
+			it.Normalized = linear(c, in.GPA)
+		case YearOfStudy:
+			it.Value = float64(in.YearOfStudy)
+			it.Normalized = linear(c, it.Value)
+		case ECTS:
+			it.Value = 1
+			if in.YearOfStudy > 1 {
+				it.Value = float64(in.ECTSEarned) / float64(60*(in.YearOfStudy-1))
+			} else {
+				it.Note = "first year"
+			}
+			it.Normalized = linear(c, it.Value)
+		case IncomePerMember:
+			if in.HouseholdMembers == 0 {
+				it.Note = "not provided"
+				break
+			}
+			it.Value = in.HouseholdIncome / float64(in.HouseholdMembers)
+			it.Normalized = linear(c, it.Value)
+		case Distance:
+			it.Value = in.DistanceKm
+			it.Normalized = linear(c, it.Value)
+		case SocialCategory:
+			// Ista kategorija navedena vise puta se boduje jednom
+			seen := map[string]bool{}
+			for _, sc := range in.SocialCategories {
+				if !seen[sc] {
+					seen[sc] = true
+					it.Value += c.Categories[sc]
+				}
+			}
+			it.Normalized = math.Min(it.Value/c.Weight, 1)
+		}
+		it.Normalized = round2(it.Normalized)
+		it.Points = round2(c.Weight * it.Normalized)
+		b.Total += it.Points
+		b.Items = append(b.Items, it)
+	}
+	b.Total = round2(b.Total)
+	return b
+}
+
+func linear(c Criterion, v float64) float64 {
+	n := (v - c.Min) / (c.Max - c.Min)
+	n = math.Max(0, math.Min(1, n))
+	if c.Invert {
+		n = 1 - n
+	}
+	return n
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,128 @@
+package scoring
+
+import (
+	"testing"
+	"time"
+)
+
+var now = time.Date(2026, 9, 1, 12, 0, 0, 0, time.UTC)
+
+func item(t *testing.T, b Breakdown, k Kind) Item {
+	t.Helper()
+	for _, it := range b.Items {
+		if it.Kind == k {
+			return it
+		}
+	}
+	t.Fatalf("no item %s in breakdown", k)
+	return Item{}
+}
+
+func TestScoreCriteria(t *testing.T) {
+	tests := []struct {
+		name   string
+		c      Criterion
+		in     Input
+		points float64
+		note   string
+	}{
+		{"gpa linear", Criterion{Kind: GPA, Weight: 40, Min: 6, Max: 10}, Input{Evidence: Evidence{GPA: 8}}, 20, ""},
+		{"gpa missing", Criterion{Kind: GPA, Weight: 40, Min: 6, Max: 10}, Input{}, 0, "not provided"},
+		{"clip above max", Criterion{Kind: Distance, Weight: 10, Min: 0, Max: 300}, Input{Evidence: Evidence{DistanceKm: 900}}, 10, ""},
+		{"clip below min", Criterion{Kind: YearOfStudy, Weight: 5, Min: 1, Max: 6}, Input{YearOfStudy: 0}, 0, ""},
+		{"invert low income is best", Criterion{Kind: IncomePerMember, Weight: 20, Min: 0, Max: 80000, Invert: true},
+			Input{Evidence: Evidence{HouseholdIncome: 80000, HouseholdMembers: 4}}, 15, ""},
+		{"invert clipped above max", Criterion{Kind: IncomePerMember, Weight: 20, Min: 0, Max: 80000, Invert: true},
+			Input{Evidence: Evidence{HouseholdIncome: 500000, HouseholdMembers: 2}}, 0, ""},
+		{"income missing members", Criterion{Kind: IncomePerMember, Weight: 20, Min: 0, Max: 80000, Invert: true},
+			Input{Evidence: Evidence{HouseholdIncome: 0, HouseholdMembers: 0}}, 0, "not provided"},
+		{"ects first year gets full", Criterion{Kind: ECTS, Weight: 20, Min: 0, Max: 1},
+			Input{Evidence: Evidence{ECTSEarned: 0}, YearOfStudy: 1}, 20, "first year"},
+		{"ects ratio", Criterion{Kind: ECTS, Weight: 20, Min: 0, Max: 1},
+			Input{Evidence: Evidence{ECTSEarned: 90}, YearOfStudy: 3}, 15, ""},
+		{"ects above expected is clipped", Criterion{Kind: ECTS, Weight: 20, Min: 0, Max: 1},
+			Input{Evidence: Evidence{ECTSEarned: 150}, YearOfStudy: 2}, 20, ""},
+		{"social categories summed", Criterion{Kind: SocialCategory, Weight: 10, Categories: map[string]float64{"A": 3, "B": 4}},
+			Input{Evidence: Evidence{SocialCategories: []string{"A", "B"}}}, 7, ""},
+		{"social categories capped at weight", Criterion{Kind: SocialCategory, Weight: 5, Categories: map[string]float64{"A": 5, "B": 3}},
+			Input{Evidence: Evidence{SocialCategories: []string{"A", "B"}}}, 5, ""},
+		{"social category duplicates count once", Criterion{Kind: SocialCategory, Weight: 10, Categories: map[string]float64{"A": 3}},
+			Input{Evidence: Evidence{SocialCategories: []string{"A", "A", "A"}}}, 3, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := Score([]Criterion{tt.c}, tt.in, now)
+			it := item(t, b, tt.c.Kind)
+			if it.Points != tt.points || b.Total != tt.points {
+				t.Fatalf("points = %v (total %v), want %v; item %+v", it.Points, b.Total, tt.points, it)
+			}
+			if it.Note != tt.note {
+				t.Fatalf("note = %q, want %q", it.Note, tt.note)
+			}
+			if it.Normalized < 0 || it.Normalized > 1 {
+				t.Fatalf("normalized %v out of [0, 1]", it.Normalized)
+			}
+		})
+	}
+}
+
+func TestScoreDefaultTotalAndRounding(t *testing.T) {
+	best := Input{
+		Evidence: Evidence{GPA: 10, ECTSEarned: 300, HouseholdIncome: 0, HouseholdMembers: 3, DistanceKm: 400,
+			SocialCategories: []string{"DISABILITY", "NO_PARENTS"}},
+		YearOfStudy: 6,
+	}
+	b := Score(Default(), best, now)
+	if b.Total != 100 {
+		t.Fatalf("max score = %v, want 100", b.Total)
+	}
+	if !b.ComputedAt.Equal(now) || len(b.Criteria) != len(Default()) || b.Input.GPA != 10 {
+		t.Fatalf("breakdown does not record its inputs: %+v", b)
+	}
+
+	b = Score([]Criterion{{Kind: GPA, Weight: 10, Min: 6, Max: 10}}, Input{Evidence: Evidence{GPA: 7.77}}, now)
+	if b.Total != 4.4 { // 1.77/4 = 0.4425 -> 0.44
+		t.Fatalf("rounded total = %v, want 4.4", b.Total)
+	}
+}
+
+func TestValidateEvidence(t *testing.T) {
+	criteria := Default()
+	tests := []struct {
+		name string
+		ev   Evidence
+		ok   bool
+	}{
+		{"empty is fine", Evidence{}, true},
+		{"gpa below range", Evidence{GPA: 5.5}, false},
+		{"gpa above range", Evidence{GPA: 10.5}, false},
+		{"negative distance", Evidence{DistanceKm: -1}, false},
+		{"income without members", Evidence{HouseholdIncome: 50000}, false},
+		{"income with members", Evidence{HouseholdIncome: 50000, HouseholdMembers: 2}, true},
+		{"known category", Evidence{SocialCategories: []string{"ROMA"}}, true},
+		{"unknown category", Evidence{SocialCategories: []string{"VIP"}}, false},
+	}
+	for _, tt := range tests {
+		if err := ValidateEvidence(criteria, tt.ev); (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v, want ok=%v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate(Default()); err != nil {
+		t.Fatalf("default criteria: %v", err)
+	}
+	bad := [][]Criterion{
+		{{Kind: "HEIGHT", Weight: 1, Max: 1}},
+		{{Kind: GPA, Weight: 1, Min: 6, Max: 10}, {Kind: GPA, Weight: 2, Min: 6, Max: 10}},
+		{{Kind: GPA, Weight: 0, Min: 6, Max: 10}},
+		{{Kind: Distance, Weight: 1, Min: 10, Max: 10}},
+		{{Kind: SocialCategory, Weight: 1}},
+	}
+	for i, c := range bad {
+		if Validate(c) == nil {
+			t.Errorf("case %d: expected error for %+v", i, c)
+		}
+	}
+}
@@ -0,0 +1,394 @@
+package student
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"student-housting/types"
+)
+
+// Rangiranje i raspodela mesta. Pokrece se nad zatvorenim konkursom: prijave u statusu
+// SUBMITTED, UNDER_REVIEW i WAITLISTED se rangiraju po bodovima, pa redom dobijaju krevet u
+// prvom domu po zelji koji ima slobodnu kvotu i sobu sa slobodnim mestom. Statusi se menjaju
+// kroz tranzicije, sve u jednoj transakciji; dry-run izvrsava isto pa transakciju ponistava.
+//
+// Pri istim bodovima prednost ima veci prosek, pa ranije napravljena prijava, pa manji id.
+// Vec prihvacene prijave konkursa se ne diraju, ali zauzimaju kvote i krevete. Mesta iz konkursa
+// ranijih skolskih godina se ne racunaju (vidi sameAcademicYear).
+
+var (
+	errDryRun              = errors.New("dry run")
+	errCompetitionNotFound = errors.New("competition not found")
+)
+
+// rankedStatuses su statusi prijava koje ulaze u rangiranje.
+var rankedStatuses = []types.ApplicationStatus{types.StatusSubmitted, types.StatusUnderReview, types.StatusWaitlisted}
+
+func allocateCompetition(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+		dryRun := c.Query("dryRun") == "true"
+
+		var run types.AllocationRun
+		err := db.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+			// Zakljucan konkurs: dva pokretanja za isti konkurs ne mogu da se preklope
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&types.Competition{}, "id = ?", id).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return errCompetitionNotFound
+				}
+				return err
+			}
+			comp, err := loadCompetition(tx, id)
+			if err != nil {
+				return err
+			}
+			if comp.Phase != types.PhaseClosed {
+				return &transitionError{http.StatusConflict, "COMPETITION_OPEN", "competition must be closed before allocation"}
+			}
+			for _, d := range comp.Dorms {
+				if !dormInScope(c, d.DormID) {
+					return &transitionError{http.StatusForbidden, "OUT_OF_SCOPE", "competition includes dorms outside your scope"}
+				}
+			}
+			if err := allocate(c, tx, &comp, &run); err != nil {
+				return err
+			}
+			if dryRun {
+				return errDryRun
+			}
+			if err := tx.Omit("Results").Create(&run).Error; err != nil {
+				return err
+			}
+			return tx.CreateInBatches(run.Results, 500).Error
+		})
+
+		var te *transitionError
+		switch {
+		case err == nil:
+			c.JSON(http.StatusCreated, run)
+		case errors.Is(err, errDryRun):
+			run.ID, run.DryRun = uuid.Nil, true
+			for i := range run.Results {
+				run.Results[i].RunID = uuid.Nil
+			}
+			c.JSON(http.StatusOK, run)
+		case errors.Is(err, errCompetitionNotFound):
+			jsonErr(c, http.StatusNotFound, "competition not found")
+		case errors.As(err, &te):
+			c.JSON(te.status, gin.H{"error": te.msg, "code": te.code})
+		default:
+			log.Printf("[allocateCompetition] err: %v", err)
+			jsonErr(c, http.StatusInternalServerError, "failed to allocate")
+		}
+	}
+}
+
+func listAllocationRuns(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+		page, size, offset := pagination(c)
+		q := db.Model(&types.AllocationRun{}).Where("competition_id = ?", id)
+		var cnt int64
+		if err := q.Count(&cnt).Error; err != nil {
+			jsonErr(c, http.StatusInternalServerError, "failed to count allocation runs")
+			return
+		}
+		var list []types.AllocationRun
+		if err := q.Order("created_at DESC").Offset(offset).Limit(size).Find(&list).Error; err != nil {
+			jsonErr(c, http.StatusInternalServerError, "failed to retrieve allocation runs")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"items": list, "pagination": gin.H{"page": page, "pageSize": size, "totalCount": cnt}})
+	}
+}
+
+func getAllocationRun(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+		runID, ok := parseUUID(c, "runId")
+		if !ok {
+			return
+		}
+		var run types.AllocationRun
+		err := db.Preload("Results", func(q *gorm.DB) *gorm.DB { return q.Order("rank") }).
+			First(&run, "id = ? AND competition_id = ?", runID, id).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				jsonErr(c, http.StatusNotFound, "allocation run not found")
+				return
+			}
+			jsonErr(c, http.StatusInternalServerError, "failed to fetch allocation run")
+			return
+		}
+		c.JSON(http.StatusOK, run)
+	}
+}
+
+/* ===================== Helpers ===================== */
+
+type roomSlot struct {
+	id   uuid.UUID
+	free int
+}
+
+// seatPool je stanje mesta pre rangiranja: slobodni kreveti po domu (sobe po broju), preostale
+// kvote domova i fakulteta i studenti koji vec imaju mesto. assignSeats ga trosi.
+type seatPool struct {
+	dormIDs     []uuid.UUID
+	dormLeft    map[uuid.UUID]int
+	facultyLeft map[string]int
+	slots       map[uuid.UUID][]*roomSlot
+	placed      map[uint]bool
+}
+
+// allocate rangira prijave konkursa, menja im status i puni run rezultatima.
+func allocate(c *gin.Context, tx *gorm.DB, comp *types.Competition, run *types.AllocationRun) error {
+	pool := seatPool{dormLeft: map[uuid.UUID]int{}, facultyLeft: map[string]int{}, slots: map[uuid.UUID][]*roomSlot{}, placed: map[uint]bool{}}
+	for _, d := range comp.Dorms {
+		pool.dormIDs = append(pool.dormIDs, d.DormID)
+		pool.dormLeft[d.DormID] = d.Quota
+	}
+	sort.Slice(pool.dormIDs, func(i, j int) bool { return pool.dormIDs[i].String() < pool.dormIDs[j].String() })
+
+	// Sobe se zakljucavaju da rezervacija preko tranzicije ne bi zauzela isti krevet
+	var rooms []types.Room
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("dorm_id IN ? AND available", pool.dormIDs).Order("number, id").Find(&rooms).Error; err != nil {
+		return err
+	}
+	roomIDs := make([]uuid.UUID, 0, len(rooms))
+	for _, r := range rooms {
+		roomIDs = append(roomIDs, r.ID)
+	}
+	var taken []struct {
+		RoomID uuid.UUID
+		N      int
+	}
+	if err := tx.Model(&types.Application{}).Scopes(sameAcademicYear(&comp.ID)).Select("room_id, count(*) AS n").
+		Where("room_id IN ? AND status IN ?", roomIDs, placedStatuses).Group("room_id").Scan(&taken).Error; err != nil {
+		return err
+	}
+	used := map[uuid.UUID]int{}
+	for _, t := range taken {
+		used[t.RoomID] = t.N
+	}
+	for _, r := range rooms {
+		if free := r.Capacity - used[r.ID]; free > 0 {
+			pool.slots[r.DormID] = append(pool.slots[r.DormID], &roomSlot{id: r.ID, free: free})
+		}
+	}
+
+	// Vec prihvaceni na ovom konkursu trose kvote doma i fakulteta
+	var byDorm []struct {
+		DormID uuid.UUID
+		N      int
+	}
+	if err := tx.Table("applications a").Select("r.dorm_id, count(*) AS n").
+		Joins("JOIN rooms r ON r.id = a.room_id").
+		Where("a.competition_id = ? AND a.status IN ? AND a.deleted_at IS NULL", comp.ID, placedStatuses).
+		Group("r.dorm_id").Scan(&byDorm).Error; err != nil {
+		return err
+	}
+	for _, d := range byDorm {
+		pool.dormLeft[d.DormID] -= d.N
+	}
+	var byFaculty []struct {
+		Faculty string
+		N       int
+	}
+	if err := tx.Table("applications a").Select("p.faculty, count(*) AS n").
+		Joins("JOIN user_profiles p ON p.user_id = a.student_id").
+		Where("a.competition_id = ? AND a.status IN ? AND a.deleted_at IS NULL", comp.ID, placedStatuses).
+		Group("p.faculty").Scan(&byFaculty).Error; err != nil {
+		return err
+	}
+	for f, q := range comp.FacultyQuotas {
+		pool.facultyLeft[facultyKey(f)] += q
+	}
+	for _, f := range byFaculty {
+		if _, limited := pool.facultyLeft[facultyKey(f.Faculty)]; limited {
+			pool.facultyLeft[facultyKey(f.Faculty)] -= f.N
+		}
+	}
+
+	var apps []types.Application
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("competition_id = ? AND status IN ?", comp.ID, rankedStatuses).Find(&apps).Error; err != nil {
+		return err
+	}
+	studentIDs := make([]uint, 0, len(apps))
+	for _, a := range apps {
+		studentIDs = append(studentIDs, a.StudentID)
+	}
+	var profiles []types.Profile
+	if err := tx.Where("user_id IN ?", studentIDs).Find(&profiles).Error; err != nil {
+		return err
+	}
+	profileOf := map[uint]*types.Profile{}
+	for i := range profiles {
+		profileOf[profiles[i].UserID] = &profiles[i]
+	}
+	var placedIDs []uint
+	if err := tx.Model(&types.Application{}).Scopes(sameAcademicYear(&comp.ID)).
+		Where("student_id IN ? AND status IN ?", studentIDs, placedStatuses).
+		Pluck("student_id", &placedIDs).Error; err != nil {
+		return err
+	}
+	for _, s := range placedIDs {
+		pool.placed[s] = true
+	}
+
+	results := assignSeats(comp, apps, profileOf, &pool)
+	*run = types.AllocationRun{
+		ID: uuid.New(), CompetitionID: comp.ID, CreatedBy: actingUser(c), CreatedAt: time.Now().UTC(),
+		Results: results,
+	}
+	for i := range apps {
+		res := &run.Results[i]
+		res.RunID = run.ID
+		if err := applyOutcome(c, tx, &apps[i], *res); err != nil {
+			return err
+		}
+		switch res.Outcome {
+		case types.OutcomeAccepted:
+			run.Accepted++
+		case types.OutcomeWaitlisted:
+			run.Waitlisted++
+		default:
+			run.Rejected++
+		}
+	}
+	return nil
+}
+
+// assignSeats je cist korak raspodele: sortira apps po rangu i redom im dodeljuje krevet iz pool-a.
+// Rezultati (bez RunID) su u istom redosledu kao sortirane prijave.
+func assignSeats(comp *types.Competition, apps []types.Application, profileOf map[uint]*types.Profile, pool *seatPool) []types.AllocationResult {
+	sort.Slice(apps, func(i, j int) bool { return rankedBefore(&apps[i], &apps[j]) })
+
+	results := make([]types.AllocationResult, 0, len(apps))
+	for i := range apps {
+		a := &apps[i]
+		res := types.AllocationResult{ApplicationID: a.ID, Rank: i + 1, StudentID: a.StudentID, Points: a.Points}
+		p := profileOf[a.StudentID]
+		if p != nil {
+			res.Faculty = p.Faculty
+		}
+		prefs := a.DormPreferences
+		if len(prefs) == 0 {
+			prefs = pool.dormIDs
+		}
+
+		switch {
+		case p == nil || strings.TrimSpace(p.Index) == "" || strings.TrimSpace(p.Faculty) == "" || !comp.Eligible(p.Faculty, p.YearOfStudy):
+			res.Outcome, res.Reason = types.OutcomeRejected, "not eligible for this competition"
+		case pool.placed[a.StudentID]:
+			res.Outcome, res.Reason = types.OutcomeRejected, "student already has an accepted application"
+		case facultyFull(pool.facultyLeft, p.Faculty):
+			res.Outcome, res.Reason = types.OutcomeWaitlisted, "faculty quota is full"
+		default:
+			res.Outcome, res.Reason = types.OutcomeWaitlisted, "no free place in preferred dorms"
+			for _, d := range prefs {
+				if pool.dormLeft[d] <= 0 {
+					continue
+				}
+				if room := takeBed(pool.slots[d]); room != nil {
+					dorm := d
+					res.Outcome, res.Reason, res.DormID, res.RoomID = types.OutcomeAccepted, "", &dorm, room
+					pool.dormLeft[d]--
+					if _, limited := pool.facultyLeft[facultyKey(p.Faculty)]; limited {
+						pool.facultyLeft[facultyKey(p.Faculty)]--
+					}
+					pool.placed[a.StudentID] = true
+					break
+				}
+			}
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+// applyOutcome prevodi ishod u tranzicije; neprepregledana prijava prvo prolazi review.
+func applyOutcome(c *gin.Context, tx *gorm.DB, a *types.Application, res types.AllocationResult) error {
+	if a.Status == types.StatusSubmitted {
+		req := types.TransitionReq{Transition: types.TransitionReview}
+		if err := applyTransition(c, tx, a, req, transitions[req.Transition]); err != nil {
+			return err
+		}
+	}
+	req := types.TransitionReq{Reason: res.Reason}
+	switch res.Outcome {
+	case types.OutcomeAccepted:
+		req.Transition = types.TransitionAccept
+		a.RoomID = res.RoomID
+	case types.OutcomeRejected:
+		req.Transition = types.TransitionReject
+	default:
+		if a.Status == types.StatusWaitlisted {
+			return nil
+		}
+		req.Transition = types.TransitionWaitlist
+	}
+	return applyTransition(c, tx, a, req, transitions[req.Transition])
+}
+
+// rankedBefore: bodovi, pa prosek, pa vreme nastanka prijave, pa id.
+func rankedBefore(a, b *types.Application) bool {
+	if a.Points != b.Points {
+		return a.Points > b.Points
+	}
+	if ga, gb := gpaOf(a), gpaOf(b); ga != gb {
+		return ga > gb
+	}
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	return a.ID.String() < b.ID.String()
+}
+
+func gpaOf(a *types.Application) float64 {
+	if a.Evidence == nil {
+		return 0
+	}
+	return a.Evidence.GPA
+}
+
+func facultyKey(f string) string {
+	return strings.ToLower(strings.TrimSpace(f))
+}
+
+func facultyFull(left map[string]int, faculty string) bool {
+	n, limited := left[facultyKey(faculty)]
+	return limited && n <= 0
+}
+
+// takeBed uzima krevet u prvoj sobi (po broju) koja ga ima.
+func takeBed(rooms []*roomSlot) *uuid.UUID {
+	for _, r := range rooms {
+		if r.free > 0 {
+			r.free--
+			id := r.id
+			return &id
+		}
+	}
+	return nil
+}
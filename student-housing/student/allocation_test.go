@@ -0,0 +1,279 @@
+package student
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"student-housting/scoring"
+	"student-housting/types"
+)
+
+var (
+	dormA = uuid.MustParse("00000000-0000-0000-0000-00000000000a")
+	dormB = uuid.MustParse("00000000-0000-0000-0000-00000000000b")
+	t0    = time.Date(2026, 7, 1, 9, 0, 0, 0, time.UTC)
+)
+
+// room pravi sobu rednog broja n sa free slobodnih kreveta.
+func room(n int, free int) *roomSlot {
+	return &roomSlot{id: uuid.MustParse(fmt.Sprintf("00000000-0000-0000-0001-%012d", n)), free: free}
+}
+
+func newPool(quotas map[uuid.UUID]int, slots map[uuid.UUID][]*roomSlot, facultyQuotas map[string]int) *seatPool {
+	p := &seatPool{dormLeft: map[uuid.UUID]int{}, facultyLeft: map[string]int{}, slots: slots, placed: map[uint]bool{}}
+	for d, q := range quotas {
+		p.dormIDs = append(p.dormIDs, d)
+		p.dormLeft[d] = q
+	}
+	for f, q := range facultyQuotas {
+		p.facultyLeft[facultyKey(f)] = q
+	}
+	sort.Slice(p.dormIDs, func(i, j int) bool { return p.dormIDs[i].String() < p.dormIDs[j].String() })
+	return p
+}
+
+func app(n int, points float64, prefs ...uuid.UUID) types.Application {
+	return types.Application{
+		ID:              uuid.MustParse(fmt.Sprintf("00000000-0000-0000-0002-%012d", n)),
+		StudentID:       uint(n),
+		Points:          points,
+		CreatedAt:       t0,
+		Status:          types.StatusUnderReview,
+		DormPreferences: prefs,
+		Evidence:        &scoring.Evidence{GPA: 8},
+	}
+}
+
+func profiles(apps []types.Application, faculty string) map[uint]*types.Profile {
+	m := map[uint]*types.Profile{}
+	for _, a := range apps {
+		m[a.StudentID] = &types.Profile{UserID: a.StudentID, Index: fmt.Sprintf("%d/2025", a.StudentID), Faculty: faculty, YearOfStudy: 2}
+	}
+	return m
+}
+
+func outcomes(res []types.AllocationResult) map[uint]types.AllocationResult {
+	m := map[uint]types.AllocationResult{}
+	for _, r := range res {
+		m[r.StudentID] = r
+	}
+	return m
+}
+
+func TestAssignSeatsRankOrder(t *testing.T) {
+	a := app(1, 50)
+	b := app(2, 60)
+	c := app(3, 50) // isti bodovi kao 1, veci prosek
+	c.Evidence = &scoring.Evidence{GPA: 9}
+	d := app(4, 50) // isti bodovi i prosek kao 1, ranija prijava
+	d.CreatedAt = t0.Add(-time.Hour)
+	e := app(5, 50) // sve isto kao 1, odlucuje id
+	e.ID = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
+	apps := []types.Application{a, b, c, d, e}
+	res := assignSeats(&types.Competition{}, apps, profiles(apps, "ETF"), newPool(nil, nil, nil))
+
+	var got []uint
+	for i, r := range res {
+		if r.Rank != i+1 {
+			t.Fatalf("result %d has rank %d", i, r.Rank)
+		}
+		got = append(got, r.StudentID)
+	}
+	if want := []uint{2, 3, 4, 5, 1}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("rank order = %v, want %v", got, want)
+	}
+}
+
+func TestAssignSeatsDeterministic(t *testing.T) {
+	build := func(seed int64) []types.AllocationResult {
+		var apps []types.Application
+		for i := 1; i <= 30; i++ {
+			prefs := []uuid.UUID{dormA, dormB}
+			if i%3 == 0 {
+				prefs = []uuid.UUID{dormB}
+			}
+			apps = append(apps, app(i, float64(40+i%7), prefs...))
+		}
+		rand.New(rand.NewSource(seed)).Shuffle(len(apps), func(i, j int) { apps[i], apps[j] = apps[j], apps[i] })
+		pool := newPool(map[uuid.UUID]int{dormA: 8, dormB: 6},
+			map[uuid.UUID][]*roomSlot{dormA: {room(1, 2), room(2, 3), room(3, 3)}, dormB: {room(4, 4), room(5, 4)}},
+			map[string]int{"ETF": 12})
+		return assignSeats(&types.Competition{}, apps, profiles(apps, "ETF"), pool)
+	}
+	want := build(1)
+	for seed := int64(2); seed < 10; seed++ {
+		if got := build(seed); !reflect.DeepEqual(got, want) {
+			t.Fatalf("seed %d: results differ from seed 1", seed)
+		}
+	}
+}
+
+func TestAssignSeatsPreferenceOrder(t *testing.T) {
+	apps := []types.Application{
+		app(1, 90, dormB, dormA),
+		app(2, 80, dormB, dormA),
+		app(3, 70, dormA),
+		app(4, 60), // bez zelja: bilo koji dom po redu id-a
+	}
+	pool := newPool(map[uuid.UUID]int{dormA: 5, dormB: 5},
+		map[uuid.UUID][]*roomSlot{dormA: {room(1, 5)}, dormB: {room(2, 1)}}, nil)
+	got := outcomes(assignSeats(&types.Competition{}, apps, profiles(apps, "ETF"), pool))
+
+	for student, dorm := range map[uint]uuid.UUID{1: dormB, 2: dormA, 3: dormA, 4: dormA} {
+		r := got[student]
+		if r.Outcome != types.OutcomeAccepted || r.DormID == nil || *r.DormID != dorm {
+			t.Errorf("student %d: %s in %v, want ACCEPTED in %s", student, r.Outcome, r.DormID, dorm)
+		}
+	}
+}
+
+func TestAssignSeatsDormQuota(t *testing.T) {
+	apps := []types.Application{app(1, 90, dormA), app(2, 80, dormA), app(3, 70, dormA, dormB), app(4, 60, dormA)}
+	// dom A ima 4 kreveta, ali kvota konkursa je 2
+	pool := newPool(map[uuid.UUID]int{dormA: 2, dormB: 1},
+		map[uuid.UUID][]*roomSlot{dormA: {room(1, 4)}, dormB: {room(2, 2)}}, nil)
+	got := outcomes(assignSeats(&types.Competition{}, apps, profiles(apps, "ETF"), pool))
+
+	if got[1].Outcome != types.OutcomeAccepted || got[2].Outcome != types.OutcomeAccepted {
+		t.Fatalf("top two should be accepted: %+v %+v", got[1], got[2])
+	}
+	if r := got[3]; r.Outcome != types.OutcomeAccepted || *r.DormID != dormB {
+		t.Fatalf("student 3 should fall through to dorm B: %+v", r)
+	}
+	if r := got[4]; r.Outcome != types.OutcomeWaitlisted || r.Reason != "no free place in preferred dorms" {
+		t.Fatalf("student 4 should be waitlisted: %+v", r)
+	}
+	if pool.dormLeft[dormA] != 0 || pool.dormLeft[dormB] != 0 {
+		t.Fatalf("quotas left: %v", pool.dormLeft)
+	}
+}
+
+func TestAssignSeatsFacultyQuota(t *testing.T) {
+	apps := []types.Application{app(1, 90), app(2, 80), app(3, 70), app(4, 60)}
+	prof := profiles(apps, "ETF")
+	prof[3].Faculty = "FON" // fakultet bez kvote nije ogranicen
+	pool := newPool(map[uuid.UUID]int{dormA: 10}, map[uuid.UUID][]*roomSlot{dormA: {room(1, 10)}}, map[string]int{" etf ": 1})
+	got := outcomes(assignSeats(&types.Competition{}, apps, prof, pool))
+
+	want := map[uint]types.AllocationOutcome{1: types.OutcomeAccepted, 2: types.OutcomeWaitlisted, 3: types.OutcomeAccepted, 4: types.OutcomeWaitlisted}
+	for s, o := range want {
+		if got[s].Outcome != o {
+			t.Errorf("student %d: %s, want %s", s, got[s].Outcome, o)
+		}
+	}
+	if got[2].Reason != "faculty quota is full" {
+		t.Errorf("student 2 reason = %q", got[2].Reason)
+	}
+}
+
+func TestAssignSeatsRoomCapacity(t *testing.T) {
+	var apps []types.Application
+	for i := 1; i <= 7; i++ {
+		apps = append(apps, app(i, float64(100-i)))
+	}
+	r1, r2 := room(1, 2), room(2, 3)
+	pool := newPool(map[uuid.UUID]int{dormA: 100}, map[uuid.UUID][]*roomSlot{dormA: {r1, r2}}, nil)
+	res := assignSeats(&types.Competition{}, apps, profiles(apps, "ETF"), pool)
+
+	perRoom := map[uuid.UUID]int{}
+	accepted := 0
+	for _, r := range res {
+		if r.Outcome == types.OutcomeAccepted {
+			accepted++
+			perRoom[*r.RoomID]++
+		}
+	}
+	if accepted != 5 || perRoom[r1.id] != 2 || perRoom[r2.id] != 3 {
+		t.Fatalf("accepted %d, per room %v; want 5 split 2/3", accepted, perRoom)
+	}
+	// Prva soba po broju se puni prva
+	if *res[0].RoomID != r1.id || *res[1].RoomID != r1.id || *res[2].RoomID != r2.id {
+		t.Fatalf("rooms not filled in order: %v %v %v", *res[0].RoomID, *res[1].RoomID, *res[2].RoomID)
+	}
+	if r1.free != 0 || r2.free != 0 {
+		t.Fatalf("free beds left: %d %d", r1.free, r2.free)
+	}
+}
+
+func TestAssignSeatsRejections(t *testing.T) {
+	apps := []types.Application{app(1, 90), app(2, 80), app(3, 70), app(4, 60)}
+	prof := profiles(apps, "ETF")
+	prof[2].YearOfStudy = 1         // konkurs je za 2. i 3. godinu
+	prof[3].Index = ""              // bez indeksa nema rangiranja
+	delete(prof, 4)                 // profil jos nije sinhronizovan
+	apps = append(apps, app(1, 50)) // druga prijava istog studenta
+	apps[4].ID = uuid.New()
+
+	pool := newPool(map[uuid.UUID]int{dormA: 10}, map[uuid.UUID][]*roomSlot{dormA: {room(1, 10)}}, nil)
+	res := assignSeats(&types.Competition{YearsOfStudy: []int{2, 3}}, apps, prof, pool)
+
+	if res[0].Outcome != types.OutcomeAccepted {
+		t.Fatalf("student 1 first application: %+v", res[0])
+	}
+	for _, r := range res[1:] {
+		if r.Outcome != types.OutcomeRejected {
+			t.Errorf("rank %d (student %d): %s, want REJECTED", r.Rank, r.StudentID, r.Outcome)
+		}
+	}
+	if res[4].Reason != "student already has an accepted application" {
+		t.Errorf("duplicate reason = %q", res[4].Reason)
+	}
+}
+
+func TestAllocateIgnoresEarlierYearPlacements(t *testing.T) {
+	db := newTestDB(t)
+	dorm := &types.Dorm{ID: uuid.New(), Name: "Dom A", Address: "Adresa 1"}
+	oldRoom := &types.Room{ID: uuid.New(), DormID: dorm.ID, Number: "101", Capacity: 1, Available: true}
+	fullRoom := &types.Room{ID: uuid.New(), DormID: dorm.ID, Number: "102", Capacity: 1, Available: true}
+	mustCreate(t, db, dorm, oldRoom, fullRoom)
+
+	lastYear := seedCompetition(t, db, "2025/2026", true, map[uuid.UUID]int{dorm.ID: 2})
+	firstRound := seedCompetition(t, db, "2026/2027", true, map[uuid.UUID]int{dorm.ID: 1})
+	comp := seedCompetition(t, db, "2026/2027", true, map[uuid.UUID]int{dorm.ID: 2})
+
+	// Student 1 je prosle godine stanovao u sobi 101, student 2 je primljen u prvom krugu ove godine
+	mustCreate(t, db,
+		placedApp(1, lastYear, types.StatusReserved, &oldRoom.ID),
+		placedApp(2, firstRound, types.StatusReserved, &fullRoom.ID),
+	)
+	for _, s := range []uint{1, 2} {
+		mustCreate(t, db,
+			&types.Profile{UserID: s, Email: fmt.Sprintf("s%d@student.test", s), Role: types.StudentRole, Index: fmt.Sprintf("%d/2024", s), Faculty: "ETF", YearOfStudy: 3},
+			&types.Application{ID: uuid.New(), StudentID: s, CompetitionID: &comp.ID, Status: types.StatusUnderReview,
+				Points: float64(50 + s), DormPreferences: []uuid.UUID{}, Evidence: &scoring.Evidence{GPA: 8}},
+		)
+	}
+
+	c, _ := staffContext(nil)
+	loaded, err := loadCompetition(db, comp.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var run types.AllocationRun
+	if err := db.Transaction(func(tx *gorm.DB) error { return allocate(c, tx, &loaded, &run) }); err != nil {
+		t.Fatal(err)
+	}
+
+	got := outcomes(run.Results)
+	if r := got[1]; r.Outcome != types.OutcomeAccepted || r.RoomID == nil || *r.RoomID != oldRoom.ID {
+		t.Fatalf("student 1 should get last year's bed: %+v", r)
+	}
+	if r := got[2]; r.Outcome != types.OutcomeRejected || r.Reason != "student already has an accepted application" {
+		t.Fatalf("student 2 is already placed this year: %+v", r)
+	}
+	var a types.Application
+	if err := db.First(&a, "competition_id = ? AND student_id = ?", comp.ID, 1).Error; err != nil {
+		t.Fatal(err)
+	}
+	if a.Status != types.StatusAccepted {
+		t.Fatalf("student 1 application status = %s, want ACCEPTED", a.Status)
+	}
+}
@@ -3,47 +3,59 @@ package student
 import (
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+
+	"shared/middleware"
+	"student-housting/types"
+	"student-housting/upstream"
 )
 
-func WithStudentAPI(r *gin.RouterGroup, db *gorm.DB) {
-	r.GET("/students", getStudents(db))
-	r.GET("/users", getUsers(db))
+var can = middleware.RequirePermission[types.Permission]
+
+// selfOrCan: vlasnik naloga (:id) ili pozivalac sa dozvolom.
+func selfOrCan(p types.Permission) gin.HandlerFunc {
+	return middleware.RequireSelfOrPermission("id", p)
+}
+
+func WithStudentAPI(r *gin.RouterGroup, db *gorm.DB, auth gin.HandlerFunc, authClient *upstream.AuthClient) {
+	r.GET("/students", auth, can(types.PermStudentRead), getStudents(db))
 
-	r.GET("/students/:id", getStudentByID(db))
-	r.POST("/students", createStudent(db))
-	r.PUT("/students/:id", updateStudent(db))
-	r.PATCH("/students/:id", changePassword(db))
-	r.DELETE("/students/:id", deleteStudent(db))
-	r.PATCH("/users/:id/role", UpdateUserRole(db))
+	r.GET("/students/:id", auth, selfOrCan(types.PermStudentRead), getStudentByID(db, authClient))
+	r.POST("/students", auth, can(types.PermStudentWrite), createStudent(db, authClient))
+	r.PUT("/students/:id", auth, selfOrCan(types.PermStudentWrite), updateStudent(db, authClient))
+	r.DELETE("/students/:id", auth, can(types.PermStudentWrite), deleteStudent(db))
 }
 
-func WithDormAPI(r *gin.RouterGroup, db *gorm.DB) {
+func WithDormAPI(r *gin.RouterGroup, db *gorm.DB, auth gin.HandlerFunc) {
 	r.GET("/dorms", listDorms(db))
 	r.GET("/dorms/:id", getDorm(db))
-	r.POST("/dorms", createDorm(db))
-	r.PUT("/dorms/:id", updateDorm(db))
-	r.DELETE("/dorms/:id", deleteDorm(db))
+	r.POST("/dorms", auth, can(types.PermDormWrite), createDorm(db))
+	r.PUT("/dorms/:id", auth, can(types.PermDormWrite), updateDorm(db))
+	r.DELETE("/dorms/:id", auth, can(types.PermDormWrite), deleteDorm(db))
 }
 
-func WithRoomAPI(r *gin.RouterGroup, db *gorm.DB) {
+func WithRoomAPI(r *gin.RouterGroup, db *gorm.DB, auth gin.HandlerFunc) {
 	r.GET("/rooms", listRooms(db)) // ?dormId=
 	r.GET("/rooms/:id", getRoom(db))
-	r.POST("/rooms", createRoom(db))
-	r.PUT("/rooms/:id", updateRoom(db))
-	r.DELETE("/rooms/:id", deleteRoom(db))
+	r.POST("/rooms", auth, can(types.PermRoomWrite), createRoom(db))
+	r.PUT("/rooms/:id", auth, can(types.PermRoomWrite), updateRoom(db))
+	r.DELETE("/rooms/:id", auth, can(types.PermDormWrite), deleteRoom(db))
 }
 
-func WithApplicationAPI(r *gin.RouterGroup, db *gorm.DB) {
-	r.GET("/applications", listApplications(db)) // ?studentId=&dormId=&status=
-	r.GET("/applications/:id", getApplication(db))
-	r.POST("/applications", createApplication(db))
-	r.PUT("/applications/:id", updateApplication(db))
-	r.DELETE("/applications/:id", deleteApplication(db))
+func WithApplicationAPI(r *gin.RouterGroup, db *gorm.DB, auth gin.HandlerFunc, authClient *upstream.AuthClient) {
+	// Bez application:read pozivalac vidi samo svoje prijave
+	r.GET("/applications", auth, listApplications(db)) // ?studentId=&competitionId=&dormId=&status=
+	r.GET("/applications/:id", auth, getApplication(db))
+	r.POST("/applications", auth, can(types.PermApplicationSubmit, types.PermApplicationReview), createApplication(db, authClient))
+	r.PUT("/applications/:id", auth, can(types.PermApplicationSubmit, types.PermApplicationReview), updateApplication(db))
+	// Ko sme koju tranziciju proverava sama tabela tranzicija (lifecycle.go)
+	r.POST("/applications/:id/transitions", auth, transitionApplication(db, authClient))
+	r.GET("/applications/:id/transitions", auth, listApplicationTransitions(db))
+	r.DELETE("/applications/:id", auth, can(types.PermApplicationDelete), deleteApplication(db))
 }
 
-func WithPaymentAPI(r *gin.RouterGroup, db *gorm.DB) {
-	r.GET("/payments", listPayments(db)) // ?applicationId=
-	r.GET("/payments/:id", getPayment(db))
-	r.POST("/payments", createPayment(db))
-	r.DELETE("/payments/:id", deletePayment(db))
+func WithPaymentAPI(r *gin.RouterGroup, db *gorm.DB, auth gin.HandlerFunc) {
+	r.GET("/payments", auth, listPayments(db)) // ?applicationId=
+	r.GET("/payments/:id", auth, getPayment(db))
+	r.POST("/payments", auth, can(types.PermPaymentIssue), createPayment(db))
+	r.DELETE("/payments/:id", auth, can(types.PermPaymentDelete), deletePayment(db))
 }
@@ -0,0 +1,338 @@
+package student
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"shared/middleware"
+	"student-housting/types"
+)
+
+// Zalbe na privremenu rang listu. Student sa prijavom na listi podnosi jednu zalbu po listi,
+// do roka za zalbe, kao multipart formu (polje "text" i prilozi u polju "files"). Staff sa
+// application:review je uzima u rad i usvaja ili odbija uz obrazlozenje. Usvojena zalba vraca
+// prijavu na ponovni pregled (reopen); ispravka ulazi u konacnu listu kroz novu raspodelu.
+
+const (
+	maxAppealText           = 5000
+	maxAppealAttachments    = 5
+	maxAppealAttachmentSize = 5 << 20
+)
+
+var appealContentTypes = map[string]bool{"application/pdf": true, "image/jpeg": true, "image/png": true}
+
+var errAppealNotFound = errors.New("appeal not found")
+
+func WithAppealAPI(r *gin.RouterGroup, db *gorm.DB, auth gin.HandlerFunc) {
+	r.POST("/competitions/:id/appeals", auth, can(types.PermApplicationSubmit), submitAppeal(db))
+	r.GET("/competitions/:id/appeals", auth, can(types.PermApplicationSubmit, types.PermApplicationReview), listAppeals(db)) // ?status=
+	r.GET("/appeals/:id", auth, getAppeal(db))
+	r.GET("/appeals/:id/attachments/:attachmentId", auth, downloadAppealAttachment(db))
+	r.POST("/appeals/:id/review", auth, can(types.PermApplicationReview), reviewAppeal(db))
+}
+
+func submitAppeal(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+		uid, _ := middleware.UserID(c)
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxAppealAttachments*maxAppealAttachmentSize+1<<20)
+		form, err := c.MultipartForm()
+		if err != nil {
+			jsonErr(c, http.StatusBadRequest, "multipart form with text is required")
+			return
+		}
+		text := ""
+		if v := form.Value["text"]; len(v) > 0 {
+			text = strings.TrimSpace(v[0])
+		}
+		if text == "" || len(text) > maxAppealText {
+			jsonErr(c, http.StatusBadRequest, fmt.Sprintf("text is required (at most %d characters)", maxAppealText))
+			return
+		}
+		files := form.File["files"]
+		if len(files) > maxAppealAttachments {
+			jsonErr(c, http.StatusBadRequest, fmt.Sprintf("at most %d attachments", maxAppealAttachments))
+			return
+		}
+
+		now := time.Now().UTC()
+		ap := types.Appeal{ID: uuid.New(), CompetitionID: id, StudentID: uid, Text: text, Status: types.AppealSubmitted, CreatedAt: now}
+		for _, fh := range files {
+			att, msg := readAttachment(fh)
+			if msg != "" {
+				jsonErr(c, http.StatusBadRequest, msg)
+				return
+			}
+			att.AppealID, att.CreatedAt = ap.ID, now
+			ap.Attachments = append(ap.Attachments, att)
+		}
+
+		err = db.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+			list, err := latestProvisional(tx, id)
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return &transitionError{http.StatusConflict, "NO_PROVISIONAL_LIST", "no provisional ranking list for this competition"}
+				}
+				return err
+			}
+			if !now.Before(*list.AppealsCloseAt) {
+				return &transitionError{http.StatusConflict, "APPEALS_CLOSED", "appeal window is closed"}
+			}
+			var appIDs []uuid.UUID
+			if err := tx.Table("ranking_rows rr").Joins("JOIN applications a ON a.id = rr.application_id").
+				Where("rr.list_id = ? AND a.student_id = ?", list.ID, uid).
+				Pluck("rr.application_id", &appIDs).Error; err != nil {
+				return err
+			}
+			if len(appIDs) == 0 {
+				return &transitionError{http.StatusConflict, "NOT_ON_LIST", "you have no application on the provisional list"}
+			}
+			ap.ListID, ap.ApplicationID = list.ID, appIDs[0]
+			if err := tx.Create(&ap).Error; err != nil {
+				var pgErr *pgconn.PgError
+				if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+					return &transitionError{http.StatusConflict, "ALREADY_APPEALED", "you already appealed against this list"}
+				}
+				return err
+			}
+			return nil
+		})
+
+		var te *transitionError
+		switch {
+		case err == nil:
+			c.JSON(http.StatusCreated, ap)
+		case errors.As(err, &te):
+			c.JSON(te.status, gin.H{"error": te.msg, "code": te.code})
+		default:
+			log.Printf("[submitAppeal] err: %v", err)
+			jsonErr(c, http.StatusInternalServerError, "failed to submit appeal")
+		}
+	}
+}
+
+// listAppeals: student vidi svoje zalbe, staff sve (upravnik doma samo za prijave u svojim domovima).
+func listAppeals(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+		page, size, offset := pagination(c)
+		q := db.Model(&types.Appeal{}).Where("appeals.competition_id = ?", id)
+		if own, ok := callerStudentID(c, types.PermApplicationReview); ok {
+			q = q.Where("appeals.student_id = ?", own)
+		} else {
+			q = scopeToDorms(c, q.Joins("JOIN applications ON applications.id = appeals.application_id"))
+		}
+		if s := strings.ToUpper(c.Query("status")); s != "" {
+			q = q.Where("appeals.status = ?", s)
+		}
+		var cnt int64
+		if err := q.Count(&cnt).Error; err != nil {
+			jsonErr(c, http.StatusInternalServerError, "failed to count appeals")
+			return
+		}
+		var list []types.Appeal
+		if err := q.Preload("Attachments", attachmentMeta).Order("appeals.created_at").Offset(offset).Limit(size).Find(&list).Error; err != nil {
+			jsonErr(c, http.StatusInternalServerError, "failed to retrieve appeals")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"items": list, "pagination": gin.H{"page": page, "pageSize": size, "totalCount": cnt}})
+	}
+}
+
+func getAppeal(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ap, ok := loadAppealParam(c, db)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, ap)
+	}
+}
+
+func downloadAppealAttachment(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ap, ok := loadAppealParam(c, db)
+		if !ok {
+			return
+		}
+		attID, ok := parseUUID(c, "attachmentId")
+		if !ok {
+			return
+		}
+		var att types.AppealAttachment
+		if err := db.First(&att, "id = ? AND appeal_id = ?", attID, ap.ID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				jsonErr(c, http.StatusNotFound, "attachment not found")
+				return
+			}
+			jsonErr(c, http.StatusInternalServerError, "failed to fetch attachment")
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", att.FileName))
+		c.Data(http.StatusOK, att.ContentType, att.Data)
+	}
+}
+
+// reviewAppeal: SUBMITTED -> UNDER_REVIEW -> ACCEPTED | REJECTED (odluka moze i odmah iz SUBMITTED).
+func reviewAppeal(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+		var in types.AppealReviewReq
+		if err := c.ShouldBindJSON(&in); err != nil {
+			jsonErr(c, http.StatusBadRequest, "invalid json")
+			return
+		}
+		in.Decision = types.AppealStatus(strings.ToUpper(strings.TrimSpace(string(in.Decision))))
+		in.Response = strings.TrimSpace(in.Response)
+		switch in.Decision {
+		case types.AppealUnderReview:
+		case types.AppealAccepted, types.AppealRejected:
+			if in.Response == "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "response is required", "code": "REASON_REQUIRED"})
+				return
+			}
+		default:
+			jsonErr(c, http.StatusBadRequest, "decision must be UNDER_REVIEW, ACCEPTED or REJECTED")
+			return
+		}
+
+		var ap types.Appeal
+		err := db.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&ap, "id = ?", id).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return errAppealNotFound
+				}
+				return err
+			}
+			var a types.Application
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&a, "id = ?", ap.ApplicationID).Error; err != nil {
+				return err
+			}
+			if !applicationInScope(c, tx, a.ID) {
+				return errAppealNotFound
+			}
+			from := []types.AppealStatus{types.AppealSubmitted, types.AppealUnderReview}
+			if in.Decision == types.AppealUnderReview {
+				from = from[:1]
+			}
+			if !containsAppealStatus(from, ap.Status) {
+				return &transitionError{http.StatusConflict, "ILLEGAL_TRANSITION", fmt.Sprintf("cannot move an appeal from %s to %s", ap.Status, in.Decision)}
+			}
+
+			now, reviewer := time.Now().UTC(), actingUser(c)
+			ap.Status, ap.Response, ap.ReviewedBy, ap.ReviewedAt = in.Decision, in.Response, &reviewer, &now
+			if err := tx.Model(&ap).Select("status", "response", "reviewed_by", "reviewed_at").Updates(&ap).Error; err != nil {
+				return err
+			}
+			if in.Decision != types.AppealAccepted || (a.Status != types.StatusWaitlisted && a.Status != types.StatusRejected) {
+				return nil
+			}
+			req := types.TransitionReq{Transition: types.TransitionReopen, Reason: "appeal accepted: " + in.Response}
+			return applyTransition(c, tx, &a, req, transitions[req.Transition])
+		})
+
+		var te *transitionError
+		switch {
+		case err == nil:
+			c.JSON(http.StatusOK, ap)
+		case errors.Is(err, errAppealNotFound):
+			jsonErr(c, http.StatusNotFound, "appeal not found")
+		case errors.As(err, &te):
+			c.JSON(te.status, gin.H{"error": te.msg, "code": te.code})
+		default:
+			log.Printf("[reviewAppeal] err: %v", err)
+			jsonErr(c, http.StatusInternalServerError, "failed to review appeal")
+		}
+	}
+}
+
+/* ===================== Helpers ===================== */
+
+// readAttachment cita prilog i proverava velicinu i tip (po sadrzaju, ne po imenu fajla).
+func readAttachment(fh *multipart.FileHeader) (types.AppealAttachment, string) {
+	name := strings.TrimSpace(filepath.Base(fh.Filename))
+	if fh.Size > maxAppealAttachmentSize {
+		return types.AppealAttachment{}, fmt.Sprintf("attachment %q is larger than %d MB", name, maxAppealAttachmentSize>>20)
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return types.AppealAttachment{}, "failed to read attachment"
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxAppealAttachmentSize+1))
+	if err != nil || len(data) > maxAppealAttachmentSize {
+		return types.AppealAttachment{}, "failed to read attachment"
+	}
+	ct := http.DetectContentType(data)
+	if i := strings.IndexByte(ct, ';'); i >= 0 {
+		ct = ct[:i]
+	}
+	if !appealContentTypes[ct] {
+		return types.AppealAttachment{}, fmt.Sprintf("attachment %q must be PDF, JPEG or PNG", name)
+	}
+	return types.AppealAttachment{ID: uuid.New(), FileName: name, ContentType: ct, Size: int64(len(data)), Data: data}, ""
+}
+
+// attachmentMeta ucitava priloge bez sadrzaja.
+func attachmentMeta(q *gorm.DB) *gorm.DB {
+	return q.Select("id", "appeal_id", "file_name", "content_type", "size", "created_at").Order("created_at")
+}
+
+// loadAppealParam ucitava zalbu :id ako je pozivalac sme videti (vlasnik ili staff u svom domu).
+func loadAppealParam(c *gin.Context, db *gorm.DB) (types.Appeal, bool) {
+	var ap types.Appeal
+	id, ok := parseUUID(c, "id")
+	if !ok {
+		return ap, false
+	}
+	if err := db.Preload("Attachments", attachmentMeta).First(&ap, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			jsonErr(c, http.StatusNotFound, "appeal not found")
+			return ap, false
+		}
+		jsonErr(c, http.StatusInternalServerError, "failed to fetch appeal")
+		return ap, false
+	}
+	visible := false
+	if own, ok := callerStudentID(c, types.PermApplicationReview); ok {
+		visible = ap.StudentID == own
+	} else {
+		visible = applicationInScope(c, db, ap.ApplicationID)
+	}
+	if !visible {
+		jsonErr(c, http.StatusNotFound, "appeal not found")
+		return ap, false
+	}
+	return ap, true
+}
+
+func containsAppealStatus(list []types.AppealStatus, s types.AppealStatus) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,330 @@
+package student
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"student-housting/scoring"
+	"student-housting/types"
+)
+
+// Konkursi za smestaj. Prijava uvek pripada jednom konkursu, prima se samo dok je konkurs
+// otvoren, i student ima najvise jednu (nepovucenu) prijavu po konkursu. Konkurs odredjuje i
+// kriterijume bodovanja (vidi paket scoring).
+
+const maxYearOfStudy = 6
+
+var academicYearRe = regexp.MustCompile(`^(\d{4})/(\d{4})$`)
+
+func WithCompetitionAPI(r *gin.RouterGroup, db *gorm.DB, auth gin.HandlerFunc) {
+	r.GET("/competitions", listCompetitions(db)) // ?academicYear=&phase=
+	r.GET("/competitions/:id", getCompetition(db))
+	r.POST("/competitions", auth, can(types.PermCompetitionManage), createCompetition(db))
+	r.PUT("/competitions/:id", auth, can(types.PermCompetitionManage), updateCompetition(db))
+	r.DELETE("/competitions/:id", auth, can(types.PermCompetitionManage), deleteCompetition(db))
+	r.POST("/competitions/:id/extend", auth, can(types.PermCompetitionManage), extendCompetition(db))
+	r.POST("/competitions/:id/allocate", auth, can(types.PermCompetitionManage), allocateCompetition(db)) // ?dryRun=true
+	r.GET("/competitions/:id/allocations", auth, can(types.PermCompetitionManage, types.PermApplicationReview), listAllocationRuns(db))
+	r.GET("/competitions/:id/allocations/:runId", auth, can(types.PermCompetitionManage, types.PermApplicationReview), getAllocationRun(db))
+}
+
+func listCompetitions(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, size, offset := pagination(c)
+		q := db.Model(&types.Competition{})
+		if y := strings.TrimSpace(c.Query("academicYear")); y != "" {
+			q = q.Where("academic_year = ?", y)
+		}
+		now := time.Now()
+		switch types.CompetitionPhase(strings.ToUpper(c.Query("phase"))) {
+		case "":
+		case types.PhaseUpcoming:
+			q = q.Where("opens_at > ?", now)
+		case types.PhaseOpen:
+			q = q.Where("opens_at <= ? AND closes_at > ?", now, now)
+		case types.PhaseClosed:
+			q = q.Where("closes_at <= ?", now)
+		default:
+			jsonErr(c, http.StatusBadRequest, "invalid phase")
+			return
+		}
+
+		var cnt int64
+		if err := q.Count(&cnt).Error; err != nil {
+			jsonErr(c, http.StatusInternalServerError, "failed to count competitions")
+			return
+		}
+		var list []types.Competition
+		if err := q.Preload("Dorms").Order("opens_at DESC").Offset(offset).Limit(size).Find(&list).Error; err != nil {
+			jsonErr(c, http.StatusInternalServerError, "failed to retrieve competitions")
+			return
+		}
+		for i := range list {
+			list[i].Phase = list[i].PhaseAt(now)
+		}
+		c.JSON(http.StatusOK, gin.H{"items": list, "pagination": gin.H{"page": page, "pageSize": size, "totalCount": cnt}})
+	}
+}
+
+func getCompetition(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		comp, ok := loadCompetitionParam(c, db)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, comp)
+	}
+}
+
+func createCompetition(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var in types.Competition
+		if err := c.ShouldBindJSON(&in); err != nil {
+			jsonErr(c, http.StatusBadRequest, "invalid json")
+			return
+		}
+		in.ID = uuid.New()
+		if msg := validateCompetition(db, &in); msg != "" {
+			jsonErr(c, http.StatusBadRequest, msg)
+			return
+		}
+		if err := db.Create(&in).Error; err != nil {
+			log.Printf("[createCompetition] err: %v", err)
+			jsonErr(c, http.StatusInternalServerError, "failed to create competition")
+			return
+		}
+		in.Phase = in.PhaseAt(time.Now())
+		c.JSON(http.StatusCreated, in)
+	}
+}
+
+// updateCompetition zamenjuje sva polja i listu domova; prijave o kojima nije odluceno se
+// preracunavaju po novim kriterijumima.
+func updateCompetition(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		comp, ok := loadCompetitionParam(c, db)
+		if !ok {
+			return
+		}
+		var in types.Competition
+		if err := c.ShouldBindJSON(&in); err != nil {
+			jsonErr(c, http.StatusBadRequest, "invalid json")
+			return
+		}
+		in.ID, in.CreatedAt = comp.ID, comp.CreatedAt
+		if msg := validateCompetition(db, &in); msg != "" {
+			jsonErr(c, http.StatusBadRequest, msg)
+			return
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("competition_id = ?", comp.ID).Delete(&types.CompetitionDorm{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Session(&gorm.Session{FullSaveAssociations: true}).Save(&in).Error; err != nil {
+				return err
+			}
+			return rescoreCompetition(tx, &in)
+		})
+		if err != nil {
+			log.Printf("[updateCompetition] err: %v", err)
+			jsonErr(c, http.StatusInternalServerError, "failed to update competition")
+			return
+		}
+		in.Phase = in.PhaseAt(time.Now())
+		c.JSON(http.StatusOK, in)
+	}
+}
+
+// deleteCompetition brise samo konkurs na koji jos niko nije podneo prijavu.
+func deleteCompetition(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+		// I obrisane prijave (deleted_at) i dalje pokazuju na konkurs
+		var n int64
+		if err := db.Unscoped().Model(&types.Application{}).Where("competition_id = ?", id).Count(&n).Error; err != nil {
+			jsonErr(c, http.StatusInternalServerError, "failed to delete competition")
+			return
+		}
+		if n > 0 {
+			jsonErr(c, http.StatusConflict, "competition has applications")
+			return
+		}
+		if err := db.Delete(&types.Competition{}, "id = ?", id).Error; err != nil {
+			jsonErr(c, http.StatusInternalServerError, "failed to delete competition")
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// extendCompetition pomera rok za prijave; rok moze samo da se produzi, i to u buducnost.
+func extendCompetition(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		comp, ok := loadCompetitionParam(c, db)
+		if !ok {
+			return
+		}
+		var in types.ExtendDeadlineReq
+		if err := c.ShouldBindJSON(&in); err != nil || in.ClosesAt.IsZero() {
+			jsonErr(c, http.StatusBadRequest, "closesAt is required")
+			return
+		}
+		if !in.ClosesAt.After(comp.ClosesAt) || !in.ClosesAt.After(time.Now()) {
+			jsonErr(c, http.StatusConflict, "new deadline must be later than the current one and in the future")
+			return
+		}
+		if err := db.Model(&comp).Update("closes_at", in.ClosesAt).Error; err != nil {
+			jsonErr(c, http.StatusInternalServerError, "failed to extend deadline")
+			return
+		}
+		comp.Phase = comp.PhaseAt(time.Now())
+		c.JSON(http.StatusOK, comp)
+	}
+}
+
+/* ===================== Helpers ===================== */
+
+// validateCompetition normalizuje ulaz i vraca poruku o prvoj gresci ("" ako je sve u redu).
+func validateCompetition(db *gorm.DB, in *types.Competition) string {
+	in.Name = strings.TrimSpace(in.Name)
+	in.AcademicYear = strings.TrimSpace(in.AcademicYear)
+	if in.Name == "" {
+		return "name is required"
+	}
+	m := academicYearRe.FindStringSubmatch(in.AcademicYear)
+	if m == nil {
+		return "academicYear must look like 2026/2027"
+	}
+	if from, _ := strconv.Atoi(m[1]); m[2] != strconv.Itoa(from+1) {
+		return "academicYear must span two consecutive years"
+	}
+	if in.OpensAt.IsZero() || in.ClosesAt.IsZero() || !in.ClosesAt.After(in.OpensAt) {
+		return "opensAt and closesAt are required and closesAt must be after opensAt"
+	}
+
+	faculties := make([]string, 0, len(in.Faculties))
+	for _, f := range in.Faculties {
+		if f = strings.TrimSpace(f); f != "" {
+			faculties = append(faculties, f)
+		}
+	}
+	in.Faculties = faculties
+	if in.YearsOfStudy == nil {
+		in.YearsOfStudy = []int{}
+	}
+	for _, y := range in.YearsOfStudy {
+		if y < 1 || y > maxYearOfStudy {
+			return fmt.Sprintf("yearsOfStudy must be between 1 and %d", maxYearOfStudy)
+		}
+	}
+
+	quotas := make(map[string]int, len(in.FacultyQuotas))
+	for f, q := range in.FacultyQuotas {
+		if f = strings.TrimSpace(f); f == "" || q <= 0 {
+			return "facultyQuotas need a faculty name and a positive quota"
+		}
+		if len(in.Faculties) > 0 && !containsFold(in.Faculties, f) {
+			return "facultyQuotas can only name faculties of the competition"
+		}
+		quotas[f] = q
+	}
+	in.FacultyQuotas = quotas
+
+	if len(in.Criteria) == 0 {
+		in.Criteria = scoring.Default()
+	}
+	if err := scoring.Validate(in.Criteria); err != nil {
+		return err.Error()
+	}
+
+	if len(in.Dorms) == 0 {
+		return "at least one dorm is required"
+	}
+	seen := map[uuid.UUID]bool{}
+	ids := make([]uuid.UUID, 0, len(in.Dorms))
+	for i := range in.Dorms {
+		d := &in.Dorms[i]
+		if d.DormID == uuid.Nil || d.Quota <= 0 {
+			return "each dorm needs dormId and a positive quota"
+		}
+		if seen[d.DormID] {
+			return "duplicate dorm"
+		}
+		seen[d.DormID] = true
+		d.CompetitionID = in.ID
+		ids = append(ids, d.DormID)
+	}
+	var found int64
+	if err := db.Model(&types.Dorm{}).Where("id IN ?", ids).Count(&found).Error; err != nil || int(found) != len(ids) {
+		return "unknown dorm"
+	}
+	return ""
+}
+
+// validateDormPreferences: zeljeni domovi moraju biti ponudjeni na konkursu, bez ponavljanja.
+func validateDormPreferences(comp types.Competition, prefs []uuid.UUID) string {
+	offered := map[uuid.UUID]bool{}
+	for _, d := range comp.Dorms {
+		offered[d.DormID] = true
+	}
+	seen := map[uuid.UUID]bool{}
+	for _, d := range prefs {
+		if !offered[d] {
+			return "dormPreferences can only contain dorms of the competition"
+		}
+		if seen[d] {
+			return "duplicate dorm in dormPreferences"
+		}
+		seen[d] = true
+	}
+	return ""
+}
+
+func containsFold(list []string, s string) bool {
+	for _, x := range list {
+		if strings.EqualFold(strings.TrimSpace(x), strings.TrimSpace(s)) {
+			return true
+		}
+	}
+	return false
+}
+
+func loadCompetitionParam(c *gin.Context, db *gorm.DB) (types.Competition, bool) {
+	id, ok := parseUUID(c, "id")
+	if !ok {
+		return types.Competition{}, false
+	}
+	comp, err := loadCompetition(db, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			jsonErr(c, http.StatusNotFound, "competition not found")
+			return types.Competition{}, false
+		}
+		jsonErr(c, http.StatusInternalServerError, "failed to fetch competition")
+		return types.Competition{}, false
+	}
+	return comp, true
+}
+
+func loadCompetition(db *gorm.DB, id uuid.UUID) (types.Competition, error) {
+	var comp types.Competition
+	if err := db.Preload("Dorms").First(&comp, "id = ?", id).Error; err != nil {
+		return comp, err
+	}
+	comp.Phase = comp.PhaseAt(time.Now())
+	return comp, nil
+}
@@ -0,0 +1,413 @@
+package student
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"shared/middleware"
+	"student-housting/types"
+	"student-housting/upstream"
+)
+
+// Zivotni ciklus prijave. Status se menja iskljucivo tranzicijama iz tabele ispod; svaka
+// tranzicija ima dozvoljena polazna stanja, ko sme da je izvrsi i uslove (guard), i upisuje
+// se u application_transitions u istoj transakciji kao i izmena prijave.
+//
+//	DRAFT --submit--> SUBMITTED --review--> UNDER_REVIEW --accept--> ACCEPTED --reserve--> RESERVED
+//	                                        UNDER_REVIEW --waitlist--> WAITLISTED --accept--> ACCEPTED
+//	                  SUBMITTED, UNDER_REVIEW, WAITLISTED --reject--> REJECTED
+//	WAITLISTED, REJECTED --reopen--> UNDER_REVIEW (staff, npr. posle usvojene zalbe)
+//	DRAFT .. RESERVED --withdraw--> WITHDRAWN (student)     ACCEPTED, RESERVED --cancel--> CANCELLED (staff)
+
+// actorKind odredjuje ko sme da izvrsi tranziciju.
+type actorKind int
+
+const (
+	actorOwner        actorKind = iota // samo student cija je prijava
+	actorReviewer                      // application:review
+	actorOwnerOrStaff                  // vlasnik ili application:review (unos u ime studenta)
+)
+
+type transitionDef struct {
+	from  []types.ApplicationStatus
+	to    types.ApplicationStatus
+	actor actorKind
+	// guard se izvrsava u transakciji, nad zakljucanom prijavom
+	guard func(tx *gorm.DB, a *types.Application, req types.TransitionReq) error
+	// effect se izvrsava posle guard-a, pre promene statusa
+	effect func(tx *gorm.DB, a *types.Application) error
+}
+
+var transitions = map[types.Transition]transitionDef{
+	types.TransitionSubmit: {
+		from:   []types.ApplicationStatus{types.StatusDraft},
+		to:     types.StatusSubmitted,
+		actor:  actorOwnerOrStaff,
+		guard:  guardSubmittable,
+		effect: scoreApplication,
+	},
+	types.TransitionReview: {
+		from:  []types.ApplicationStatus{types.StatusSubmitted},
+		to:    types.StatusUnderReview,
+		actor: actorReviewer,
+	},
+	types.TransitionAccept: {
+		from:  []types.ApplicationStatus{types.StatusUnderReview, types.StatusWaitlisted},
+		to:    types.StatusAccepted,
+		actor: actorReviewer,
+		guard: guardNoOtherPlacement,
+	},
+	types.TransitionReject: {
+		from:  []types.ApplicationStatus{types.StatusSubmitted, types.StatusUnderReview, types.StatusWaitlisted},
+		to:    types.StatusRejected,
+		actor: actorReviewer,
+		guard: guardReason,
+	},
+	types.TransitionWaitlist: {
+		from:  []types.ApplicationStatus{types.StatusUnderReview},
+		to:    types.StatusWaitlisted,
+		actor: actorReviewer,
+	},
+	types.TransitionReopen: {
+		from:  []types.ApplicationStatus{types.StatusWaitlisted, types.StatusRejected},
+		to:    types.StatusUnderReview,
+		actor: actorReviewer,
+		guard: guardReason,
+	},
+	types.TransitionReserve: {
+		from:  []types.ApplicationStatus{types.StatusAccepted},
+		to:    types.StatusReserved,
+		actor: actorReviewer,
+		guard: guardRoomFree,
+	},
+	types.TransitionWithdraw: {
+		from: []types.ApplicationStatus{
+			types.StatusDraft, types.StatusSubmitted, types.StatusUnderReview, types.StatusWaitlisted,
+			types.StatusAccepted, types.StatusReserved,
+		},
+		to:    types.StatusWithdrawn,
+		actor: actorOwner,
+	},
+	types.TransitionCancel: {
+		from:  []types.ApplicationStatus{types.StatusAccepted, types.StatusReserved},
+		to:    types.StatusCancelled,
+		actor: actorReviewer,
+		guard: guardReason,
+	},
+}
+
+// transitionError nosi HTTP status i kod koji klijent moze da prikaze.
+type transitionError struct {
+	status int
+	code   string
+	msg    string
+}
+
+func (e *transitionError) Error() string { return e.msg }
+
+func illegalTransition(a *types.Application, t types.Transition) error {
+	return &transitionError{http.StatusConflict, "ILLEGAL_TRANSITION",
+		fmt.Sprintf("cannot %s an application in status %s", t, a.Status)}
+}
+
+func guardFailed(msg string) error {
+	return &transitionError{http.StatusConflict, "GUARD_FAILED", msg}
+}
+
+var errApplicationNotFound = errors.New("application not found")
+
+// placedStatuses su statusi u kojima student ima mesto (i krevet, ako je soba dodeljena).
+var placedStatuses = []types.ApplicationStatus{types.StatusAccepted, types.StatusReserved}
+
+// sameAcademicYear ogranicava upit nad prijavama na konkurse iste skolske godine kao konkurs
+// competitionID: mesto iz ranije godine ne zauzima ni studenta ni krevet. Prijave bez konkursa
+// (nastale pre uvodjenja konkursa) porede se samo medjusobno.
+func sameAcademicYear(competitionID *uuid.UUID) func(*gorm.DB) *gorm.DB {
+	return func(q *gorm.DB) *gorm.DB {
+		if competitionID == nil {
+			return q.Where("applications.competition_id IS NULL")
+		}
+		return q.Where(`applications.competition_id IN (SELECT id FROM competitions WHERE academic_year =
+			(SELECT academic_year FROM competitions WHERE id = ?))`, *competitionID)
+	}
+}
+
+// transitionApplication izvrsava tranziciju nad prijavom :id.
+func transitionApplication(db *gorm.DB, ac *upstream.AuthClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+		var req types.TransitionReq
+		if err := c.ShouldBindJSON(&req); err != nil {
+			jsonErr(c, http.StatusBadRequest, "invalid json")
+			return
+		}
+		req.Transition = types.Transition(strings.ToLower(strings.TrimSpace(string(req.Transition))))
+		req.Reason = strings.TrimSpace(req.Reason)
+		def, known := transitions[req.Transition]
+		if !known {
+			jsonErr(c, http.StatusBadRequest, "unknown transition")
+			return
+		}
+
+		// Profil se povlaci iz auth-a van transakcije; guard za submit ga posle samo cita
+		if req.Transition == types.TransitionSubmit {
+			var owner uint
+			if err := db.Model(&types.Application{}).Where("id = ?", id).Pluck("student_id", &owner).Error; err == nil && owner != 0 {
+				if _, err := ensureProfile(c.Request.Context(), db, ac, owner); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+					log.Printf("[transitionApplication] ensure profile err: %v", err)
+				}
+			}
+		}
+
+		var a types.Application
+		err := db.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&a, "id = ?", id).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return errApplicationNotFound
+				}
+				return err
+			}
+			if !mayTransition(c, tx, &a, def.actor) {
+				return errApplicationNotFound
+			}
+			return applyTransition(c, tx, &a, req, def)
+		})
+
+		var te *transitionError
+		switch {
+		case err == nil:
+			c.JSON(http.StatusOK, a)
+		case errors.Is(err, errApplicationNotFound):
+			jsonErr(c, http.StatusNotFound, "application not found")
+		case errors.As(err, &te):
+			c.JSON(te.status, gin.H{"error": te.msg, "code": te.code, "currentStatus": a.Status, "allowed": allowedTransitions(a.Status)})
+		default:
+			log.Printf("[transitionApplication] %s err: %v", req.Transition, err)
+			jsonErr(c, http.StatusInternalServerError, "failed to update application")
+		}
+	}
+}
+
+// listApplicationTransitions vraca istoriju prijave, od najstarije tranzicije.
+func listApplicationTransitions(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+		var a types.Application
+		if err := db.First(&a, "id = ?", id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				jsonErr(c, http.StatusNotFound, "application not found")
+				return
+			}
+			jsonErr(c, http.StatusInternalServerError, "failed to fetch application")
+			return
+		}
+		if !applicationVisible(c, db, &a) {
+			jsonErr(c, http.StatusNotFound, "application not found")
+			return
+		}
+		var items []types.ApplicationTransition
+		if err := db.Where("application_id = ?", a.ID).Order("created_at, id").Find(&items).Error; err != nil {
+			jsonErr(c, http.StatusInternalServerError, "failed to retrieve transitions")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"items": items, "allowed": allowedTransitions(a.Status)})
+	}
+}
+
+/* ===================== Helpers ===================== */
+
+// applyTransition proverava polazno stanje i guard, menja prijavu i belezi tranziciju.
+func applyTransition(c *gin.Context, tx *gorm.DB, a *types.Application, req types.TransitionReq, def transitionDef) error {
+	if !containsStatus(def.from, a.Status) {
+		return illegalTransition(a, req.Transition)
+	}
+	if def.guard != nil {
+		if err := def.guard(tx, a, req); err != nil {
+			return err
+		}
+	}
+	if def.effect != nil {
+		if err := def.effect(tx, a); err != nil {
+			return err
+		}
+	}
+	if req.Transition == types.TransitionReserve {
+		if !inDormScope(c, tx, req.RoomID) {
+			return guardFailed("room out of scope")
+		}
+		a.RoomID = req.RoomID
+	}
+
+	from := a.Status
+	a.Status = def.to
+	if err := tx.Model(a).Updates(map[string]any{"status": a.Status, "room_id": a.RoomID}).Error; err != nil {
+		return err
+	}
+	return recordTransition(c, tx, a.ID, req.Transition, from, a.Status, req.Reason)
+}
+
+// recordTransition upisuje red istorije; za nastanak prijave from je prazan.
+func recordTransition(c *gin.Context, tx *gorm.DB, appID uuid.UUID, t types.Transition, from, to types.ApplicationStatus, reason string) error {
+	return tx.Create(&types.ApplicationTransition{
+		ID:            uuid.New(),
+		ApplicationID: appID,
+		Transition:    t,
+		FromStatus:    from,
+		ToStatus:      to,
+		ActorID:       actingUser(c),
+		Reason:        reason,
+		CreatedAt:     time.Now().UTC(),
+	}).Error
+}
+
+// actingUser je ko stvarno izvrsava izmenu; kod impersonacije to je admin.
+func actingUser(c *gin.Context) uint {
+	if actor, ok := middleware.ActorID(c); ok {
+		return actor
+	}
+	uid, _ := middleware.UserID(c)
+	return uid
+}
+
+// mayTransition: vlasnik radi svoje tranzicije, staff sa application:review ostale, i to samo
+// za prijave u domovima na koje je ogranicen. Ostalima se prijava "ne vidi" (404).
+func mayTransition(c *gin.Context, db *gorm.DB, a *types.Application, kind actorKind) bool {
+	uid, _ := middleware.UserID(c)
+	owner := a.StudentID == uid
+	reviewer := middleware.HasPermission(c, types.PermApplicationReview) && applicationInScope(c, db, a.ID)
+	switch kind {
+	case actorOwner:
+		return owner
+	case actorReviewer:
+		return reviewer
+	default:
+		return owner || reviewer
+	}
+}
+
+// applicationVisible: isto pravilo kao za GET /applications/:id.
+func applicationVisible(c *gin.Context, db *gorm.DB, a *types.Application) bool {
+	if own, ok := callerStudentID(c, types.PermApplicationRead); ok {
+		return a.StudentID == own
+	}
+	return applicationInScope(c, db, a.ID)
+}
+
+func allowedTransitions(s types.ApplicationStatus) []types.Transition {
+	out := []types.Transition{}
+	for _, t := range []types.Transition{
+		types.TransitionSubmit, types.TransitionReview, types.TransitionAccept, types.TransitionWaitlist,
+		types.TransitionReject, types.TransitionReopen, types.TransitionReserve, types.TransitionWithdraw, types.TransitionCancel,
+	} {
+		if containsStatus(transitions[t].from, s) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func containsStatus(list []types.ApplicationStatus, s types.ApplicationStatus) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+/* ===================== Guards ===================== */
+
+// guardSubmittable: bez indeksa i fakulteta prijava ne moze da se rangira, a konkurs mora biti
+// otvoren i student mora ispunjavati uslove (fakultet, godina studija).
+func guardSubmittable(tx *gorm.DB, a *types.Application, _ types.TransitionReq) error {
+	var p types.Profile
+	if err := tx.First(&p, "user_id = ?", a.StudentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return guardFailed("student profile not found")
+		}
+		return err
+	}
+	if strings.TrimSpace(p.Index) == "" || strings.TrimSpace(p.Faculty) == "" {
+		return guardFailed("student profile must have index and faculty before submitting")
+	}
+	if a.CompetitionID == nil {
+		return nil
+	}
+	comp, err := loadCompetition(tx, *a.CompetitionID)
+	if err != nil {
+		return err
+	}
+	if comp.Phase != types.PhaseOpen {
+		return guardFailed("competition is not open for applications")
+	}
+	if !comp.Eligible(p.Faculty, p.YearOfStudy) {
+		return guardFailed("student is not eligible for this competition (faculty or year of study)")
+	}
+	return nil
+}
+
+// guardNoOtherPlacement: student moze imati samo jednu prihvacenu ili rezervisanu prijavu u
+// skolskoj godini; mesto iz ranije godine ne smeta.
+func guardNoOtherPlacement(tx *gorm.DB, a *types.Application, _ types.TransitionReq) error {
+	var n int64
+	if err := tx.Model(&types.Application{}).Scopes(sameAcademicYear(a.CompetitionID)).
+		Where("student_id = ? AND id <> ? AND status IN ?", a.StudentID, a.ID, placedStatuses).
+		Count(&n).Error; err != nil {
+		return err
+	}
+	if n > 0 {
+		return guardFailed("student already has an accepted application")
+	}
+	return nil
+}
+
+func guardReason(_ *gorm.DB, _ *types.Application, req types.TransitionReq) error {
+	if req.Reason == "" {
+		return &transitionError{http.StatusBadRequest, "REASON_REQUIRED", "reason is required"}
+	}
+	return nil
+}
+
+// guardRoomFree zakljucava sobu, pa dve paralelne rezervacije ne mogu obe da prodju kapacitet.
+// Krevet zauzimaju i prihvacene prijave kojima je soba dodeljena raspodelom, i to samo iz iste
+// skolske godine kao prijava.
+func guardRoomFree(tx *gorm.DB, a *types.Application, req types.TransitionReq) error {
+	if req.RoomID == nil {
+		return &transitionError{http.StatusBadRequest, "ROOM_REQUIRED", "roomId is required"}
+	}
+	var r types.Room
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&r, "id = ?", *req.RoomID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return guardFailed("room not found")
+		}
+		return err
+	}
+	if !r.Available {
+		return guardFailed("room is not available")
+	}
+	var taken int64
+	if err := tx.Model(&types.Application{}).Scopes(sameAcademicYear(a.CompetitionID)).
+		Where("room_id = ? AND id <> ? AND status IN ?", r.ID, a.ID, placedStatuses).
+		Count(&taken).Error; err != nil {
+		return err
+	}
+	if int(taken) >= r.Capacity {
+		return guardFailed("room is full")
+	}
+	return nil
+}
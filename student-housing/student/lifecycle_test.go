@@ -0,0 +1,80 @@
+package student
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"student-housting/types"
+)
+
+func placedApp(studentID uint, comp *types.Competition, status types.ApplicationStatus, roomID *uuid.UUID) *types.Application {
+	a := &types.Application{ID: uuid.New(), StudentID: studentID, Status: status, RoomID: roomID, DormPreferences: []uuid.UUID{}}
+	if comp != nil {
+		a.CompetitionID = &comp.ID
+	}
+	return a
+}
+
+func wantGuard(t *testing.T, err error, failed bool) {
+	t.Helper()
+	var te *transitionError
+	switch {
+	case !failed && err != nil:
+		t.Fatalf("guard failed: %v", err)
+	case failed && (!errors.As(err, &te) || te.code != "GUARD_FAILED"):
+		t.Fatalf("err = %v, want GUARD_FAILED", err)
+	}
+}
+
+func TestGuardNoOtherPlacementPerAcademicYear(t *testing.T) {
+	db := newTestDB(t)
+	lastYear := seedCompetition(t, db, "2025/2026", true, nil)
+	firstRound := seedCompetition(t, db, "2026/2027", true, nil)
+	secondRound := seedCompetition(t, db, "2026/2027", true, nil)
+
+	// Student 1 je stanovao prosle godine, student 2 je vec primljen u prvom krugu ove godine
+	mustCreate(t, db,
+		placedApp(1, lastYear, types.StatusReserved, nil),
+		placedApp(2, firstRound, types.StatusAccepted, nil),
+		placedApp(3, nil, types.StatusReserved, nil),
+	)
+
+	tests := []struct {
+		name    string
+		student uint
+		failed  bool
+	}{
+		{"placement from an earlier year", 1, false},
+		{"placement in the same year", 2, true},
+		{"placement without a competition", 3, false},
+		{"no placement", 4, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := placedApp(tt.student, secondRound, types.StatusUnderReview, nil)
+			mustCreate(t, db, a)
+			wantGuard(t, guardNoOtherPlacement(db, a, types.TransitionReq{}), tt.failed)
+		})
+	}
+}
+
+func TestGuardRoomFreePerAcademicYear(t *testing.T) {
+	db := newTestDB(t)
+	lastYear := seedCompetition(t, db, "2025/2026", true, nil)
+	thisYear := seedCompetition(t, db, "2026/2027", true, nil)
+
+	dorm := &types.Dorm{ID: uuid.New(), Name: "Dom A", Address: "Adresa 1"}
+	oldRoom := &types.Room{ID: uuid.New(), DormID: dorm.ID, Number: "101", Capacity: 1, Available: true}
+	fullRoom := &types.Room{ID: uuid.New(), DormID: dorm.ID, Number: "102", Capacity: 1, Available: true}
+	mustCreate(t, db, dorm, oldRoom, fullRoom,
+		placedApp(1, lastYear, types.StatusReserved, &oldRoom.ID),
+		placedApp(2, thisYear, types.StatusReserved, &fullRoom.ID),
+	)
+
+	a := placedApp(3, thisYear, types.StatusAccepted, nil)
+	mustCreate(t, db, a)
+	wantGuard(t, guardRoomFree(db, a, types.TransitionReq{RoomID: &oldRoom.ID}), false)
+	wantGuard(t, guardRoomFree(db, a, types.TransitionReq{RoomID: &fullRoom.ID}), true)
+}
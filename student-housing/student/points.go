@@ -0,0 +1,72 @@
+package student
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"student-housting/scoring"
+	"student-housting/types"
+)
+
+// Bodovi prijave se ne unose rucno. Racunaju se iz podataka koje student prilaze (Evidence),
+// godine studija iz profila i kriterijuma konkursa, i to pri podnosenju, pri svakoj izmeni
+// podataka i pri izmeni kriterijuma konkursa. Uz bodove se cuva obracun po kriterijumima.
+// Posle odluke (ACCEPTED i dalje) bodovi se vise ne menjaju.
+
+// scoringStatuses su statusi u kojima se bodovi jos preracunavaju.
+var scoringStatuses = []types.ApplicationStatus{types.StatusDraft, types.StatusSubmitted, types.StatusUnderReview}
+
+// criteriaFor vraca kriterijume konkursa prijave (Default za prijave bez konkursa).
+func criteriaFor(tx *gorm.DB, a *types.Application) ([]scoring.Criterion, error) {
+	if a.CompetitionID == nil {
+		return scoring.Default(), nil
+	}
+	var comp types.Competition
+	if err := tx.Select("criteria").First(&comp, "id = ?", *a.CompetitionID).Error; err != nil {
+		return nil, err
+	}
+	if len(comp.Criteria) == 0 {
+		return scoring.Default(), nil
+	}
+	return comp.Criteria, nil
+}
+
+// scoreApplication preracunava bodove prijave i upisuje ih zajedno sa obracunom.
+func scoreApplication(tx *gorm.DB, a *types.Application) error {
+	criteria, err := criteriaFor(tx, a)
+	if err != nil {
+		return err
+	}
+	return scoreWith(tx, a, criteria)
+}
+
+func scoreWith(tx *gorm.DB, a *types.Application, criteria []scoring.Criterion) error {
+	var p types.Profile
+	if err := tx.Select("year_of_study").First(&p, "user_id = ?", a.StudentID).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	in := scoring.Input{YearOfStudy: p.YearOfStudy}
+	if a.Evidence != nil {
+		in.Evidence = *a.Evidence
+	}
+	b := scoring.Score(criteria, in, time.Now())
+	a.Points, a.ScoreBreakdown = b.Total, &b
+	// Updates sa strukturom (a ne mapom) da bi se primenio json serializer
+	return tx.Model(a).Select("points", "score_breakdown").Updates(a).Error
+}
+
+// rescoreCompetition preracunava sve prijave konkursa o kojima jos nije odluceno.
+func rescoreCompetition(tx *gorm.DB, comp *types.Competition) error {
+	var list []types.Application
+	if err := tx.Where("competition_id = ? AND status IN ?", comp.ID, scoringStatuses).Find(&list).Error; err != nil {
+		return err
+	}
+	for i := range list {
+		if err := scoreWith(tx, &list[i], comp.Criteria); err != nil {
+			return err
+		}
+	}
+	return nil
+}
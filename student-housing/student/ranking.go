@@ -0,0 +1,339 @@
+package student
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"student-housting/types"
+)
+
+// Objavljene rang liste. Posle raspodele staff objavljuje privremenu listu sa rokom za zalbe
+// (vidi appeal.go); kada rok istekne i sve zalbe su resene, objavljuje se konacna lista. Svaka
+// objava je nova verzija sa snimkom ishoda konkursa (vidi competitionOutcome) i posle se ne
+// menja. Liste su javne i pseudonimizovane: rang, broj indeksa i bodovi. PDF istih lista pravi
+// open-data servis.
+
+const defaultAppealWindow = 72 * time.Hour
+
+func WithRankingAPI(r *gin.RouterGroup, db *gorm.DB, auth gin.HandlerFunc) {
+	r.GET("/competitions/:id/rankings", listRankingLists(db))        // ?kind=
+	r.GET("/competitions/:id/rankings/:version", getRankingList(db)) // :version je broj ili "latest" (?kind=)
+	r.GET("/competitions/:id/rankings/:version/ranking.csv", rankingCSV(db))
+	r.POST("/competitions/:id/rankings", auth, can(types.PermCompetitionManage), publishRankingList(db))
+}
+
+func listRankingLists(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+		page, size, offset := pagination(c)
+		q := db.Model(&types.RankingList{}).Where("competition_id = ?", id)
+		if k := strings.ToUpper(c.Query("kind")); k != "" {
+			q = q.Where("kind = ?", k)
+		}
+		var cnt int64
+		if err := q.Count(&cnt).Error; err != nil {
+			jsonErr(c, http.StatusInternalServerError, "failed to count ranking lists")
+			return
+		}
+		var list []types.RankingList
+		if err := q.Order("version DESC").Offset(offset).Limit(size).Find(&list).Error; err != nil {
+			jsonErr(c, http.StatusInternalServerError, "failed to retrieve ranking lists")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"items": list, "pagination": gin.H{"page": page, "pageSize": size, "totalCount": cnt}})
+	}
+}
+
+func getRankingList(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		list, ok := loadRankingParam(c, db)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, list)
+	}
+}
+
+func rankingCSV(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		list, ok := loadRankingParam(c, db)
+		if !ok {
+			return
+		}
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="ranking-%s-v%d.csv"`, strings.ToLower(string(list.Kind)), list.Version))
+		w := csv.NewWriter(c.Writer)
+		_ = w.Write([]string{"rank", "index", "points"})
+		for _, r := range list.Rows {
+			_ = w.Write([]string{strconv.Itoa(r.Rank), csvSafe(r.Index), strconv.FormatFloat(r.Points, 'f', 2, 64)})
+		}
+		w.Flush()
+	}
+}
+
+// publishRankingList objavljuje novu verziju liste iz raspodele (zadate ili poslednje).
+// Privremena lista otvara rok za zalbe; konacna se objavljuje tek kada rok istekne i sve
+// zalbe su resene, a posle usvojene zalbe samo iz raspodele novije od privremene liste.
+func publishRankingList(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseUUID(c, "id")
+		if !ok {
+			return
+		}
+		var in types.PublishRankingReq
+		if err := c.ShouldBindJSON(&in); err != nil {
+			jsonErr(c, http.StatusBadRequest, "invalid json")
+			return
+		}
+		in.Kind = types.RankingKind(strings.ToUpper(strings.TrimSpace(string(in.Kind))))
+		if in.Kind != types.RankingProvisional && in.Kind != types.RankingFinal {
+			jsonErr(c, http.StatusBadRequest, "kind must be PROVISIONAL or FINAL")
+			return
+		}
+
+		var list types.RankingList
+		err := db.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+			// Zakljucan konkurs cuva redosled verzija
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&types.Competition{}, "id = ?", id).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return errCompetitionNotFound
+				}
+				return err
+			}
+			comp, err := loadCompetition(tx, id)
+			if err != nil {
+				return err
+			}
+			if comp.Phase != types.PhaseClosed {
+				return &transitionError{http.StatusConflict, "COMPETITION_OPEN", "competition is still open"}
+			}
+
+			var run types.AllocationRun
+			q := tx.Where("competition_id = ?", comp.ID)
+			if in.RunID != nil {
+				q = q.Where("id = ?", *in.RunID)
+			}
+			if err := q.Order("created_at DESC").First(&run).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return &transitionError{http.StatusConflict, "NO_ALLOCATION", "no allocation run to publish"}
+				}
+				return err
+			}
+
+			now := time.Now().UTC()
+			list = types.RankingList{ID: uuid.New(), CompetitionID: comp.ID, Kind: in.Kind, RunID: run.ID, PublishedBy: actingUser(c), PublishedAt: now}
+			if in.Kind == types.RankingProvisional {
+				err = checkProvisionalPublishable(tx, comp.ID, now, in.AppealsCloseAt)
+				closes := now.Add(defaultAppealWindow)
+				if in.AppealsCloseAt != nil {
+					closes = in.AppealsCloseAt.UTC()
+				}
+				list.AppealsCloseAt = &closes
+			} else {
+				err = checkFinalPublishable(tx, comp.ID, now, run)
+			}
+			if err != nil {
+				return err
+			}
+
+			if err := tx.Model(&types.RankingList{}).Where("competition_id = ?", comp.ID).
+				Select("COALESCE(MAX(version), 0) + 1").Scan(&list.Version).Error; err != nil {
+				return err
+			}
+			rows, err := competitionOutcome(tx, run)
+			if err != nil {
+				return err
+			}
+			for i := range rows {
+				rows[i].ListID = list.ID
+			}
+			if err := tx.Omit("Rows").Create(&list).Error; err != nil {
+				return err
+			}
+			list.Rows = rows
+			if len(rows) == 0 {
+				return nil
+			}
+			return tx.CreateInBatches(rows, 1000).Error
+		})
+
+		var te *transitionError
+		switch {
+		case err == nil:
+			c.JSON(http.StatusCreated, list)
+		case errors.Is(err, errCompetitionNotFound):
+			jsonErr(c, http.StatusNotFound, "competition not found")
+		case errors.As(err, &te):
+			c.JSON(te.status, gin.H{"error": te.msg, "code": te.code})
+		default:
+			log.Printf("[publishRankingList] err: %v", err)
+			jsonErr(c, http.StatusInternalServerError, "failed to publish ranking list")
+		}
+	}
+}
+
+/* ===================== Helpers ===================== */
+
+// checkProvisionalPublishable: posle konacne liste nema novih privremenih, a rok za zalbe je u buducnosti.
+func checkProvisionalPublishable(tx *gorm.DB, competitionID uuid.UUID, now time.Time, closes *time.Time) error {
+	var finals int64
+	if err := tx.Model(&types.RankingList{}).Where("competition_id = ? AND kind = ?", competitionID, types.RankingFinal).Count(&finals).Error; err != nil {
+		return err
+	}
+	if finals > 0 {
+		return &transitionError{http.StatusConflict, "FINAL_PUBLISHED", "final list is already published"}
+	}
+	if closes != nil && !closes.After(now) {
+		return &transitionError{http.StatusBadRequest, "INVALID_DEADLINE", "appealsCloseAt must be in the future"}
+	}
+	return nil
+}
+
+func checkFinalPublishable(tx *gorm.DB, competitionID uuid.UUID, now time.Time, run types.AllocationRun) error {
+	prov, err := latestProvisional(tx, competitionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &transitionError{http.StatusConflict, "NO_PROVISIONAL_LIST", "publish a provisional list first"}
+		}
+		return err
+	}
+	if now.Before(*prov.AppealsCloseAt) {
+		return &transitionError{http.StatusConflict, "APPEALS_OPEN", "appeal window is still open"}
+	}
+	var pending, accepted int64
+	if err := tx.Model(&types.Appeal{}).Where("competition_id = ? AND status IN ?", competitionID,
+		[]types.AppealStatus{types.AppealSubmitted, types.AppealUnderReview}).Count(&pending).Error; err != nil {
+		return err
+	}
+	if pending > 0 {
+		return &transitionError{http.StatusConflict, "APPEALS_PENDING", "all appeals must be decided first"}
+	}
+	if err := tx.Model(&types.Appeal{}).Where("list_id = ? AND status = ?", prov.ID, types.AppealAccepted).Count(&accepted).Error; err != nil {
+		return err
+	}
+	if accepted > 0 && !run.CreatedAt.After(prov.PublishedAt) {
+		return &transitionError{http.StatusConflict, "STALE_ALLOCATION", "accepted appeals require a new allocation run"}
+	}
+	return nil
+}
+
+// competitionOutcome vraca redove liste za ishod konkursa zakljucno sa raspodelom run. Ponovljena
+// raspodela (posle zalbi) rangira samo prijave koje jos cekaju, pa za svaku prijavu vazi rezultat
+// poslednje raspodele koja ju je rangirala; prihvaceni i odbijeni iz ranijih ostaju na listi.
+func competitionOutcome(tx *gorm.DB, run types.AllocationRun) ([]types.RankingRow, error) {
+	var results []types.AllocationResult
+	if err := tx.Table("allocation_results r").Select("r.*").
+		Joins("JOIN allocation_runs ar ON ar.id = r.run_id").
+		Where("ar.competition_id = ? AND ar.created_at <= ?", run.CompetitionID, run.CreatedAt).
+		Order("ar.created_at, r.rank").Scan(&results).Error; err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, nil
+	}
+	appIDs := make([]uuid.UUID, 0, len(results))
+	studentIDs := make([]uint, 0, len(results))
+	for _, r := range results {
+		appIDs = append(appIDs, r.ApplicationID)
+		studentIDs = append(studentIDs, r.StudentID)
+	}
+	var apps []types.Application
+	if err := tx.Where("id IN ?", appIDs).Find(&apps).Error; err != nil {
+		return nil, err
+	}
+	var profiles []types.Profile
+	if err := tx.Where("user_id IN ?", studentIDs).Find(&profiles).Error; err != nil {
+		return nil, err
+	}
+	index := map[uint]string{}
+	for _, p := range profiles {
+		index[p.UserID] = p.Index
+	}
+	return outcomeRows(results, apps, index), nil
+}
+
+// outcomeRows spaja rezultate raspodela (od najstarije) u jedan rang: poslednji rezultat prijave
+// vazi, obrisane prijave (kojih nema u apps) otpadaju, a redosled je isti kao u raspodeli.
+func outcomeRows(results []types.AllocationResult, apps []types.Application, index map[uint]string) []types.RankingRow {
+	latest := map[uuid.UUID]types.AllocationResult{}
+	for _, r := range results {
+		latest[r.ApplicationID] = r
+	}
+	ranked := make([]types.Application, 0, len(apps))
+	for _, a := range apps {
+		r, ok := latest[a.ID]
+		if !ok {
+			continue
+		}
+		a.Points = r.Points
+		ranked = append(ranked, a)
+	}
+	sort.Slice(ranked, func(i, j int) bool { return rankedBefore(&ranked[i], &ranked[j]) })
+
+	rows := make([]types.RankingRow, 0, len(ranked))
+	for i, a := range ranked {
+		rows = append(rows, types.RankingRow{Rank: i + 1, Index: index[a.StudentID], Points: a.Points, ApplicationID: a.ID})
+	}
+	return rows
+}
+
+func latestProvisional(tx *gorm.DB, competitionID uuid.UUID) (types.RankingList, error) {
+	var l types.RankingList
+	err := tx.Where("competition_id = ? AND kind = ?", competitionID, types.RankingProvisional).Order("version DESC").First(&l).Error
+	return l, err
+}
+
+// loadRankingParam ucitava listu :version konkursa :id sa redovima; "latest" je poslednja verzija (?kind=).
+func loadRankingParam(c *gin.Context, db *gorm.DB) (types.RankingList, bool) {
+	var list types.RankingList
+	id, ok := parseUUID(c, "id")
+	if !ok {
+		return list, false
+	}
+	q := db.Preload("Rows", func(q *gorm.DB) *gorm.DB { return q.Order("rank") }).Where("competition_id = ?", id)
+	if v := c.Param("version"); v == "latest" {
+		if k := strings.ToUpper(c.Query("kind")); k != "" {
+			q = q.Where("kind = ?", k)
+		}
+		q = q.Order("version DESC")
+	} else {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			jsonErr(c, http.StatusBadRequest, "invalid version")
+			return list, false
+		}
+		q = q.Where("version = ?", n)
+	}
+	if err := q.First(&list).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			jsonErr(c, http.StatusNotFound, "ranking list not found")
+			return list, false
+		}
+		jsonErr(c, http.StatusInternalServerError, "failed to fetch ranking list")
+		return list, false
+	}
+	return list, true
+}
+
+// csvSafe sprecava da Excel vrednost koja pocinje sa = + - @ protumaci kao formulu.
+func csvSafe(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
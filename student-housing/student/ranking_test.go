@@ -0,0 +1,37 @@
+package student
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"student-housting/types"
+)
+
+func TestOutcomeRowsKeepsEarlierResults(t *testing.T) {
+	accepted, rejected, appealed, deleted := app(1, 80), app(2, 70), app(3, 60), app(4, 90)
+	first := uuid.New()
+	second := uuid.New()
+	results := []types.AllocationResult{
+		// prva raspodela rangira sve
+		{RunID: first, ApplicationID: deleted.ID, StudentID: 4, Rank: 1, Points: 90, Outcome: types.OutcomeAccepted},
+		{RunID: first, ApplicationID: accepted.ID, StudentID: 1, Rank: 2, Points: 80, Outcome: types.OutcomeAccepted},
+		{RunID: first, ApplicationID: rejected.ID, StudentID: 2, Rank: 3, Points: 70, Outcome: types.OutcomeRejected},
+		{RunID: first, ApplicationID: appealed.ID, StudentID: 3, Rank: 4, Points: 60, Outcome: types.OutcomeWaitlisted},
+		// posle usvojene zalbe ponovljena raspodela vidi samo prijavu 3, sa novim bodovima
+		{RunID: second, ApplicationID: appealed.ID, StudentID: 3, Rank: 1, Points: 75, Outcome: types.OutcomeAccepted},
+	}
+	apps := []types.Application{rejected, appealed, accepted} // prijava 4 je obrisana
+	index := map[uint]string{1: "1/2025", 2: "2/2025", 3: "3/2025"}
+
+	got := outcomeRows(results, apps, index)
+	want := []types.RankingRow{
+		{Rank: 1, Index: "1/2025", Points: 80, ApplicationID: accepted.ID},
+		{Rank: 2, Index: "3/2025", Points: 75, ApplicationID: appealed.ID},
+		{Rank: 3, Index: "2/2025", Points: 70, ApplicationID: rejected.ID},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("rows = %+v\nwant %+v", got, want)
+	}
+}
@@ -1,6 +1,8 @@
 package student
 
 import (
+	"database/sql"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -9,10 +11,14 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
+	"shared/middleware"
+	"student-housting/scoring"
 	"student-housting/types"
+	"student-housting/upstream"
 )
 
 /* ===================== Helpers ===================== */
@@ -44,286 +50,248 @@ func jsonErr(c *gin.Context, code int, msg string) {
 	c.JSON(code, gin.H{"error": msg})
 }
 
-func isValidStatus(s types.ApplicationStatus) bool {
-	switch s {
-	case types.StatusSubmitted, types.StatusAccepted, types.StatusRejected, types.StatusReserved:
-		return true
+// callerStudentID vraca id pozivaoca ako nema dozvolu perm, tj. sme da vidi samo svoje zapise.
+func callerStudentID(c *gin.Context, perm types.Permission) (uint, bool) {
+	if middleware.HasPermission(c, perm) {
+		return 0, false
 	}
-	return false
+	return middleware.UserID(c)
 }
 
-/* ===================== STUDENT ===================== */
-
-func getUsers(db *gorm.DB) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		var students []types.User
-		var totalCount int64
+// applicationInDormsSQL: prijava pripada domovima @dorms ako je tamo njena soba. Prijava bez
+// sobe (pre raspodele) pripada domovima iz svojih zelja, a bez zelja svim domovima konkursa.
+const applicationInDormsSQL = `(applications.room_id IN (SELECT id FROM rooms WHERE dorm_id IN @dorms)
+	OR (applications.room_id IS NULL AND (
+		EXISTS (SELECT 1 FROM jsonb_array_elements_text(applications.dorm_preferences) AS p(dorm_id) WHERE p.dorm_id IN @dorms)
+		OR (jsonb_array_length(applications.dorm_preferences) = 0 AND EXISTS (
+			SELECT 1 FROM competition_dorms cd WHERE cd.competition_id = applications.competition_id AND cd.dorm_id IN @dorms)))))`
 
-		searchQuery := c.DefaultQuery("name", "")
-		page, pageSize, offset := pagination(c)
+// scopeToDorms ogranicava upit nad prijavama na domove pozivaoca (upravnik doma).
+func scopeToDorms(c *gin.Context, q *gorm.DB) *gorm.DB {
+	dorms, scoped := middleware.DormScope(c)
+	if !scoped {
+		return q
+	}
+	return q.Where(applicationInDormsSQL, sql.Named("dorms", dorms))
+}
 
-		if searchQuery != "" {
-			// Requires pg_trgm extension for similarity(); otherwise replace with ILIKE.
-			err := db.Raw(`
-				SELECT * FROM users
-				WHERE similarity(first_name, ?) > 0.3 OR similarity(last_name, ?) > 0.3
-				ORDER BY GREATEST(similarity(first_name, ?), similarity(last_name, ?)) DESC
-				LIMIT ? OFFSET ?`,
-				searchQuery, searchQuery, searchQuery, searchQuery, pageSize, offset,
-			).Scan(&students).Error
-			if err != nil {
-				jsonErr(c, http.StatusInternalServerError, "failed to retrieve students")
-				return
-			}
-			if err := db.Raw(`
-				SELECT COUNT(*) FROM students
-				WHERE similarity(first_name, ?) > 0.3 OR similarity(last_name, ?) > 0.3`,
-				searchQuery, searchQuery,
-			).Scan(&totalCount).Error; err != nil {
-				jsonErr(c, http.StatusInternalServerError, "failed to count students")
-				return
-			}
-		} else {
-			if err := db.Model(&types.User{}).Count(&totalCount).Error; err != nil {
-				jsonErr(c, http.StatusInternalServerError, "failed to count students")
-				return
-			}
-			if err := db.Offset(offset).Limit(pageSize).Find(&students).Error; err != nil {
-				jsonErr(c, http.StatusInternalServerError, "failed to retrieve students")
-				return
-			}
+// applicationInScope: prijava je u domovima na koje je pozivalac ogranicen, po istom pravilu kao
+// applicationInDormsSQL (soba, pa zelje, pa domovi konkursa).
+func applicationInScope(c *gin.Context, db *gorm.DB, applicationID uuid.UUID) bool {
+	dorms, scoped := middleware.DormScope(c)
+	if !scoped {
+		return true
+	}
+	var a types.Application
+	if err := db.Select("id", "room_id", "competition_id", "dorm_preferences").First(&a, "id = ?", applicationID).Error; err != nil {
+		return false
+	}
+	if a.RoomID != nil {
+		return inDormScope(c, db, a.RoomID)
+	}
+	for _, d := range a.DormPreferences {
+		if dormInScope(c, d) {
+			return true
 		}
+	}
+	if len(a.DormPreferences) > 0 || a.CompetitionID == nil {
+		return false
+	}
+	var n int64
+	if err := db.Model(&types.CompetitionDorm{}).Where("competition_id = ? AND dorm_id IN ?", *a.CompetitionID, dorms).Count(&n).Error; err != nil {
+		return false
+	}
+	return n > 0
+}
 
-		c.JSON(http.StatusOK, gin.H{
-			"students":   students,
-			"pagination": gin.H{"page": page, "pageSize": pageSize, "totalCount": totalCount},
-		})
+// inDormScope proverava da li soba pripada domu na koji je pozivalac ogranicen.
+func inDormScope(c *gin.Context, db *gorm.DB, roomID *uuid.UUID) bool {
+	dorms, scoped := middleware.DormScope(c)
+	if !scoped {
+		return true
+	}
+	if roomID == nil {
+		return false
+	}
+	var n int64
+	if err := db.Model(&types.Room{}).Where("id = ? AND dorm_id IN ?", *roomID, dorms).Count(&n).Error; err != nil {
+		return false
+	}
+	return n > 0
+}
+
+func dormInScope(c *gin.Context, dormID uuid.UUID) bool {
+	dorms, scoped := middleware.DormScope(c)
+	if !scoped {
+		return true
+	}
+	for _, d := range dorms {
+		if d == dormID.String() {
+			return true
+		}
 	}
+	return false
 }
 
+/* ===================== STUDENT ===================== */
+
+// Nalozi (lozinka, email, ime, uloga) pripadaju auth servisu; ovde se cuva samo
+// profil (indeks, fakultet) i kopija identiteta koju odrzava SyncProfiles.
+
+// getStudents lista studentske profile; listu svih naloga daje auth (GET /users).
 func getStudents(db *gorm.DB) gin.HandlerFunc {
+	return listProfiles(db, types.StudentRole)
+}
+
+func listProfiles(db *gorm.DB, role types.Role) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var students []types.User
+		var profiles []types.Profile
 		var totalCount int64
 
 		searchQuery := c.DefaultQuery("name", "")
 		page, pageSize, offset := pagination(c)
 
+		q := db.Model(&types.Profile{})
+		if role != "" {
+			q = q.Where("role = ?", role)
+		}
 		if searchQuery != "" {
 			// Requires pg_trgm extension for similarity(); otherwise replace with ILIKE.
-			err := db.Raw(`
-				SELECT * FROM users
-				WHERE similarity(first_name, ?) > 0.3 OR similarity(last_name, ?) > 0.3 AND role = 'STUDENT'
-				ORDER BY GREATEST(similarity(first_name, ?), similarity(last_name, ?)) DESC
-				LIMIT ? OFFSET ?`,
-				searchQuery, searchQuery, searchQuery, searchQuery, pageSize, offset,
-			).Scan(&students).Error
-			if err != nil {
-				jsonErr(c, http.StatusInternalServerError, "failed to retrieve students")
-				return
-			}
-			if err := db.Raw(`
-				SELECT COUNT(*) FROM students
-				WHERE similarity(first_name, ?) > 0.3 OR similarity(last_name, ?) > 0.3`,
-				searchQuery, searchQuery,
-			).Scan(&totalCount).Error; err != nil {
-				jsonErr(c, http.StatusInternalServerError, "failed to count students")
-				return
-			}
+			q = q.Where("similarity(first_name, ?) > 0.3 OR similarity(last_name, ?) > 0.3", searchQuery, searchQuery)
+		}
+		if err := q.Count(&totalCount).Error; err != nil {
+			jsonErr(c, http.StatusInternalServerError, "failed to count students")
+			return
+		}
+
+		if searchQuery != "" {
+			q = q.Order(clause.Expr{
+				SQL:  "GREATEST(similarity(first_name, ?), similarity(last_name, ?)) DESC",
+				Vars: []any{searchQuery, searchQuery},
+			})
 		} else {
-			if err := db.Model(&types.User{}).Count(&totalCount).Error; err != nil {
-				jsonErr(c, http.StatusInternalServerError, "failed to count students")
-				return
-			}
-			if err := db.Offset(offset).Limit(pageSize).Find(&students).Error; err != nil {
-				jsonErr(c, http.StatusInternalServerError, "failed to retrieve students")
-				return
-			}
+			q = q.Order("user_id")
+		}
+		if err := q.Offset(offset).Limit(pageSize).Find(&profiles).Error; err != nil {
+			jsonErr(c, http.StatusInternalServerError, "failed to retrieve students")
+			return
 		}
 
 		c.JSON(http.StatusOK, gin.H{
-			"students":   students,
+			"students":   profiles,
 			"pagination": gin.H{"page": page, "pageSize": pageSize, "totalCount": totalCount},
 		})
 	}
 }
 
-func getStudentByID(db *gorm.DB) gin.HandlerFunc {
+func getStudentByID(db *gorm.DB, ac *upstream.AuthClient) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id, ok := parseUintParam(c, "id")
 		if !ok {
 			return
 		}
-		var s types.User
-		if err := db.First(&s, "id = ?", id).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
+		p, err := ensureProfile(c.Request.Context(), db, ac, id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
 				jsonErr(c, http.StatusNotFound, "student not found")
 				return
 			}
+			log.Printf("[getStudentByID] err: %v", err)
 			jsonErr(c, http.StatusInternalServerError, "failed to fetch student")
 			return
 		}
-		c.JSON(http.StatusOK, s)
-	}
-}
-
-type updateRoleReq struct {
-	Role string `json:"role"` // "ADMIN" | "STUDENT" | "TEACHER"
-}
-
-func isValidRole(r string) bool {
-	switch strings.ToUpper(strings.TrimSpace(r)) {
-	case string(types.AdminRole), string(types.StudentRole), string(types.TeacherRole):
-		return true
-	default:
-		return false
+		c.JSON(http.StatusOK, p)
 	}
 }
 
-func UpdateUserRole(db *gorm.DB) gin.HandlerFunc {
+// createStudent pravi profil za postojeci nalog; nalozi se prave iskljucivo u auth servisu.
+func createStudent(db *gorm.DB, ac *upstream.AuthClient) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		id := c.Param("id")
-		var body updateRoleReq
-		if err := c.ShouldBindJSON(&body); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+		var in types.ProfileReq
+		if err := c.ShouldBindJSON(&in); err != nil {
+			jsonErr(c, http.StatusBadRequest, "invalid json")
 			return
 		}
-		if !isValidRole(body.Role) {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid role"})
+		if in.UserID == 0 {
+			jsonErr(c, http.StatusBadRequest, "userId is required")
 			return
 		}
-		newRole := types.Role(strings.ToUpper(body.Role))
 
-		var u types.User
-		if err := db.First(&u, "id = ?", id).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		p, err := ensureProfile(c.Request.Context(), db, ac, in.UserID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				jsonErr(c, http.StatusUnprocessableEntity, "unknown user; create the account in auth first")
 				return
 			}
-			log.Printf("[UpdateUserRole] load user err: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load user"})
-			return
-		}
-
-		// Update role
-		if err := db.Model(&types.User{}).
-			Where("id = ?", id).
-			Update("role", newRole).Error; err != nil {
-			log.Printf("[UpdateUserRole] update err: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update role"})
-			return
-		}
-
-		u.Role = newRole
-		c.JSON(http.StatusOK, u)
-	}
-}
-
-func createStudent(db *gorm.DB) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		var s types.User
-		if err := c.ShouldBindJSON(&s); err != nil {
-			jsonErr(c, http.StatusBadRequest, "invalid json")
-			return
-		}
-		if strings.TrimSpace(s.FirstName) == "" || strings.TrimSpace(s.LastName) == "" {
-			jsonErr(c, http.StatusBadRequest, "firstName and lastName are required")
+			log.Printf("[createStudent] err: %v", err)
+			jsonErr(c, http.StatusInternalServerError, "failed to create student")
 			return
 		}
 
-		// Ako nije setovan role, postavi default na STUDENT
-		if strings.TrimSpace(string(s.Role)) == "" {
-			s.Role = types.StudentRole
-		}
-
-		if err := db.Create(&s).Error; err != nil {
+		p.Index, p.Faculty, p.YearOfStudy = in.Index, in.Faculty, in.YearOfStudy
+		if err := db.Model(&p).Updates(map[string]any{"index": p.Index, "faculty": p.Faculty, "year_of_study": p.YearOfStudy}).Error; err != nil {
 			jsonErr(c, http.StatusInternalServerError, "failed to create student")
 			return
 		}
-		c.JSON(http.StatusCreated, s)
+		c.JSON(http.StatusCreated, p)
 	}
 }
 
-func updateStudent(db *gorm.DB) gin.HandlerFunc {
+// updateStudent menja samo studentske podatke; ime i email se menjaju preko auth-a (PATCH /me).
+// Podatke o upisu menja studentska sluzba (student:write); student ih samo vidi.
+func updateStudent(db *gorm.DB, ac *upstream.AuthClient) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id, ok := parseUintParam(c, "id")
 		if !ok {
 			return
 		}
-		var in types.User
+		var in types.ProfileUpdateReq
 		if err := c.ShouldBindJSON(&in); err != nil {
 			jsonErr(c, http.StatusBadRequest, "invalid json")
 			return
 		}
-		var s types.User
-		if err := db.First(&s, "id = ?", id).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
+		p, err := ensureProfile(c.Request.Context(), db, ac, id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
 				jsonErr(c, http.StatusNotFound, "student not found")
 				return
 			}
 			jsonErr(c, http.StatusInternalServerError, "failed to fetch student")
 			return
 		}
-		s.Index = in.Index
-		s.FirstName = in.FirstName
-		s.LastName = in.LastName
-		s.Faculty = in.Faculty
-		if err := db.Save(&s).Error; err != nil {
-			jsonErr(c, http.StatusInternalServerError, "failed to update student")
-			return
-		}
-		c.JSON(http.StatusOK, s)
-	}
-}
-
-func changePassword(db *gorm.DB) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		id, ok := parseUintParam(c, "id")
-		if !ok {
+		changed := in.Index != nil && *in.Index != p.Index ||
+			in.Faculty != nil && *in.Faculty != p.Faculty ||
+			in.YearOfStudy != nil && *in.YearOfStudy != p.YearOfStudy
+		// Student (self) ne menja podatke o upisu; isti podaci u zahtevu nisu greska
+		if changed && !middleware.HasPermission(c, types.PermStudentWrite) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "index, faculty and year of study are managed by student services", "code": "ENROLMENT_READ_ONLY"})
 			return
 		}
-		var in types.ChangePassReq
-		if err := c.ShouldBindJSON(&in); err != nil {
-			jsonErr(c, http.StatusBadRequest, "invalid json")
-			return
-		}
-
-		var s types.User
-		if err := db.First(&s, "id = ?", id).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				jsonErr(c, http.StatusNotFound, "student not found")
-				return
-			}
-			jsonErr(c, http.StatusInternalServerError, "failed to fetch student")
-			return
+		if in.Index != nil {
+			p.Index = *in.Index
 		}
-
-		if err := bcrypt.CompareHashAndPassword([]byte(s.Password), []byte(in.OldPassword)); err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "incorect old password"})
-			return
+		if in.Faculty != nil {
+			p.Faculty = *in.Faculty
 		}
-
-		hash, err := bcrypt.GenerateFromPassword([]byte(in.NewPassword), bcrypt.DefaultCost)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to hash password"})
-			return
+		if in.YearOfStudy != nil {
+			p.YearOfStudy = *in.YearOfStudy
 		}
-		s.Password = string(hash)
-		if err := db.Save(&s).Error; err != nil {
+		if err := db.Model(&p).Updates(map[string]any{"index": p.Index, "faculty": p.Faculty, "year_of_study": p.YearOfStudy}).Error; err != nil {
 			jsonErr(c, http.StatusInternalServerError, "failed to update student")
 			return
 		}
-		c.JSON(http.StatusOK, s)
+		c.JSON(http.StatusOK, p)
 	}
 }
 
+// deleteStudent brise samo profil; nalog u auth-u ostaje.
 func deleteStudent(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		id, ok := parseUUID(c, "id")
+		id, ok := parseUintParam(c, "id")
 		if !ok {
 			return
 		}
-		if err := db.Delete(&types.User{}, "id = ?", id).Error; err != nil {
+		if err := db.Delete(&types.Profile{}, "user_id = ?", id).Error; err != nil {
 			jsonErr(c, http.StatusInternalServerError, "failed to delete student")
 			return
 		}
@@ -482,6 +450,10 @@ func createRoom(db *gorm.DB) gin.HandlerFunc {
 			jsonErr(c, http.StatusBadRequest, "dormId, number and capacity are required")
 			return
 		}
+		if !dormInScope(c, r.DormID) {
+			jsonErr(c, http.StatusForbidden, "dorm out of scope")
+			return
+		}
 		if r.ID == uuid.Nil {
 			r.ID = uuid.New()
 		}
@@ -513,6 +485,10 @@ func updateRoom(db *gorm.DB) gin.HandlerFunc {
 			jsonErr(c, http.StatusInternalServerError, "failed to fetch room")
 			return
 		}
+		if !dormInScope(c, r.DormID) {
+			jsonErr(c, http.StatusForbidden, "dorm out of scope")
+			return
+		}
 		r.Number, r.Capacity, r.Available = in.Number, in.Capacity, in.Available
 		if err := db.Save(&r).Error; err != nil {
 			jsonErr(c, http.StatusInternalServerError, "failed to update room")
@@ -528,7 +504,20 @@ func deleteRoom(db *gorm.DB) gin.HandlerFunc {
 		if !ok {
 			return
 		}
-		if err := db.Delete(&types.Room{}, "id = ?", id).Error; err != nil {
+		var r types.Room
+		if err := db.First(&r, "id = ?", id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				jsonErr(c, http.StatusNotFound, "room not found")
+				return
+			}
+			jsonErr(c, http.StatusInternalServerError, "failed to fetch room")
+			return
+		}
+		if !dormInScope(c, r.DormID) {
+			jsonErr(c, http.StatusForbidden, "dorm out of scope")
+			return
+		}
+		if err := db.Delete(&r).Error; err != nil {
 			jsonErr(c, http.StatusInternalServerError, "failed to delete room")
 			return
 		}
@@ -543,9 +532,14 @@ func listApplications(db *gorm.DB) gin.HandlerFunc {
 		var list []types.Application
 		page, size, offset := pagination(c)
 		q := db.Offset(offset).Limit(size)
-		if sid := c.Query("studentId"); sid != "" {
+		if own, ok := callerStudentID(c, types.PermApplicationRead); ok {
+			q = q.Where("student_id = ?", own)
+		} else if sid := c.Query("studentId"); sid != "" {
 			q = q.Where("student_id = ?", sid)
 		}
+		if cid := c.Query("competitionId"); cid != "" {
+			q = q.Where("competition_id = ?", cid)
+		}
 		if dormID := c.Query("dormId"); dormID != "" {
 			// filter by dorm via room
 			q = q.Joins("LEFT JOIN rooms r ON r.id = applications.room_id").Where("r.dorm_id = ?", dormID)
@@ -553,6 +547,9 @@ func listApplications(db *gorm.DB) gin.HandlerFunc {
 		if status := c.Query("status"); status != "" {
 			q = q.Where("status = ?", status)
 		}
+		if _, own := callerStudentID(c, types.PermApplicationRead); !own {
+			q = scopeToDorms(c, q)
+		}
 		if err := q.Order("created_at DESC").Find(&list).Error; err != nil {
 			jsonErr(c, http.StatusInternalServerError, "failed to retrieve applications")
 			return
@@ -578,30 +575,104 @@ func getApplication(db *gorm.DB) gin.HandlerFunc {
 			jsonErr(c, http.StatusInternalServerError, "failed to fetch application")
 			return
 		}
+		if own, ok := callerStudentID(c, types.PermApplicationRead); ok {
+			if a.StudentID != own {
+				jsonErr(c, http.StatusNotFound, "application not found")
+				return
+			}
+		} else if !applicationInScope(c, db, a.ID) {
+			jsonErr(c, http.StatusNotFound, "application not found")
+			return
+		}
 		c.JSON(http.StatusOK, a)
 	}
 }
 
-func createApplication(db *gorm.DB) gin.HandlerFunc {
+// createApplication pravi prijavu kao DRAFT i, ako je trazen status SUBMITTED (podrazumevano),
+// odmah izvrsava tranziciju submit. Bodovi se racunaju iz evidence; soba se ovde ne zadaje.
+func createApplication(db *gorm.DB, ac *upstream.AuthClient) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var a types.Application
-		if err := c.ShouldBindJSON(&a); err != nil {
+		var in types.ApplicationReq
+		if err := c.ShouldBindJSON(&in); err != nil {
 			jsonErr(c, http.StatusBadRequest, "invalid json")
 			return
 		}
-		if a.StudentID == 0 {
-			jsonErr(c, http.StatusBadRequest, "studentId is required")
+		if own, ok := callerStudentID(c, types.PermApplicationReview); ok {
+			in.StudentID = own
+		}
+		if in.StudentID == 0 || in.CompetitionID == uuid.Nil {
+			jsonErr(c, http.StatusBadRequest, "studentId and competitionId are required")
+			return
+		}
+		comp, err := loadCompetition(db, in.CompetitionID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				jsonErr(c, http.StatusUnprocessableEntity, "unknown competition")
+				return
+			}
+			jsonErr(c, http.StatusInternalServerError, "failed to fetch competition")
+			return
+		}
+		if comp.Phase != types.PhaseOpen {
+			c.JSON(http.StatusConflict, gin.H{"error": "competition is not open for applications", "code": "COMPETITION_CLOSED", "phase": comp.Phase})
+			return
+		}
+		if in.Status == "" {
+			in.Status = types.StatusSubmitted
+		}
+		if in.Status != types.StatusDraft && in.Status != types.StatusSubmitted {
+			c.JSON(http.StatusConflict, gin.H{"error": "a new application can only be DRAFT or SUBMITTED", "code": "ILLEGAL_TRANSITION"})
 			return
 		}
-		if !isValidStatus(a.Status) {
-			jsonErr(c, http.StatusBadRequest, "invalid status")
+		if in.Evidence != nil {
+			criteria := comp.Criteria
+			if len(criteria) == 0 {
+				criteria = scoring.Default()
+			}
+			if err := scoring.ValidateEvidence(criteria, *in.Evidence); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "INVALID_EVIDENCE"})
+				return
+			}
+		}
+		if in.DormPreferences == nil {
+			in.DormPreferences = []uuid.UUID{}
+		}
+		if msg := validateDormPreferences(comp, in.DormPreferences); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg, "code": "INVALID_PREFERENCES"})
 			return
 		}
-		if a.ID == uuid.Nil {
-			a.ID = uuid.New()
+		if in.Status == types.StatusSubmitted {
+			if _, err := ensureProfile(c.Request.Context(), db, ac, in.StudentID); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				log.Printf("[createApplication] ensure profile err: %v", err)
+			}
+		}
+
+		a := types.Application{ID: uuid.New(), CreatedAt: time.Now().UTC(), Status: types.StatusDraft, StudentID: in.StudentID, CompetitionID: &comp.ID,
+			Evidence: in.Evidence, DormPreferences: in.DormPreferences}
+		err = db.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&a).Error; err != nil {
+				// Jedinstveni indeks (competition_id, student_id) za prijave koje nisu povucene
+				var pgErr *pgconn.PgError
+				if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+					return &transitionError{http.StatusConflict, "ALREADY_APPLIED", "student already has an application for this competition"}
+				}
+				return err
+			}
+			if err := recordTransition(c, tx, a.ID, types.TransitionCreate, "", a.Status, ""); err != nil {
+				return err
+			}
+			if in.Status == types.StatusSubmitted {
+				return applyTransition(c, tx, &a, types.TransitionReq{Transition: types.TransitionSubmit}, transitions[types.TransitionSubmit])
+			}
+			return scoreApplication(tx, &a)
+		})
+		var te *transitionError
+		if errors.As(err, &te) {
+			c.JSON(te.status, gin.H{"error": te.msg, "code": te.code})
+			return
 		}
-		a.CreatedAt = time.Now().UTC()
-		if err := db.Create(&a).Error; err != nil {
+		if err != nil {
+			log.Printf("[createApplication] err: %v", err)
 			jsonErr(c, http.StatusInternalServerError, "failed to create application")
 			return
 		}
@@ -609,38 +680,82 @@ func createApplication(db *gorm.DB) gin.HandlerFunc {
 	}
 }
 
+// updateApplication menja podatke za bodovanje (i preracunava bodove) i zeljene domove.
+// Student menja svoju prijavu dok je DRAFT ili SUBMITTED, staff (application:review) i tokom pregleda.
 func updateApplication(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		id, ok := parseUUID(c, "id")
 		if !ok {
 			return
 		}
-		var in types.Application
-		if err := c.ShouldBindJSON(&in); err != nil {
-			jsonErr(c, http.StatusBadRequest, "invalid json")
-			return
-		}
-		if !isValidStatus(in.Status) {
-			jsonErr(c, http.StatusBadRequest, "invalid status")
+		var in types.ApplicationUpdateReq
+		if err := c.ShouldBindJSON(&in); err != nil || (in.Evidence == nil && in.DormPreferences == nil) {
+			jsonErr(c, http.StatusBadRequest, "evidence or dormPreferences is required")
 			return
 		}
 		var a types.Application
-		if err := db.First(&a, "id = ?", id).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				jsonErr(c, http.StatusNotFound, "application not found")
-				return
+		err := db.WithContext(c.Request.Context()).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&a, "id = ?", id).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return errApplicationNotFound
+				}
+				return err
 			}
-			jsonErr(c, http.StatusInternalServerError, "failed to fetch application")
-			return
-		}
-		a.Points = in.Points
-		a.Status = in.Status
-		a.RoomID = in.RoomID // may be nil
-		if err := db.Save(&a).Error; err != nil {
+			if !mayTransition(c, tx, &a, actorOwnerOrStaff) {
+				return errApplicationNotFound
+			}
+			editable := []types.ApplicationStatus{types.StatusDraft, types.StatusSubmitted}
+			if middleware.HasPermission(c, types.PermApplicationReview) {
+				editable = scoringStatuses
+			}
+			if !containsStatus(editable, a.Status) {
+				return &transitionError{http.StatusConflict, "ILLEGAL_TRANSITION", "application can no longer be changed"}
+			}
+			if in.DormPreferences != nil {
+				if a.CompetitionID == nil {
+					return &transitionError{http.StatusBadRequest, "INVALID_PREFERENCES", "application has no competition"}
+				}
+				comp, err := loadCompetition(tx, *a.CompetitionID)
+				if err != nil {
+					return err
+				}
+				if msg := validateDormPreferences(comp, in.DormPreferences); msg != "" {
+					return &transitionError{http.StatusBadRequest, "INVALID_PREFERENCES", msg}
+				}
+				a.DormPreferences = in.DormPreferences
+				if err := tx.Model(&a).Select("dorm_preferences").Updates(&a).Error; err != nil {
+					return err
+				}
+			}
+			if in.Evidence == nil {
+				return nil
+			}
+			criteria, err := criteriaFor(tx, &a)
+			if err != nil {
+				return err
+			}
+			if err := scoring.ValidateEvidence(criteria, *in.Evidence); err != nil {
+				return &transitionError{http.StatusBadRequest, "INVALID_EVIDENCE", err.Error()}
+			}
+			a.Evidence = in.Evidence
+			if err := tx.Model(&a).Select("evidence").Updates(&a).Error; err != nil {
+				return err
+			}
+			return scoreWith(tx, &a, criteria)
+		})
+
+		var te *transitionError
+		switch {
+		case err == nil:
+			c.JSON(http.StatusOK, a)
+		case errors.Is(err, errApplicationNotFound):
+			jsonErr(c, http.StatusNotFound, "application not found")
+		case errors.As(err, &te):
+			c.JSON(te.status, gin.H{"error": te.msg, "code": te.code})
+		default:
+			log.Printf("[updateApplication] err: %v", err)
 			jsonErr(c, http.StatusInternalServerError, "failed to update application")
-			return
 		}
-		c.JSON(http.StatusOK, a)
 	}
 }
 
@@ -650,10 +765,40 @@ func deleteApplication(db *gorm.DB) gin.HandlerFunc {
 		if !ok {
 			return
 		}
-		if err := db.Delete(&types.Application{}, "id = ?", id).Error; err != nil {
+		// Prijava van domova pozivaoca se "ne vidi", kao i kod GET
+		if !applicationInScope(c, db, id) {
+			jsonErr(c, http.StatusNotFound, "application not found")
+			return
+		}
+		var payments int64
+		if err := db.Model(&types.Payment{}).Where("application_id = ?", id).Count(&payments).Error; err != nil {
+			jsonErr(c, http.StatusInternalServerError, "failed to delete application")
+			return
+		}
+		if payments > 0 {
+			jsonErr(c, http.StatusConflict, "application has payments")
+			return
+		}
+		// Objavljene rang liste se ne menjaju, pa ni prijave sa njih ne nestaju
+		var listed int64
+		if err := db.Table("ranking_rows").Where("application_id = ?", id).Count(&listed).Error; err != nil {
+			jsonErr(c, http.StatusInternalServerError, "failed to delete application")
+			return
+		}
+		if listed > 0 {
+			jsonErr(c, http.StatusConflict, "application is on a published ranking list")
+			return
+		}
+		// Logicko brisanje (deleted_at); istorija tranzicija ostaje
+		res := db.Delete(&types.Application{}, "id = ?", id)
+		if res.Error != nil {
 			jsonErr(c, http.StatusInternalServerError, "failed to delete application")
 			return
 		}
+		if res.RowsAffected == 0 {
+			jsonErr(c, http.StatusNotFound, "application not found")
+			return
+		}
 		c.Status(http.StatusNoContent)
 	}
 }
@@ -668,7 +813,14 @@ func listPayments(db *gorm.DB) gin.HandlerFunc {
 		if aid := c.Query("applicationId"); aid != "" {
 			q = q.Where("application_id = ?", aid)
 		}
-		if err := q.Order("issued_at DESC").Find(&list).Error; err != nil {
+		if own, ok := callerStudentID(c, types.PermPaymentRead); ok {
+			q = q.Joins("JOIN applications a ON a.id = payments.application_id").Where("a.student_id = ?", own)
+		} else if dorms, scoped := middleware.DormScope(c); scoped {
+			q = q.Joins("JOIN applications a ON a.id = payments.application_id").
+				Joins("JOIN rooms r ON r.id = a.room_id").
+				Where("r.dorm_id IN ?", dorms)
+		}
+		if err := q.Order("payments.issued_at DESC").Find(&list).Error; err != nil {
 			jsonErr(c, http.StatusInternalServerError, "failed to retrieve payments")
 			return
 		}
@@ -693,6 +845,10 @@ func getPayment(db *gorm.DB) gin.HandlerFunc {
 			jsonErr(c, http.StatusInternalServerError, "failed to fetch payment")
 			return
 		}
+		if !paymentVisible(c, db, p.ApplicationID) {
+			jsonErr(c, http.StatusNotFound, "payment not found")
+			return
+		}
 		c.JSON(http.StatusOK, p)
 	}
 }
@@ -708,6 +864,10 @@ func createPayment(db *gorm.DB) gin.HandlerFunc {
 			jsonErr(c, http.StatusBadRequest, "applicationId and reference are required")
 			return
 		}
+		if !paymentVisible(c, db, p.ApplicationID) {
+			jsonErr(c, http.StatusForbidden, "application out of scope")
+			return
+		}
 		if p.ID == uuid.Nil {
 			p.ID = uuid.New()
 		}
@@ -728,7 +888,20 @@ func deletePayment(db *gorm.DB) gin.HandlerFunc {
 		if !ok {
 			return
 		}
-		if err := db.Delete(&types.Payment{}, "id = ?", id).Error; err != nil {
+		var p types.Payment
+		if err := db.First(&p, "id = ?", id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				jsonErr(c, http.StatusNotFound, "payment not found")
+				return
+			}
+			jsonErr(c, http.StatusInternalServerError, "failed to fetch payment")
+			return
+		}
+		if !paymentVisible(c, db, p.ApplicationID) {
+			jsonErr(c, http.StatusNotFound, "payment not found")
+			return
+		}
+		if err := db.Delete(&p).Error; err != nil {
 			jsonErr(c, http.StatusInternalServerError, "failed to delete payment")
 			return
 		}
@@ -736,6 +909,19 @@ func deletePayment(db *gorm.DB) gin.HandlerFunc {
 	}
 }
 
+// paymentVisible: student vidi uplate za svoje prijave, ograniceni staff za prijave u svojim domovima.
+func paymentVisible(c *gin.Context, db *gorm.DB, applicationID uuid.UUID) bool {
+	own, ok := callerStudentID(c, types.PermPaymentRead)
+	if !ok {
+		return applicationInScope(c, db, applicationID)
+	}
+	var cnt int64
+	if err := db.Model(&types.Application{}).Where("id = ? AND student_id = ?", applicationID, own).Count(&cnt).Error; err != nil {
+		return false
+	}
+	return cnt > 0
+}
+
 func parseUintParam(c *gin.Context, name string) (uint, bool) {
 	raw := c.Param(name)
 	if raw == "" {
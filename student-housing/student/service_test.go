@@ -0,0 +1,133 @@
+package student
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"student-housting/types"
+)
+
+// scopedFixture: dom A (u opsegu staff-a) i dom B (van opsega), sa po jednom sobom.
+type scopedFixture struct {
+	db            *gorm.DB
+	dormA, dormB  uuid.UUID
+	roomA, roomB  *types.Room
+	comp          *types.Competition
+	staffPerms    []types.Permission
+	nextStudentID uint
+}
+
+func newScopedFixture(t *testing.T) *scopedFixture {
+	db := newTestDB(t)
+	f := &scopedFixture{db: db, dormA: uuid.New(), dormB: uuid.New(), nextStudentID: 1}
+	f.roomA = &types.Room{ID: uuid.New(), DormID: f.dormA, Number: "101", Capacity: 2, Available: true}
+	f.roomB = &types.Room{ID: uuid.New(), DormID: f.dormB, Number: "201", Capacity: 2, Available: true}
+	mustCreate(t, db,
+		&types.Dorm{ID: f.dormA, Name: "Dom A", Address: "Adresa 1"},
+		&types.Dorm{ID: f.dormB, Name: "Dom B", Address: "Adresa 2"},
+		f.roomA, f.roomB,
+	)
+	f.comp = seedCompetition(t, db, "2026/2027", false, map[uuid.UUID]int{f.dormA: 5})
+	f.staffPerms = []types.Permission{types.PermRoomWrite, types.PermApplicationDelete, types.PermPaymentRead, types.PermPaymentDelete}
+	return f
+}
+
+func (f *scopedFixture) application(t *testing.T, roomID *uuid.UUID, prefs ...uuid.UUID) *types.Application {
+	t.Helper()
+	if prefs == nil {
+		prefs = []uuid.UUID{}
+	}
+	a := &types.Application{ID: uuid.New(), StudentID: f.nextStudentID, CompetitionID: &f.comp.ID,
+		Status: types.StatusDraft, RoomID: roomID, DormPreferences: prefs}
+	f.nextStudentID++
+	mustCreate(t, f.db, a)
+	return a
+}
+
+// deleteAs poziva handler kao staff ogranicen na dom A i vraca HTTP status.
+func (f *scopedFixture) deleteAs(h func(*gorm.DB) gin.HandlerFunc, id uuid.UUID) int {
+	c, _ := staffContext(f.staffPerms, f.dormA)
+	c.Request.Method = http.MethodDelete
+	c.Params = gin.Params{{Key: "id", Value: id.String()}}
+	h(f.db)(c)
+	return c.Writer.Status()
+}
+
+func exists(t *testing.T, db *gorm.DB, model any, id uuid.UUID) bool {
+	t.Helper()
+	var n int64
+	if err := db.Model(model).Where("id = ?", id).Count(&n).Error; err != nil {
+		t.Fatal(err)
+	}
+	return n > 0
+}
+
+func TestScopedStaffDeleteRoom(t *testing.T) {
+	f := newScopedFixture(t)
+
+	if got := f.deleteAs(deleteRoom, f.roomB.ID); got != http.StatusForbidden {
+		t.Fatalf("delete room in dorm B: status %d, want 403", got)
+	}
+	if !exists(t, f.db, &types.Room{}, f.roomB.ID) {
+		t.Fatal("room in dorm B was deleted")
+	}
+	if got := f.deleteAs(deleteRoom, f.roomA.ID); got != http.StatusNoContent {
+		t.Fatalf("delete room in dorm A: status %d, want 204", got)
+	}
+	if exists(t, f.db, &types.Room{}, f.roomA.ID) {
+		t.Fatal("room in dorm A was not deleted")
+	}
+	if got := f.deleteAs(deleteRoom, uuid.New()); got != http.StatusNotFound {
+		t.Fatalf("delete missing room: status %d, want 404", got)
+	}
+}
+
+func TestScopedStaffDeleteApplication(t *testing.T) {
+	f := newScopedFixture(t)
+
+	tests := []struct {
+		name   string
+		app    *types.Application
+		status int
+	}{
+		{"room in dorm B", f.application(t, &f.roomB.ID), http.StatusNotFound},
+		{"prefers only dorm B", f.application(t, nil, f.dormB), http.StatusNotFound},
+		{"room in dorm A", f.application(t, &f.roomA.ID), http.StatusNoContent},
+		{"prefers dorm A", f.application(t, nil, f.dormB, f.dormA), http.StatusNoContent},
+		{"no preference, competition offers dorm A", f.application(t, nil), http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := f.deleteAs(deleteApplication, tt.app.ID); got != tt.status {
+				t.Fatalf("status %d, want %d", got, tt.status)
+			}
+			if deleted := !exists(t, f.db, &types.Application{}, tt.app.ID); deleted != (tt.status == http.StatusNoContent) {
+				t.Fatalf("deleted = %v after status %d", deleted, tt.status)
+			}
+		})
+	}
+}
+
+func TestScopedStaffDeletePayment(t *testing.T) {
+	f := newScopedFixture(t)
+	payB := &types.Payment{ID: uuid.New(), Reference: "97-B", Amount: 5000, ApplicationID: f.application(t, &f.roomB.ID).ID}
+	payA := &types.Payment{ID: uuid.New(), Reference: "97-A", Amount: 5000, ApplicationID: f.application(t, &f.roomA.ID).ID}
+	mustCreate(t, f.db, payB, payA)
+
+	if got := f.deleteAs(deletePayment, payB.ID); got != http.StatusNotFound {
+		t.Fatalf("delete payment in dorm B: status %d, want 404", got)
+	}
+	if !exists(t, f.db, &types.Payment{}, payB.ID) {
+		t.Fatal("payment in dorm B was deleted")
+	}
+	if got := f.deleteAs(deletePayment, payA.ID); got != http.StatusNoContent {
+		t.Fatalf("delete payment in dorm A: status %d, want 204", got)
+	}
+	if exists(t, f.db, &types.Payment{}, payA.ID) {
+		t.Fatal("payment in dorm A was not deleted")
+	}
+}
@@ -0,0 +1,135 @@
+package student
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"student-housting/types"
+)
+
+const statsDateLayout = "2006-01-02"
+
+// Zbirni podaci za open-data i ostale servise (masinski token sa housing:stats:read).
+// Vracaju se samo brojevi po domu i danu, nikad pojedinacne prijave ili studenti.
+// Prijave bez sobe imaju prazan dormId.
+
+func WithStatsAPI(r *gin.RouterGroup, db *gorm.DB, service gin.HandlerFunc) {
+	r.GET("/stats/applications", service, applicationStats(db)) // ?dormId=&from=&to=
+	r.GET("/stats/payments", service, paymentStats(db))
+}
+
+func applicationStats(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		q := db.Table("applications p").
+			Select(`COALESCE(s.dorm_id::text, '') AS dorm_id, p.created_at::date AS date,
+				SUM(CASE WHEN p.status = ? THEN 1 ELSE 0 END) AS submitted,
+				SUM(CASE WHEN p.status = ? THEN 1 ELSE 0 END) AS accepted,
+				SUM(CASE WHEN p.status = ? THEN 1 ELSE 0 END) AS rejected,
+				SUM(CASE WHEN p.status = ? THEN 1 ELSE 0 END) AS reserved`,
+				types.StatusSubmitted, types.StatusAccepted, types.StatusRejected, types.StatusReserved).
+			Joins("LEFT JOIN rooms s ON s.id = p.room_id").
+			Where("p.deleted_at IS NULL").
+			Group("s.dorm_id, p.created_at::date")
+		q, ok := statsFilter(c, q, "p.created_at")
+		if !ok {
+			return
+		}
+
+		var rows []struct {
+			DormID    string
+			Date      time.Time
+			Submitted int
+			Accepted  int
+			Rejected  int
+			Reserved  int
+		}
+		page, size, cnt, ok := statsPage(c, db, q, &rows)
+		if !ok {
+			return
+		}
+		items := make([]types.ApplicationStats, 0, len(rows))
+		for _, r := range rows {
+			items = append(items, types.ApplicationStats{
+				DormID:    r.DormID,
+				Date:      r.Date.Format(statsDateLayout),
+				Submitted: r.Submitted,
+				Accepted:  r.Accepted,
+				Rejected:  r.Rejected,
+				Reserved:  r.Reserved,
+			})
+		}
+		c.JSON(http.StatusOK, gin.H{"items": items, "pagination": gin.H{"page": page, "pageSize": size, "totalCount": cnt}})
+	}
+}
+
+func paymentStats(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		q := db.Table("payments u").
+			Select("COALESCE(s.dorm_id::text, '') AS dorm_id, u.issued_at::date AS date, COUNT(*) AS count, SUM(u.amount) AS sum").
+			Joins("JOIN applications p ON p.id = u.application_id").
+			Joins("LEFT JOIN rooms s ON s.id = p.room_id").
+			Group("s.dorm_id, u.issued_at::date")
+		q, ok := statsFilter(c, q, "u.issued_at")
+		if !ok {
+			return
+		}
+
+		var rows []struct {
+			DormID string
+			Date   time.Time
+			Count  int
+			Sum    float64
+		}
+		page, size, cnt, ok := statsPage(c, db, q, &rows)
+		if !ok {
+			return
+		}
+		items := make([]types.PaymentStats, 0, len(rows))
+		for _, r := range rows {
+			items = append(items, types.PaymentStats{
+				DormID: r.DormID,
+				Date:   r.Date.Format(statsDateLayout),
+				Count:  r.Count,
+				Sum:    r.Sum,
+			})
+		}
+		c.JSON(http.StatusOK, gin.H{"items": items, "pagination": gin.H{"page": page, "pageSize": size, "totalCount": cnt}})
+	}
+}
+
+/* ===================== Helpers ===================== */
+
+// statsFilter primenjuje ?dormId=&from=&to= (datumi YYYY-MM-DD, ukljucivo).
+func statsFilter(c *gin.Context, q *gorm.DB, dateCol string) (*gorm.DB, bool) {
+	if dormID := c.Query("dormId"); dormID != "" {
+		q = q.Where("s.dorm_id::text = ?", dormID)
+	}
+	for _, f := range []struct{ param, op string }{{"from", ">="}, {"to", "<="}} {
+		v := c.Query(f.param)
+		if v == "" {
+			continue
+		}
+		if _, err := time.Parse(statsDateLayout, v); err != nil {
+			jsonErr(c, http.StatusBadRequest, "invalid "+f.param+" (want YYYY-MM-DD)")
+			return nil, false
+		}
+		q = q.Where(dateCol+"::date "+f.op+" ?::date", v)
+	}
+	return q, true
+}
+
+func statsPage(c *gin.Context, db *gorm.DB, q *gorm.DB, out any) (page, size int, cnt int64, ok bool) {
+	page, size, offset := pagination(c)
+	if err := db.Table("(?) AS t", q).Count(&cnt).Error; err != nil {
+		jsonErr(c, http.StatusInternalServerError, "failed to count stats")
+		return 0, 0, 0, false
+	}
+	if err := q.Order("date, dorm_id").Offset(offset).Limit(size).Scan(out).Error; err != nil {
+		jsonErr(c, http.StatusInternalServerError, "failed to load stats")
+		return 0, 0, 0, false
+	}
+	return page, size, cnt, true
+}
@@ -0,0 +1,97 @@
+package student
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"student-housting/types"
+	"student-housting/upstream"
+)
+
+// SyncProfiles periodicno povlaci izmene naloga iz auth-a i osvezava kopiju identiteta
+// u user_profiles. Kursor je u memoriji, pa posle restarta ide jedna puna sinhronizacija.
+func SyncProfiles(ctx context.Context, db *gorm.DB, ac *upstream.AuthClient, every time.Duration) {
+	var since time.Time
+	var afterID uint
+
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		for {
+			items, err := ac.UsersSince(ctx, since, afterID)
+			if err != nil {
+				log.Printf("[profiles] sync failed: %v", err)
+				break
+			}
+			for _, it := range items {
+				if err := upsertIdentity(db.WithContext(ctx), it); err != nil {
+					log.Printf("[profiles] upsert %d failed: %v", it.ID, err)
+				}
+				since, afterID = it.UpdatedAt, it.ID
+			}
+			if len(items) == 0 {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// upsertIdentity azurira samo kolone koje pripadaju auth-u. Index i Faculty iz uvoza (Enrollment)
+// upisuju se samo dok su u profilu prazni; posle toga ih menja samo housing.
+func upsertIdentity(db *gorm.DB, id upstream.Identity) error {
+	p := types.Profile{
+		UserID:    id.ID,
+		Email:     id.Email,
+		FirstName: id.FirstName,
+		LastName:  id.LastName,
+		Role:      types.Role(id.Role),
+		Status:    id.Status,
+		SyncedAt:  time.Now(),
+	}
+	if id.Enrollment != nil {
+		p.Index, p.Faculty = id.Enrollment.Index, id.Enrollment.Faculty
+	}
+	updates := clause.AssignmentColumns([]string{"email", "first_name", "last_name", "role", "status", "synced_at"})
+	updates = append(updates,
+		clause.Assignment{Column: clause.Column{Name: "index"}, Value: gorm.Expr(`COALESCE(NULLIF(user_profiles."index", ''), excluded."index")`)},
+		clause.Assignment{Column: clause.Column{Name: "faculty"}, Value: gorm.Expr(`COALESCE(NULLIF(user_profiles.faculty, ''), excluded.faculty)`)},
+	)
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: updates,
+	}).Create(&p).Error
+}
+
+// ensureProfile vraca profil, a ako ga jos nema (nalog napravljen posle poslednje
+// sinhronizacije) odmah ga povlaci iz auth-a. gorm.ErrRecordNotFound ako nalog ne postoji.
+func ensureProfile(ctx context.Context, db *gorm.DB, ac *upstream.AuthClient, userID uint) (types.Profile, error) {
+	var p types.Profile
+	err := db.WithContext(ctx).First(&p, "user_id = ?", userID).Error
+	if err == nil || !errors.Is(err, gorm.ErrRecordNotFound) {
+		return p, err
+	}
+
+	id, err := ac.User(ctx, userID)
+	if errors.Is(err, upstream.ErrNotFound) {
+		return p, gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		return p, err
+	}
+	if err := upsertIdentity(db.WithContext(ctx), id); err != nil {
+		return p, err
+	}
+	err = db.WithContext(ctx).First(&p, "user_id = ?", userID).Error
+	return p, err
+}
@@ -1,9 +1,13 @@
 package types
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"student-housting/scoring"
 )
 
 /* ========== Core models (English) ========== */
@@ -18,26 +22,99 @@ import (
 // 	Applications []Application `gorm:"foreignKey:StudentID" json:"applications,omitempty"`
 // }
 
-type User struct {
-	ID           uint          `gorm:"primaryKey" json:"ID"`
-	Email        string        `gorm:"unique;not null" json:"email"`
-	Password     string        `gorm:"not null" json:"password"`
-	Role         Role          `gorm:"not null" json:"role"`
-	Index        string        ` json:"index"`
-	FirstName    string        `gorm:"not null" json:"firstName"`
-	LastName     string        `gorm:"not null" json:"lastName"`
-	Faculty      string        `json:"faculty"`
+// Profile je studentski profil vezan za nalog iz auth servisa (UserID = id naloga).
+// Email, ime, uloga i status su kopija iz auth-a koju osvezava student.SyncProfiles;
+// ovde se menjaju samo Index i Faculty.
+type Profile struct {
+	UserID    uint   `gorm:"primaryKey;autoIncrement:false" json:"ID"`
+	Email     string `gorm:"not null" json:"email"`
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+	Role      Role   `gorm:"type:varchar(50);not null;index" json:"role"`
+	Status    string `gorm:"type:varchar(30)" json:"status"`
+	Index     string `json:"index"`
+	Faculty   string `json:"faculty"`
+	// YearOfStudy odredjuje pravo ucesca na konkursu (0 = nije uneto)
+	YearOfStudy int       `gorm:"not null;default:0" json:"yearOfStudy"`
+	SyncedAt    time.Time `json:"-"`
+
 	Applications []Application `gorm:"foreignKey:StudentID" json:"applications,omitempty"`
 }
 
+func (Profile) TableName() string { return "user_profiles" }
+
+type ProfileReq struct {
+	UserID      uint   `json:"userId"`
+	Index       string `json:"index"`
+	Faculty     string `json:"faculty"`
+	YearOfStudy int    `json:"yearOfStudy"`
+}
+
+// ProfileUpdateReq: nil polje se ne menja. Sva polja su podaci o upisu (uslovi konkursa,
+// bodovanje, kvote po fakultetu), pa ih menja samo neko sa student:write.
+type ProfileUpdateReq struct {
+	Index       *string `json:"index"`
+	Faculty     *string `json:"faculty"`
+	YearOfStudy *int    `json:"yearOfStudy"`
+}
+
 type Role string
 
 const (
 	AdminRole   Role = "ADMIN"
 	StudentRole Role = "STUDENT"
-	TeacherRole Role = "STAFF"
+	StaffRole   Role = "STAFF"
+)
+
+// Permission odgovara dozvolama koje auth upisuje u token (claim "perms").
+type Permission string
+
+const (
+	PermUserRead  Permission = "user:read"
+	PermUserWrite Permission = "user:write"
+	PermUserRole  Permission = "user:role"
+
+	PermStudentRead  Permission = "student:read"
+	PermStudentWrite Permission = "student:write"
+
+	PermDormWrite Permission = "dorm:write"
+	PermRoomWrite Permission = "room:write"
+
+	PermApplicationRead   Permission = "application:read"
+	PermApplicationSubmit Permission = "application:submit"
+	PermApplicationReview Permission = "application:review"
+	PermApplicationDelete Permission = "application:delete"
+
+	PermPaymentRead   Permission = "payment:read"
+	PermPaymentIssue  Permission = "payment:issue"
+	PermPaymentDelete Permission = "payment:delete"
+
+	PermCompetitionManage Permission = "competition:manage"
 )
 
+// Scope-ovi masinskih tokena (client credentials) koje housing prihvata
+const (
+	ScopeStatsRead = "housing:stats:read"
+)
+
+// ApplicationStats je broj prijava po domu i danu; bez podataka o studentima.
+type ApplicationStats struct {
+	DormID    string `json:"dormId"`
+	Date      string `json:"date"`
+	Submitted int    `json:"submitted"`
+	Accepted  int    `json:"accepted"`
+	Rejected  int    `json:"rejected"`
+	Reserved  int    `json:"reserved"`
+}
+
+// PaymentStats je broj i zbir uplata po domu i danu.
+type PaymentStats struct {
+	DormID string  `json:"dormId"`
+	Date   string  `json:"date"`
+	Count  int     `json:"count"`
+	Sum    float64 `json:"sum"`
+}
+
 type Dorm struct {
 	ID      uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
 	Name    string    `gorm:"not null" json:"name"`
@@ -59,13 +136,256 @@ type Room struct {
 type Application struct {
 	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
 	CreatedAt time.Time `gorm:"autoCreateTime" json:"createdAt"`
-	Points    int       `json:"points"`
+	// Points racuna servis iz Evidence i kriterijuma konkursa (vidi student/points.go)
+	Points float64 `gorm:"type:numeric(8,2);not null;default:0" json:"points"`
 
 	Status ApplicationStatus `gorm:"type:varchar(20);not null" json:"status"`
 
 	StudentID uint       `gorm:"not null" json:"studentId"`
 	RoomID    *uuid.UUID `json:"roomId,omitempty"`
-	Payment   *Payment   `gorm:"foreignKey:ApplicationID" json:"payment,omitempty"`
+	// CompetitionID je nil samo za prijave nastale pre uvodjenja konkursa
+	CompetitionID *uuid.UUID `gorm:"type:uuid;index" json:"competitionId,omitempty"`
+	// DormPreferences su domovi konkursa po redu zelje; prazna lista znaci "bilo koji"
+	DormPreferences []uuid.UUID `gorm:"serializer:json;type:jsonb;not null" json:"dormPreferences"`
+	Payment         *Payment    `gorm:"foreignKey:ApplicationID" json:"payment,omitempty"`
+
+	Evidence       *scoring.Evidence  `gorm:"serializer:json;type:jsonb" json:"evidence,omitempty"`
+	ScoreBreakdown *scoring.Breakdown `gorm:"serializer:json;type:jsonb" json:"scoreBreakdown,omitempty"`
+
+	// Brisanje je logicko: istorija tranzicija ostaje (gorm sam izostavlja obrisane u upitima)
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// ApplicationTransition je red istorije prijave (tabela application_transitions, samo dodavanje).
+// ActorID je ko je izvrsio tranziciju; kod impersonacije to je admin.
+type ApplicationTransition struct {
+	ID            uuid.UUID         `gorm:"type:uuid;primaryKey" json:"id"`
+	ApplicationID uuid.UUID         `gorm:"type:uuid;not null;index" json:"applicationId"`
+	Transition    Transition        `gorm:"type:varchar(20);not null" json:"transition"`
+	FromStatus    ApplicationStatus `gorm:"type:varchar(20);not null" json:"fromStatus"`
+	ToStatus      ApplicationStatus `gorm:"type:varchar(20);not null" json:"toStatus"`
+	ActorID       uint              `gorm:"not null" json:"actorId"`
+	Reason        string            `gorm:"not null" json:"reason,omitempty"`
+	CreatedAt     time.Time         `gorm:"not null" json:"createdAt"`
+}
+
+// TransitionReq: RoomID je obavezan za reserve, Reason za reject, cancel i reopen.
+type TransitionReq struct {
+	Transition Transition `json:"transition"`
+	Reason     string     `json:"reason"`
+	RoomID     *uuid.UUID `json:"roomId"`
+}
+
+// ApplicationReq je ono sto se zadaje pri kreiranju; status moze biti samo DRAFT ili SUBMITTED.
+type ApplicationReq struct {
+	StudentID       uint              `json:"studentId"`
+	CompetitionID   uuid.UUID         `json:"competitionId"`
+	Status          ApplicationStatus `json:"status"`
+	Evidence        *scoring.Evidence `json:"evidence"`
+	DormPreferences []uuid.UUID       `json:"dormPreferences"`
+}
+
+// ApplicationUpdateReq menja podatke za bodovanje (bodovi se posle preracunavaju) i redosled
+// zeljenih domova; nil polje ostaje kakvo jeste. Status i soba idu kroz tranzicije.
+type ApplicationUpdateReq struct {
+	Evidence        *scoring.Evidence `json:"evidence"`
+	DormPreferences []uuid.UUID       `json:"dormPreferences"`
+}
+
+// Competition je konkurs za smestaj za jednu akademsku godinu. Prijave se primaju samo
+// izmedju OpensAt i ClosesAt; prazne liste fakulteta i godina znace "svi".
+type Competition struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	Name         string    `gorm:"not null" json:"name"`
+	AcademicYear string    `gorm:"type:varchar(9);not null;index" json:"academicYear"` // npr. 2026/2027
+	OpensAt      time.Time `gorm:"not null" json:"opensAt"`
+	ClosesAt     time.Time `gorm:"not null" json:"closesAt"`
+	Faculties    []string  `gorm:"serializer:json;type:jsonb;not null" json:"faculties"`
+	YearsOfStudy []int     `gorm:"serializer:json;type:jsonb;not null" json:"yearsOfStudy"`
+	// Criteria su kriterijumi bodovanja; prazna lista pri kreiranju postaje scoring.Default()
+	Criteria []scoring.Criterion `gorm:"serializer:json;type:jsonb;not null" json:"criteria"`
+	// FacultyQuotas ogranicava broj primljenih po fakultetu; fakultet bez kvote nije ogranicen
+	FacultyQuotas map[string]int `gorm:"serializer:json;type:jsonb;not null" json:"facultyQuotas"`
+	CreatedAt     time.Time      `gorm:"autoCreateTime" json:"createdAt"`
+	UpdatedAt     time.Time      `gorm:"autoUpdateTime" json:"updatedAt"`
+
+	Dorms []CompetitionDorm `gorm:"foreignKey:CompetitionID;constraint:OnDelete:CASCADE" json:"dorms"`
+	Phase CompetitionPhase  `gorm:"-" json:"phase"`
+}
+
+// CompetitionDorm je dom ponudjen na konkursu i broj mesta (kvota) u njemu.
+type CompetitionDorm struct {
+	CompetitionID uuid.UUID `gorm:"type:uuid;primaryKey" json:"-"`
+	DormID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"dormId"`
+	Quota         int       `gorm:"not null" json:"quota"`
+}
+
+type CompetitionPhase string
+
+const (
+	PhaseUpcoming CompetitionPhase = "UPCOMING"
+	PhaseOpen     CompetitionPhase = "OPEN"
+	PhaseClosed   CompetitionPhase = "CLOSED"
+)
+
+func (c Competition) PhaseAt(t time.Time) CompetitionPhase {
+	switch {
+	case t.Before(c.OpensAt):
+		return PhaseUpcoming
+	case t.Before(c.ClosesAt):
+		return PhaseOpen
+	}
+	return PhaseClosed
+}
+
+// Eligible: fakultet se poredi bez obzira na velika/mala slova.
+func (c Competition) Eligible(faculty string, year int) bool {
+	if len(c.Faculties) > 0 {
+		ok := false
+		for _, f := range c.Faculties {
+			if strings.EqualFold(strings.TrimSpace(f), strings.TrimSpace(faculty)) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	if len(c.YearsOfStudy) > 0 {
+		for _, y := range c.YearsOfStudy {
+			if y == year {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+type ExtendDeadlineReq struct {
+	ClosesAt time.Time `json:"closesAt"`
+}
+
+// AllocationOutcome je ishod prijave u jednom rangiranju.
+type AllocationOutcome string
+
+const (
+	OutcomeAccepted   AllocationOutcome = "ACCEPTED"
+	OutcomeWaitlisted AllocationOutcome = "WAITLISTED"
+	OutcomeRejected   AllocationOutcome = "REJECTED"
+)
+
+// AllocationRun je jedno rangiranje i raspodela mesta za konkurs (vidi student/allocation.go).
+// Dry-run se ne cuva; tada je DryRun true i ID prazan.
+type AllocationRun struct {
+	ID            uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	CompetitionID uuid.UUID `gorm:"type:uuid;not null;index" json:"competitionId"`
+	DryRun        bool      `gorm:"-" json:"dryRun"`
+	Accepted      int       `gorm:"not null" json:"accepted"`
+	Waitlisted    int       `gorm:"not null" json:"waitlisted"`
+	Rejected      int       `gorm:"not null" json:"rejected"`
+	CreatedBy     uint      `gorm:"not null" json:"createdBy"`
+	CreatedAt     time.Time `gorm:"not null" json:"createdAt"`
+
+	Results []AllocationResult `gorm:"foreignKey:RunID;constraint:OnDelete:CASCADE" json:"results,omitempty"`
+}
+
+// AllocationResult je jedan red rang liste; Rank pocinje od 1.
+type AllocationResult struct {
+	RunID         uuid.UUID         `gorm:"type:uuid;primaryKey" json:"-"`
+	ApplicationID uuid.UUID         `gorm:"type:uuid;primaryKey" json:"applicationId"`
+	Rank          int               `gorm:"not null" json:"rank"`
+	StudentID     uint              `gorm:"not null" json:"studentId"`
+	Faculty       string            `gorm:"not null" json:"faculty"`
+	Points        float64           `gorm:"type:numeric(8,2);not null" json:"points"`
+	Outcome       AllocationOutcome `gorm:"type:varchar(20);not null" json:"outcome"`
+	DormID        *uuid.UUID        `gorm:"type:uuid" json:"dormId,omitempty"`
+	RoomID        *uuid.UUID        `gorm:"type:uuid" json:"roomId,omitempty"`
+	Reason        string            `gorm:"not null" json:"reason,omitempty"`
+}
+
+type RankingKind string
+
+const (
+	RankingProvisional RankingKind = "PROVISIONAL"
+	RankingFinal       RankingKind = "FINAL"
+)
+
+// RankingList je objavljena rang lista konkursa (vidi student/ranking.go). Redovi su snimak
+// ishoda konkursa zakljucno sa raspodelom RunID i posle se ne menjaju; Version raste sa svakom objavom.
+type RankingList struct {
+	ID            uuid.UUID   `gorm:"type:uuid;primaryKey" json:"id"`
+	CompetitionID uuid.UUID   `gorm:"type:uuid;not null;uniqueIndex:idx_ranking_lists_version" json:"competitionId"`
+	Version       int         `gorm:"not null;uniqueIndex:idx_ranking_lists_version" json:"version"`
+	Kind          RankingKind `gorm:"type:varchar(20);not null" json:"kind"`
+	RunID         uuid.UUID   `gorm:"type:uuid;not null" json:"runId"`
+	// AppealsCloseAt postoji samo za privremenu listu
+	AppealsCloseAt *time.Time `json:"appealsCloseAt,omitempty"`
+	PublishedBy    uint       `gorm:"not null" json:"-"`
+	PublishedAt    time.Time  `gorm:"not null" json:"publishedAt"`
+
+	Rows []RankingRow `gorm:"foreignKey:ListID;constraint:OnDelete:CASCADE" json:"rows,omitempty"`
+}
+
+// RankingRow je pseudonimizovan red liste: javno se vide samo broj indeksa i bodovi.
+type RankingRow struct {
+	ListID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"-"`
+	Rank          int       `gorm:"primaryKey;autoIncrement:false" json:"rank"`
+	Index         string    `gorm:"column:index;not null" json:"index"`
+	Points        float64   `gorm:"type:numeric(8,2);not null" json:"points"`
+	ApplicationID uuid.UUID `gorm:"type:uuid;not null" json:"-"`
+}
+
+// PublishRankingReq: bez RunID se objavljuje poslednja raspodela konkursa. AppealsCloseAt
+// vazi samo za privremenu listu (podrazumevano defaultAppealWindow od objave).
+type PublishRankingReq struct {
+	Kind           RankingKind `json:"kind"`
+	RunID          *uuid.UUID  `json:"runId"`
+	AppealsCloseAt *time.Time  `json:"appealsCloseAt"`
+}
+
+type AppealStatus string
+
+const (
+	AppealSubmitted   AppealStatus = "SUBMITTED"
+	AppealUnderReview AppealStatus = "UNDER_REVIEW"
+	AppealAccepted    AppealStatus = "ACCEPTED"
+	AppealRejected    AppealStatus = "REJECTED"
+)
+
+// Appeal je zalba studenta na privremenu rang listu (ListID); jedna po studentu i listi.
+type Appeal struct {
+	ID            uuid.UUID    `gorm:"type:uuid;primaryKey" json:"id"`
+	ListID        uuid.UUID    `gorm:"type:uuid;not null;index" json:"listId"`
+	CompetitionID uuid.UUID    `gorm:"type:uuid;not null;index" json:"competitionId"`
+	ApplicationID uuid.UUID    `gorm:"type:uuid;not null" json:"applicationId"`
+	StudentID     uint         `gorm:"not null;index" json:"studentId"`
+	Text          string       `gorm:"not null" json:"text"`
+	Status        AppealStatus `gorm:"type:varchar(20);not null" json:"status"`
+	Response      string       `gorm:"not null" json:"response,omitempty"`
+	ReviewedBy    *uint        `json:"reviewedBy,omitempty"`
+	ReviewedAt    *time.Time   `json:"reviewedAt,omitempty"`
+	CreatedAt     time.Time    `gorm:"not null" json:"createdAt"`
+
+	Attachments []AppealAttachment `gorm:"foreignKey:AppealID;constraint:OnDelete:CASCADE" json:"attachments"`
+}
+
+// AppealAttachment je prilog uz zalbu; sadrzaj se cuva u bazi i vraca samo preko download rute.
+type AppealAttachment struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	AppealID    uuid.UUID `gorm:"type:uuid;not null;index" json:"-"`
+	FileName    string    `gorm:"not null" json:"fileName"`
+	ContentType string    `gorm:"not null" json:"contentType"`
+	Size        int64     `gorm:"not null" json:"size"`
+	Data        []byte    `gorm:"not null" json:"-"`
+	CreatedAt   time.Time `gorm:"not null" json:"createdAt"`
+}
+
+// AppealReviewReq: Decision je UNDER_REVIEW, ACCEPTED ili REJECTED; odluka trazi obrazlozenje.
+type AppealReviewReq struct {
+	Decision AppealStatus `json:"decision"`
+	Response string       `json:"response"`
 }
 
 type Payment struct {
@@ -77,18 +397,34 @@ type Payment struct {
 	ApplicationID uuid.UUID `gorm:"unique;not null" json:"applicationId"`
 }
 
-type ChangePassReq struct {
-	NewPassword string `json:"newPassword"`
-	OldPassword string `json:"oldPassword"`
-}
-
 /* ========== Enum ========== */
 
+// ApplicationStatus se menja samo kroz tranzicije (vidi student/lifecycle.go).
 type ApplicationStatus string
 
 const (
-	StatusSubmitted ApplicationStatus = "SUBMITTED"
-	StatusAccepted  ApplicationStatus = "ACCEPTED"
-	StatusRejected  ApplicationStatus = "REJECTED"
-	StatusReserved  ApplicationStatus = "RESERVED"
+	StatusDraft       ApplicationStatus = "DRAFT"
+	StatusSubmitted   ApplicationStatus = "SUBMITTED"
+	StatusUnderReview ApplicationStatus = "UNDER_REVIEW"
+	StatusAccepted    ApplicationStatus = "ACCEPTED"
+	StatusWaitlisted  ApplicationStatus = "WAITLISTED" // ispunjava uslove, ali nije bilo mesta
+	StatusRejected    ApplicationStatus = "REJECTED"
+	StatusReserved    ApplicationStatus = "RESERVED"
+	StatusWithdrawn   ApplicationStatus = "WITHDRAWN"
+	StatusCancelled   ApplicationStatus = "CANCELLED"
+)
+
+type Transition string
+
+const (
+	TransitionCreate   Transition = "create" // samo u istoriji: nastanak prijave
+	TransitionSubmit   Transition = "submit"
+	TransitionReview   Transition = "review"
+	TransitionAccept   Transition = "accept"
+	TransitionReject   Transition = "reject"
+	TransitionWaitlist Transition = "waitlist"
+	TransitionReopen   Transition = "reopen" // ponovni pregled, npr. posle usvojene zalbe
+	TransitionReserve  Transition = "reserve"
+	TransitionWithdraw Transition = "withdraw"
+	TransitionCancel   Transition = "cancel"
 )
@@ -0,0 +1,88 @@
+package upstream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ErrNotFound: auth ne poznaje trazeni nalog.
+var ErrNotFound = errors.New("not found")
+
+// AuthClient poziva interne endpointe auth servisa koji nisu dostupni spolja.
+type AuthClient struct {
+	base  string
+	httpc *http.Client
+}
+
+// Identity prati types.UserIdentity iz auth servisa.
+type Identity struct {
+	ID        uint      `json:"id"`
+	Email     string    `json:"email"`
+	FirstName string    `json:"firstName"`
+	LastName  string    `json:"lastName"`
+	Role      string    `json:"role"`
+	Status    string    `json:"status"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// Enrollment postoji samo za naloge iz uvoza spiska upisanih studenata
+	Enrollment *Enrollment `json:"enrollment,omitempty"`
+}
+
+type Enrollment struct {
+	Index   string `json:"index"`
+	Faculty string `json:"faculty"`
+}
+
+func NewAuthClient(base string, timeout time.Duration) *AuthClient {
+	return &AuthClient{
+		base:  base,
+		httpc: &http.Client{Timeout: timeout},
+	}
+}
+
+// User vraca jedan nalog (interni endpoint).
+func (a *AuthClient) User(ctx context.Context, userID uint) (Identity, error) {
+	var out Identity
+	err := a.get(ctx, fmt.Sprintf("/internal/users/%d", userID), &out)
+	return out, err
+}
+
+// UsersSince vraca sledecu stranicu naloga izmenjenih posle kursora (since, afterID).
+// Nulti since vraca sve naloge od pocetka.
+func (a *AuthClient) UsersSince(ctx context.Context, since time.Time, afterID uint) ([]Identity, error) {
+	q := url.Values{}
+	if !since.IsZero() {
+		q.Set("since", since.Format(time.RFC3339Nano))
+		q.Set("afterId", fmt.Sprint(afterID))
+	}
+	var out struct {
+		Items []Identity `json:"items"`
+	}
+	err := a.get(ctx, "/internal/users?"+q.Encode(), &out)
+	return out.Items, err
+}
+
+func (a *AuthClient) get(ctx context.Context, path string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.base+path, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := a.httpc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("auth upstream status %d", res.StatusCode)
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}